@@ -0,0 +1,187 @@
+package statsd
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// WithAggregation buffer the gauges and timers like the counters and send
+// them once per window, which replaces the flush interval: per metric and tags
+// the counters are summed, the last value of the gauges is kept and the
+// timer samples are collected and sent together at the end of the window.
+// It cuts the packets of the hosts emitting the same metrics at a high rate.
+func WithAggregation(window time.Duration) Option {
+	return func(c *Client) {
+		c.aggregate = true
+		c.flushInterval = window
+	}
+}
+
+// WithBufferedMetrics buffer the gauges and timers like the counters until
+// the next flush of the flush interval, as WithAggregation does for its own
+// window: the last value of a gauge wins and the samples of a timer are
+// written together, instead of a write per call.
+func WithBufferedMetrics() Option {
+	return func(c *Client) {
+		c.aggregate = true
+	}
+}
+
+// WithTimerPercentiles send, with WithAggregation, the statistics of the
+// timer samples of each window in place of the samples, as the gauges
+// <stat>.count, .min, .max, .mean, .p95 and .p99 for the backends (e.g. plain
+// Graphite) which don't aggregate the timers themselves. The count is scaled
+// by the sample rate.
+func WithTimerPercentiles() Option {
+	return func(c *Client) {
+		c.percentiles = true
+	}
+}
+
+// gaugeBuffer is the last value of a gauge in the window
+type gaugeBuffer struct {
+	name  string
+	tags  string
+	value interface{} // int64 or float64
+	rate  float32
+}
+
+// timerBuffer is the samples of a timer in the window
+type timerBuffer struct {
+	name    string
+	tags    string
+	rate    float32 // sample rate of the first sample
+	samples []int64
+}
+
+// addGauge keep value as the last value of the gauge
+func (c *Client) addGauge(stat string, value interface{}, sampleRate float32, tags []string) error {
+	stat, tags = c.hashName(stat, tags)
+	stat = c.bucket(stat)
+	joined := joinTags(tags)
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	k := countKey{name: stat, tags: joined} // the last rate wins with the value
+	if i, ok := c.gaugeIndex[k]; ok {
+		c.gauges[i].value = value
+		c.gauges[i].rate = sampleRate
+		return nil
+	}
+	if c.gaugeIndex == nil {
+		c.gaugeIndex = make(map[countKey]int)
+	}
+	c.gaugeIndex[k] = len(c.gauges)
+	c.gauges = append(c.gauges, gaugeBuffer{stat, joined, value, sampleRate})
+	c.buffered(stat, joined)
+	return nil
+}
+
+// addTimer collect a sample of the timer
+func (c *Client) addTimer(stat string, delta int64, sampleRate float32, tags []string) error {
+	stat, tags = c.hashName(stat, tags)
+	stat = c.bucket(stat)
+	joined := joinTags(tags)
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	c.buffered(stat, joined)
+	k := countKey{name: stat, tags: joined}
+	if i, ok := c.timerIndex[k]; ok {
+		c.timers[i].samples = append(c.timers[i].samples, delta)
+		return nil
+	}
+	if c.timerIndex == nil {
+		c.timerIndex = make(map[countKey]int)
+	}
+	c.timerIndex[k] = len(c.timers)
+	c.timers = append(c.timers, timerBuffer{stat, joined, sampleRate, []int64{delta}})
+	return nil
+}
+
+// aggregateLines append the lines of the buffered gauges and timers
+func (c *Client) aggregateLines(lines []string, b buffers) []string {
+	for _, g := range b.gauges {
+		line := c.formatLine(g.name, g.value, "g", g.rate, g.tags)
+		if isNegative(g.value) && !c.allowNegative {
+			// a single line for packLines, so that both are in the same packet
+			line = c.formatLine(g.name, 0, "g", 1, g.tags) + "\n" + line
+		}
+		lines = append(lines, line)
+	}
+	for _, t := range b.timers {
+		if c.percentiles {
+			lines = c.percentileLines(lines, t)
+			continue
+		}
+		for _, sample := range t.samples {
+			lines = append(lines, c.formatLine(t.name, sample, "ms", t.rate, t.tags))
+		}
+	}
+	return lines
+}
+
+// percentileLines append the gauges of the statistics of the timer samples
+func (c *Client) percentileLines(lines []string, t timerBuffer) []string {
+	samples := t.samples
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	var sum int64
+	for _, sample := range samples {
+		sum += sample
+	}
+	count := int64(float64(len(samples))/float64(t.rate) + 0.5)
+	mean := float64(sum) / float64(len(samples))
+
+	for _, g := range []struct {
+		suffix string
+		value  interface{}
+	}{
+		{"count", count},
+		{"min", samples[0]},
+		{"max", samples[len(samples)-1]},
+		{"mean", mean},
+		{"p95", percentile(samples, 0.95)},
+		{"p99", percentile(samples, 0.99)},
+	} {
+		lines = append(lines, c.formatLine(t.name+"."+g.suffix, g.value, "g", 1, t.tags))
+	}
+	return lines
+}
+
+// percentile return the nearest-rank percentile p of the sorted samples
+func percentile(samples []int64, p float64) int64 {
+	rank := int(math.Ceil(p * float64(len(samples))))
+	if rank < 1 {
+		rank = 1
+	}
+	return samples[rank-1]
+}
+
+// float return the value of the gauge as a float64
+func (g gaugeBuffer) float() float64 {
+	switch v := g.value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func isNegative(value interface{}) bool {
+	switch v := value.(type) {
+	case int64:
+		return v < 0
+	case float64:
+		return v < 0
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WithAggregation(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithAggregation(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Incr("hits", 1)
+	c.Incr("hits", 1)
+	c.Gauge("queue", 3)
+	c.Gauge("queue", 5)
+	c.FGauge("temp", -1.5, "room:a")
+	c.Timing("db", 4)
+	c.Timing("db", 6)
+
+	// nothing is sent before the end of the window
+	l.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := l.ReadFrom(make([]byte, 1024)); err == nil {
+		t.Fatalf("sent before the end of the window: %d bytes", n)
+	}
+
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"api.hits:2|c",
+		"api.queue:5|g",
+		"api.temp:0|g|#room:a",
+		"api.temp:-1.5|g|#room:a",
+		"api.db:4|ms",
+		"api.db:6|ms",
+	}
+	if got := readPacket(t, l); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if len(c.buffer)+len(c.gauges)+len(c.timers) != 0 {
+		t.Fatal("buffers not emptied by the flush")
+	}
+}
+
+func Test_WithBufferedMetrics(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithFlushInterval(time.Hour), WithBufferedMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.flushInterval != time.Hour {
+		t.Fatalf("flush interval: %s <=> want: %s", c.flushInterval, time.Hour)
+	}
+
+	want := strings.Join([]string{"queue:5|g", "queue:5|g|#q:b", "db:4|ms", "db:6|ms"}, "\n")
+	for i := 0; i < 2; i++ { // the second window checks the reset of the indexes
+		c.Gauge("queue", 3)
+		c.Gauge("queue", 5)
+		c.Gauge("queue", 5, "q:b")
+		c.Timing("db", 4)
+		c.Timing("db", 6)
+		if err := c.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := readPacket(t, l); got != want {
+			t.Fatalf("window %d, got:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+}
+
+func Test_WithTimerPercentiles(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithAggregation(time.Hour), WithTimerPercentiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 100; i > 0; i-- {
+		c.Timing("db", int64(i), "op:select")
+	}
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"db.count:100|g|#op:select",
+		"db.min:1|g|#op:select",
+		"db.max:100|g|#op:select",
+		"db.mean:50.5|g|#op:select",
+		"db.p95:95|g|#op:select",
+		"db.p99:99|g|#op:select",
+	}
+	if got := readPacket(t, l); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	tests := []struct {
+		samples []int64
+		p       float64
+		want    int64
+	}{
+		{[]int64{7}, 0.99, 7},
+		{[]int64{1, 2}, 0.5, 1},
+		{[]int64{1, 2, 3, 4}, 0.95, 4},
+		{[]int64{1, 2, 3, 4}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(tt.samples, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %v): %d <=> want: %d", tt.samples, tt.p, got, tt.want)
+		}
+	}
+}
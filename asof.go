@@ -0,0 +1,25 @@
+package statsd
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimestampNotSupported is returned by AsOf when the lines carry no timestamp
+var ErrTimestampNotSupported = errors.New("metric timestamps not enabled, see ExtTimestamp")
+
+// AsOf return a child client, with the prefix of c, whose metrics are dated
+// t instead of the time of the call, for the batch and backfill jobs to
+// produce back-dated series rather than a spike at run time. It needs the
+// server to read the timestamp of ExtTimestamp, if not enabled it returns
+// ErrTimestampNotSupported. The counters of the child are written at once
+// instead of buffered, and its gauges and timers are not aggregated.
+func (c *Client) AsOf(t time.Time) (*Client, error) {
+	if !c.extensions.Has(ExtTimestamp) {
+		return nil, ErrTimestampNotSupported
+	}
+
+	child := c.WithPrefix("")
+	child.asOf = t
+	return child, nil
+}
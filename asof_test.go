@@ -0,0 +1,46 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_AsOf(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("job"), WithExtensions(ExtTimestamp), WithAggregation(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	past, err := c.AsOf(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	past.Incr("rows", 1)
+	past.WithPrefix("import").Gauge("lag", 3)
+	stamp := "|T" + "1704153600"
+	for _, want := range []string{
+		"job.rows:1|c" + stamp,
+		"job.import.lag:3|g" + stamp,
+	} {
+		if got := readPacket(t, l); got != want {
+			t.Fatalf("got: %s <=> want: %s", got, want)
+		}
+	}
+
+	// the parent is still dated at the flush
+	c.Incr("rows", 1)
+	c.flush()
+	if got := readPacket(t, l); strings.HasSuffix(got, stamp) {
+		t.Fatalf("got: %s <=> want: dated now", got)
+	}
+
+	c2, _ := newTestClient(t, "")
+	if _, err := c2.AsOf(day); err != ErrTimestampNotSupported {
+		t.Fatalf("err: %v <=> want: %v", err, ErrTimestampNotSupported)
+	}
+}
@@ -0,0 +1,153 @@
+package statsd
+
+import "time"
+
+// WithMaxBatchDelay hold the lines sent one by one (timers, gauges, ...) at
+// most d before writing them, together in a packet, instead of one packet per
+// line. A packet is written as soon as it is full, a partial one once d has
+// passed since it received its first line: the knob between one packet per
+// metric (0, the default) and waiting for full packets, for the metrics
+// alerting in near real-time.
+func WithMaxBatchDelay(d time.Duration) Option {
+	return func(c *Client) {
+		c.batchDelay = d
+	}
+}
+
+// batchLine add line to the pending packet, writing the packet first if line
+// doesn't fit in it
+func (c *Client) batchLine(line []byte) error {
+	c.m.Lock()
+	if c.closed.Load() {
+		c.m.Unlock()
+		return ErrClosed
+	}
+
+	var full []byte
+	if len(c.batch) > 0 && len(c.batch)+1+len(line) > c.maxPacketSize {
+		full = c.batch
+		c.batch = nil
+	}
+	if len(c.batch) > 0 {
+		c.batch = append(c.batch, '\n')
+	}
+	c.batch = append(c.batch, line...)
+
+	// the timer of the previous packet is kept, a line never waits longer than d
+	if c.batchTimer == nil {
+		c.batchTimer = time.AfterFunc(c.batchDelay, c.flushBatch)
+	}
+	c.m.Unlock()
+
+	if full == nil {
+		return nil
+	}
+	return c.write(full)
+}
+
+// flushBatch write the pending packet
+func (c *Client) flushBatch() {
+	c.m.Lock()
+	batch := c.takeBatch()
+	c.m.Unlock()
+
+	if len(batch) > 0 {
+		c.handleError(c.write(batch))
+	}
+}
+
+// takeBatch empty the pending packet, c.m must be held
+func (c *clientConn) takeBatch() []byte {
+	if c.batchTimer != nil {
+		c.batchTimer.Stop()
+		c.batchTimer = nil
+	}
+	batch := c.batch
+	c.batch = nil
+	return batch
+}
+
+// Batch collect metrics to write them at once, see Client.Batch. A Batch
+// without client, given by SendBatch when disabled, collects nothing.
+type Batch struct {
+	c     *Client
+	lines []string
+	err   error
+}
+
+// Batch call fn to collect metrics then write them in a single packet, even
+// if larger than the max packet size, so that a server gets all of them or
+// none. The metrics of a batch are not sampled and the counters are not
+// buffered, the filtered ones are left out. It returns the first invalid
+// count or name given to the batch, or ErrBudgetExceeded, which then writes
+// nothing, or the error of the write.
+func (c *Client) Batch(fn func(b *Batch)) error {
+	b := &Batch{c: c}
+	fn(b)
+	if b.err != nil {
+		return b.err
+	}
+	if len(b.lines) == 0 {
+		return nil
+	}
+	return c.sendPacket(b.lines)
+}
+
+// Incr add a counter to the batch
+func (b *Batch) Incr(stat string, count int64, tags ...string) {
+	if err := checkCount(count); err != nil {
+		b.fail(err)
+		return
+	}
+	b.add(stat, count, "c", tags)
+}
+
+// Decr add a decrement of a counter to the batch
+func (b *Batch) Decr(stat string, count int64, tags ...string) {
+	if err := checkCount(count); err != nil {
+		b.fail(err)
+		return
+	}
+	b.add(stat, -count, "c", tags)
+}
+
+// Timing add a timer to the batch, the delta must be given in milliseconds
+func (b *Batch) Timing(stat string, delta int64, tags ...string) {
+	b.add(stat, delta, "ms", tags)
+}
+
+// Gauge add a gauge to the batch, a negative value is set by first setting it to zero
+func (b *Batch) Gauge(stat string, value int64, tags ...string) {
+	if value < 0 && (b.c == nil || !b.c.allowNegative) {
+		b.add(stat, 0, "g", tags)
+	}
+	b.add(stat, value, "g", tags)
+}
+
+// FGauge add a floating point gauge to the batch
+func (b *Batch) FGauge(stat string, value float64, tags ...string) {
+	if value < 0 && (b.c == nil || !b.c.allowNegative) {
+		b.add(stat, 0, "g", tags)
+	}
+	b.add(stat, value, "g", tags)
+}
+
+func (b *Batch) add(stat string, value interface{}, t string, tags []string) {
+	if b.c == nil {
+		return
+	}
+	stat, ok, err := b.c.admit(stat, tags)
+	if !ok {
+		if err != nil {
+			b.fail(newSendError(err, "Batch", stat, value))
+		}
+		return
+	}
+	b.lines = append(b.lines, b.c.format(stat, value, t, 1, tags...))
+}
+
+func (b *Batch) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,132 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WithMaxBatchDelay(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithMaxBatchDelay(50*time.Millisecond), WithMaxPacketSize(24))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the partial packet is written once the delay has passed
+	start := time.Now()
+	c.Timing("db", 4)
+	c.Gauge("queue", 3)
+	want := "db:4|ms\nqueue:3|g"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("written after %s, before the delay", elapsed)
+	}
+
+	// a full packet is written at once
+	c.Timing("db", 1)
+	c.Timing("db", 2)
+	c.Timing("db", 3)
+	c.Timing("db", 4)
+	want = "db:1|ms\ndb:2|ms\ndb:3|ms"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// and the rest is sent on close
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPacket(t, l); !strings.HasPrefix(got, "db:4|ms") {
+		t.Fatalf("got: %s <=> want: db:4|ms", got)
+	}
+}
+
+func Test_WithMaxBatchDelayInvalid(t *testing.T) {
+	if _, err := New("127.0.0.1:8125", WithMaxBatchDelay(-time.Second)); err != ErrInvalidBatchDelay {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidBatchDelay)
+	}
+}
+
+func Test_Batch(t *testing.T) {
+	c, l := newTestClient(t, "shop")
+	c.maxPacketSize = 16 // a batch is never split
+
+	err := c.Batch(func(b *Batch) {
+		b.Incr("orders", 2, "shop:a")
+		b.Decr("stock", 1)
+		b.Timing("checkout", 12)
+		b.Gauge("queue", -1)
+		b.FGauge("ratio", 0.5)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"shop.orders:2|c|#shop:a",
+		"shop.stock:-1|c",
+		"shop.checkout:12|ms",
+		"shop.queue:0|g",
+		"shop.queue:-1|g",
+		"shop.ratio:0.5|g",
+	}
+	if got := readPacket(t, l); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// an invalid count drops the whole batch
+	err = c.Batch(func(b *Batch) {
+		b.Incr("orders", 1)
+		b.Incr("orders", 0)
+	})
+	if err != ErrInvalidCount {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidCount)
+	}
+}
+
+func Test_SendBatch(t *testing.T) {
+	l := setupTestDefault(t, &Config{Project: "shop", Enable: true})
+
+	SendBatch(func(b *Batch) {
+		b.Incr("orders", 1)
+		b.Gauge("queue", 3)
+	})
+	want := "shop.orders:1|c\nshop.queue:3|g"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// disabled, fn is still called
+	setupTestDefault(t, &Config{Project: "shop"})
+	called := false
+	SendBatch(func(b *Batch) {
+		called = true
+		b.Incr("orders", 1)
+	})
+	if !called {
+		t.Fatal("fn not called while disabled")
+	}
+}
+
+func Test_BatchFiltered(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithDeny("debug.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	err = c.Batch(func(b *Batch) {
+		b.Gauge("debug.loops", 1)
+		b.Gauge("queue", 2)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPacket(t, l), "queue:2|g"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package statsd
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned for the metrics shed by the budget of their scope
+var ErrBudgetExceeded = errors.New("metric dropped, budget of the scope exceeded")
+
+// Budget bound what a scope may send per interval
+type Budget struct {
+	Metrics  int64         // metrics per interval, unlimited if 0
+	Bytes    int64         // estimated bytes of the lines per interval, unlimited if 0
+	Interval time.Duration // 1s if 0
+}
+
+// WithBudget return a child client, with the prefix of c, whose metrics are
+// limited by b under the scope name: past the budget of the interval, the
+// calls shed their metric and return ErrBudgetExceeded, so that one team's
+// instrumentation can't crowd out another's in a shared process. The
+// children of the child share its budget. With WithTelemetry, each flush
+// sends statsd.client.budget.shed tagged scope:<name>.
+func (c *Client) WithBudget(name string, b Budget) *Client {
+	if b.Interval <= 0 {
+		b.Interval = time.Second
+	}
+	s := &scopeBudget{name: name, budget: b, clock: c.clock}
+
+	c.scopesMu.Lock()
+	c.scopes = append(c.scopes, s)
+	c.scopesMu.Unlock()
+
+	child := c.WithPrefix("")
+	child.budget = s
+	return child
+}
+
+// scopeBudget is the budget of a scope and its usage
+type scopeBudget struct {
+	name   string
+	budget Budget
+	clock  Clock // of the client, starting the intervals
+
+	m       sync.Mutex
+	start   time.Time // of the current interval
+	metrics int64
+	bytes   int64
+	shed    int64 // since the last flush
+}
+
+// allow tell if the scope may send a metric of an estimated size
+func (s *scopeBudget) allow(size int64) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if now := s.clock.Now(); now.Sub(s.start) >= s.budget.Interval {
+		s.start, s.metrics, s.bytes = now, 0, 0
+	}
+	if (s.budget.Metrics > 0 && s.metrics+1 > s.budget.Metrics) ||
+		(s.budget.Bytes > 0 && s.bytes+size > s.budget.Bytes) {
+		s.shed++
+		return false
+	}
+	s.metrics++
+	s.bytes += size
+	return true
+}
+
+// allow tell if the budget of the scope of c, if any, may send the metric
+func (c *Client) allow(stat string, tags []string) bool {
+	if c.budget == nil {
+		return true
+	}
+	return c.budget.allow(estimateLine(c.prefix, stat, tags))
+}
+
+// estimateLine estimate the size of a line without formatting it
+func estimateLine(prefix string, stat string, tags []string) int64 {
+	const valueAndType = 16 // ":<value>|<type>|@<rate>"
+
+	n := len(prefix) + 1 + len(stat) + valueAndType
+	for _, tag := range tags {
+		n += len(tag) + 1
+	}
+	return int64(n)
+}
+
+// budgetLines append the metrics shed by each scope since the last flush
+func (c *Client) budgetLines() []string {
+	c.scopesMu.Lock()
+	scopes := append([]*scopeBudget(nil), c.scopes...)
+	c.scopesMu.Unlock()
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].name < scopes[j].name })
+
+	lines := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s.m.Lock()
+		shed := s.shed
+		s.shed = 0
+		s.m.Unlock()
+
+		lines = append(lines, c.format("statsd.client.budget.shed", strconv.FormatInt(shed, 10), "c", 1, "scope:"+s.name))
+	}
+	return lines
+}
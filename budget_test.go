@@ -0,0 +1,80 @@
+package statsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WithBudget(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithTelemetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	search := c.WithPrefix("search").WithBudget("search", Budget{Metrics: 2, Interval: time.Hour})
+	orders := c.WithPrefix("orders").WithBudget("orders", Budget{Bytes: 40, Interval: time.Hour})
+
+	if err := search.Timing("latency", 1); err != nil {
+		t.Fatal(err)
+	}
+	// the children share the budget of the scope
+	if err := search.WithPrefix("index").Timing("latency", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := search.Timing("latency", 1); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrBudgetExceeded)
+	}
+
+	// the other scopes and the parent keep theirs
+	if err := orders.Timing("latency", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Timing("latency", 1, "shop:a"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrBudgetExceeded)
+	}
+	if err := c.Timing("latency", 1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		readPacket(t, l)
+	}
+
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+	got := readPacket(t, l)
+	for _, want := range []string{
+		"statsd.client.budget.shed:1|c|#scope:orders\nstatsd.client.budget.shed:1|c|#scope:search",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("telemetry:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func Test_BudgetInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	s := c.WithBudget("search", Budget{Metrics: 1, Interval: time.Minute}).budget
+	if !s.allow(1) || s.allow(1) {
+		t.Fatal("budget of 1 metric not enforced")
+	}
+	clock.Tick(59 * time.Second)
+	if s.allow(1) {
+		t.Fatal("budget renewed before the end of the interval")
+	}
+	clock.Tick(time.Second)
+	if !s.allow(1) {
+		t.Fatal("budget not renewed after the interval")
+	}
+}
@@ -0,0 +1,41 @@
+package statsd
+
+// WithMaxBufferedMetrics flush the buffers as soon as they hold n metrics,
+// without waiting for the flush interval: the counters and gauges count once
+// per name and tags, the timers once per sample. It bounds the memory and the
+// delay of the bursts on long flush intervals. 0, the default, sets no limit.
+func WithMaxBufferedMetrics(n int) Option {
+	return func(c *Client) {
+		c.maxMetrics = n
+	}
+}
+
+// WithMaxBufferedBytes flush the buffers as soon as the lines they hold reach
+// about n bytes, see WithMaxBufferedMetrics. 0, the default, sets no limit.
+func WithMaxBufferedBytes(n int) Option {
+	return func(c *Client) {
+		c.maxBytes = n
+	}
+}
+
+// bufferedLineOverhead is the estimate of the bytes of a buffered line besides
+// its bucket and tags: the value, the type, the sample rate and separators
+const bufferedLineOverhead = 16
+
+// buffered account for a new line in the buffers and trigger a flush once over
+// the limits, c.m must be held
+func (c *clientConn) buffered(stat string, joined string) {
+	if c.maxMetrics == 0 && c.maxBytes == 0 {
+		return
+	}
+
+	c.bufferedCount++
+	c.bufferedBytes += len(stat) + len(joined) + bufferedLineOverhead
+	if (c.maxMetrics > 0 && c.bufferedCount >= c.maxMetrics) ||
+		(c.maxBytes > 0 && c.bufferedBytes >= c.maxBytes) {
+		select {
+		case c.flushNow <- struct{}{}:
+		default: // a flush is pending already
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WithMaxBufferedMetrics(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		send func(c *Client)
+		want []string
+	}{
+		{
+			name: "counters",
+			opts: []Option{WithMaxBufferedMetrics(3)},
+			send: func(c *Client) {
+				c.Incr("a", 1)
+				c.Incr("a", 1) // the same line
+				c.Incr("b", 1)
+				c.Decr("c", 1)
+			},
+			want: []string{"a:2|c", "b:1|c", "c:-1|c"},
+		},
+		{
+			name: "timer samples",
+			opts: []Option{WithMaxBufferedMetrics(2), WithBufferedMetrics()},
+			send: func(c *Client) {
+				c.Timing("db", 4)
+				c.Timing("db", 6)
+			},
+			want: []string{"db:4|ms", "db:6|ms"},
+		},
+		{
+			name: "bytes",
+			opts: []Option{WithMaxBufferedBytes(2 * (len("hits.1") + bufferedLineOverhead))},
+			send: func(c *Client) {
+				c.Incr("hits.1", 1)
+				c.Incr("hits.2", 1)
+			},
+			want: []string{"hits.1:1|c", "hits.2:1|c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, l := newTestClient(t, "")
+			c, err := New(l.LocalAddr().String(), append(tt.opts, WithFlushInterval(time.Hour))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			tt.send(c)
+			if got, want := readPacket(t, l), strings.Join(tt.want, "\n"); got != want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func Test_WithMaxBufferedMetricsBelowLimit(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithFlushInterval(time.Hour), WithMaxBufferedMetrics(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Incr("a", 1)
+	c.Incr("b", 1)
+	l.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := l.ReadFrom(make([]byte, 1024)); err == nil {
+		t.Fatalf("flushed below the limit: %d bytes", n)
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithMaxBufferedBytes(-1)); err != ErrInvalidBufferLimit {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidBufferLimit)
+	}
+}
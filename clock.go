@@ -0,0 +1,42 @@
+package statsd
+
+import "time"
+
+// Clock is the time source of a client, see WithClock
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is a ticker of a Clock, as a time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// WithClock make the client read the time from clock instead of the system,
+// for the tests to control the flushes, the gauge pollers, the sample
+// schedules and hash windows, the aggregate windows, the budget intervals and
+// the timestamps
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// systemClock is the Clock of time.Now and time.NewTicker, the default
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+
+func (t systemTicker) Stop() { t.t.Stop() }
@@ -0,0 +1,80 @@
+package statsd
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock moved by the tests, its tickers tick on Tick only
+type fakeClock struct {
+	m       sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+type fakeTicker struct {
+	c chan time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) NewTicker(d time.Duration) Ticker {
+	f.m.Lock()
+	defer f.m.Unlock()
+	t := &fakeTicker{c: make(chan time.Time, 1)}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Tick move the clock by d and tick the tickers
+func (f *fakeClock) Tick(d time.Duration) {
+	f.m.Lock()
+	f.now = f.now.Add(d)
+	now, tickers := f.now, f.tickers
+	f.m.Unlock()
+	for _, t := range tickers {
+		t.c <- now
+	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {}
+
+func Test_WithClock(t *testing.T) {
+	_, l := newTestClient(t, "")
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	c, err := New(l.LocalAddr().String(), WithClock(clock), WithExtensions(ExtTimestamp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var windows []Aggregate
+	c.Watch("hits", func(a Aggregate) { windows = append(windows, a) })
+
+	if err := c.Incr("hits", 2); err != nil {
+		t.Fatal(err)
+	}
+	// nothing is flushed until the clock ticks
+	l.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, _, err := l.ReadFrom(make([]byte, 1024)); err == nil {
+		t.Fatal("flushed before the tick")
+	}
+
+	clock.Tick(5 * time.Second)
+	if got, want := readPacket(t, l), "hits:2|c|T1700000005"; got != want {
+		t.Fatalf("packet: %q <=> want: %q", got, want)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) == 0 || !windows[0].Start.Equal(time.Unix(1700000000, 0)) || !windows[0].End.Equal(time.Unix(1700000005, 0)) {
+		t.Fatalf("windows: %+v", windows)
+	}
+}
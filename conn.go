@@ -1,162 +1,687 @@
 package statsd
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"errors"
-	"fmt"
-	"math/rand"
+	"log/slog"
+	"math"
+	"math/rand/v2"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // errors
 var (
-	ErrNotConnected      = errors.New("cannot send stats, not connected to StatsD server")
-	ErrInvalidCount      = errors.New("count is less than 0")
-	ErrInvalidSampleRate = errors.New("sample rate is larger than 1 or less then 0")
+	ErrNotConnected           = errors.New("cannot send stats, not connected to StatsD server")
+	ErrInvalidCount           = errors.New("count is less than 0")
+	ErrInvalidSampleRate      = errors.New("sample rate is larger than 1 or less then 0")
+	ErrInvalidFlushInterval   = errors.New("flush interval is less than or equal to 0")
+	ErrInvalidPacketSize      = errors.New("max packet size is less than or equal to 0")
+	ErrClosed                 = errors.New("cannot send stats, client is closed")
+	ErrInvalidExtensionSchema = errors.New("extension schema version is less than 0")
+	ErrInvalidBatchDelay      = errors.New("max batch delay is less than 0")
+	ErrInvalidQueueSize       = errors.New("queue size is less than or equal to 0")
+	ErrInvalidTimingUnit      = errors.New("timing unit is less than or equal to 0")
+	ErrInvalidInterval        = errors.New("interval is less than or equal to 0")
+	ErrInvalidNameLength      = errors.New("max name length is less than 0")
+	ErrInvalidTagLimit        = errors.New("tag value limit is less than or equal to 0")
+	ErrInvalidRateLimit       = errors.New("rate limit is less than 0")
+	ErrInvalidProtocol        = errors.New("unknown protocol")
+	ErrInvalidSendBuffer      = errors.New("send buffer size is less than 0")
+	ErrInvalidTimeout         = errors.New("timeout is less than 0, or 0 for a connect timeout")
+	ErrInvalidRatePrecision   = errors.New("sample rate precision is less than or equal to 0")
+	ErrInvalidBufferLimit     = errors.New("max buffered metrics or bytes is less than 0")
+	ErrInvalidConnections     = errors.New("connections is less than 0")
+)
+
+const (
+	// defaultMaxPacketSize is the largest payload put in a single UDP packet when
+	// several lines are sent together, it keeps packets under the common MTU
+	defaultMaxPacketSize = 1432
+	defaultFlushInterval = 5 * time.Second
 )
 
 // Client is a client library to send events to StatsD
 type Client struct {
-	addr   string
-	prefix string
-	conn   net.Conn
+	prefix       string
+	sampleRate   float32
+	schedule     *SampleSchedule // overrides sampleRate by time of day, if set
+	child        bool            // created by WithPrefix, doesn't own the connection
+	budget       *scopeBudget    // set by WithBudget, shared with the children
+	asOf         time.Time       // timestamp of the lines set by AsOf, now if zero
+	sampleKey    string          // of WithSampleKey, if hasSampleKey
+	hasSampleKey bool
+
+	*clientConn
+}
 
-	buffer      []countBuffer
-	m           sync.Mutex
-	flushticker *time.Ticker
+// clientConn is the part of a client shared with its children:
+// the connection and the counter buffer
+type clientConn struct {
+	network         string
+	addr            string
+	tags            []string
+	flushInterval   time.Duration
+	maxPacketSize   int
+	timingUnit      time.Duration // resolution of TimingDuration, see WithTimingUnit
+	floatTimings    bool          // with WithFloatTimings only
+	ratePrecision   int           // decimals of the sample rates, 0 for the shortest
+	telemetry       bool
+	banner          bool
+	compress        bool // prefix compression, stream networks only
+	extensions      Extension
+	extensionSchema int
+	containerID     string
+	tokenPrefix     string
+	tokenSeq        atomic.Uint64
+	deadLetters     *deadLetters
+	fallback        *fallback
+	rt              *realTime // with WithRealTime only
+	scopesMu        sync.Mutex
+	scopes          []*scopeBudget // of WithBudget
+	watchMu         sync.Mutex
+	watches         []watch            // of Watch
+	windowStart     time.Time          // of the current flush window
+	sampleRates     map[string]float32 // of WithSampleRates
+	rates           *sampleRates       // built from sampleRates
+	hashSampling    bool               // of WithHashSampling
+	rng             *lockedRand        // of WithRand
+	hashInterval    time.Duration      // of WithHashSampling, the flush interval if 0
+	errorHandler    func(err error)
+	logger          *slog.Logger // of WithLogger
+	hasher          *NameHasher
+	sanitizer       func(string) string // of WithSanitizer
+	nameRules       NameRules
+	tagLimit        *tagLimiter // of WithTagLimit
+	allowed         []string    // patterns of WithAllow
+	denied          []string    // patterns of WithDeny
+	rateLimit       RateLimit
+	protocol        Protocol     // of WithProtocol
+	limiter         *rateLimiter // of WithRateLimit, nil if unlimited
+	tlsConfig       *tls.Config
+	dialer          DialFunc // of WithDialer
+	socketOptions   SocketOptions
+	connectTimeout  time.Duration
+	writeTimeout    time.Duration // of WithWriteTimeout, none if 0
+	sink            Sink          // replace conn, see WithSink
+	conn            net.Conn
+	connMu          sync.Mutex      // serialize the writes and reconnections on stream networks
+	connections     int             // of WithConnections
+	spares          chan *spareConn // idle connections of WithConnections besides conn
+	hosts           []string        // of WithHosts
+	backup          string          // of WithFailover
+	mirrors         []string        // of WithMirrors
+	failoverPolicy  FailoverPolicy
+	connStats       connStats
+	stats           clientStats   // of Telemetry
+	gapThreshold    time.Duration // with WithGapDetection only
+	gapStats        gapStats
+
+	buffer        []countBuffer
+	bufferIndex   map[countKey]int // index in buffer of the counters
+	gauges        []gaugeBuffer    // with WithAggregation or WithBufferedMetrics only
+	gaugeIndex    map[countKey]int // index in gauges, by name and tags
+	timers        []timerBuffer    // with WithAggregation or WithBufferedMetrics only
+	timerIndex    map[countKey]int // index in timers, by name and tags
+	counters      []*Counter       // of NewCounter, drained by each flush
+	maxMetrics    int              // of WithMaxBufferedMetrics, 0 for no limit
+	maxBytes      int              // of WithMaxBufferedBytes, 0 for no limit
+	bufferedCount int              // lines buffered since the last flush
+	bufferedBytes int              // estimate of their size
+	flushNow      chan struct{}    // signal the flush loop once over a limit
+	aggregate     bool
+	percentiles   bool // with WithAggregation only
+	sortFlush     bool
+	allowNegative bool          // with WithGaugeAllowNegative only
+	batchDelay    time.Duration // with WithMaxBatchDelay only
+	batch         []byte        // pending packet, guarded by m
+	batchTimer    *time.Timer
+	m             sync.Mutex
+	flushticker   Ticker
+	clock         Clock // of WithClock, the system one by default
+	done          chan struct{}
+	loopDone      chan struct{} // closed when bufferSendLoop returns
+
+	// closeMu is held for writing by Close and for reading by the writes,
+	// so that nothing is written to a closed connection. closed is set with
+	// m held too, so that no counter joins the buffer after the last flush.
+	closeMu   sync.RWMutex
+	closed    atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
 }
 
 type countBuffer struct {
 	name  string
-	count int64
+	count float64 // signed, an int64 unless FIncr is used
+	tags  string  // joined call tags, part of the key with the name
+	rate  float32 // sample rate of the calls, part of the key too
 }
 
-func newClient(addr string, prefix string) (*Client, error) {
-	prefix = strings.TrimRight(prefix, ".")
+// countKey identify a buffered counter
+type countKey struct {
+	name string
+	tags string
+	rate float32
+}
+
+// New connect a client to the StatsD server at addr ("host:port")
+func New(addr string, opts ...Option) (*Client, error) {
+	return NewContext(context.Background(), addr, opts...)
+}
 
+// NewContext is New with a context bounding the connection
+func NewContext(ctx context.Context, addr string, opts ...Option) (*Client, error) {
 	c := &Client{
-		addr:   addr,
-		prefix: prefix,
+		sampleRate: 1,
+		clientConn: &clientConn{
+			network:        "udp",
+			addr:           addr,
+			flushInterval:  defaultFlushInterval,
+			connectTimeout: connectTimeout,
+			clock:          systemClock{},
+			maxPacketSize:  defaultMaxPacketSize,
+			timingUnit:     time.Millisecond,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	conn, err := net.DialTimeout("udp", addr, 5*time.Second)
+	if err := checkSampleRate(c.sampleRate); err != nil {
+		return nil, err
+	}
+	if err := c.schedule.Validate(); err != nil {
+		return nil, err
+	}
+	rates, err := newSampleRates(c.sampleRates)
 	if err != nil {
 		return nil, err
 	}
+	c.rates = rates
+	if c.clock == nil {
+		c.clock = systemClock{}
+	}
+	if c.tagLimit != nil && c.tagLimit.limit <= 0 {
+		return nil, ErrInvalidTagLimit
+	}
+	if err := checkPatterns("Allow", c.allowed); err != nil {
+		return nil, err
+	}
+	if err := checkPatterns("Deny", c.denied); err != nil {
+		return nil, err
+	}
+	if c.socketOptions.SendBuffer < 0 {
+		return nil, ErrInvalidSendBuffer
+	}
+	if c.connectTimeout <= 0 || c.writeTimeout < 0 {
+		return nil, ErrInvalidTimeout
+	}
+	if !c.protocol.valid() {
+		return nil, ErrInvalidProtocol
+	}
+	if c.rateLimit.Metrics < 0 || c.rateLimit.Bytes < 0 {
+		return nil, ErrInvalidRateLimit
+	}
+	c.limiter = newRateLimiter(c.rateLimit, c.clock.Now())
+	if c.nameRules.MaxLength < 0 {
+		return nil, ErrInvalidNameLength
+	}
+	if c.flushInterval <= 0 {
+		return nil, ErrInvalidFlushInterval
+	}
+	if c.maxPacketSize <= 0 {
+		return nil, ErrInvalidPacketSize
+	}
+	if c.batchDelay < 0 {
+		return nil, ErrInvalidBatchDelay
+	}
+	if c.timingUnit <= 0 {
+		return nil, ErrInvalidTimingUnit
+	}
+	if c.ratePrecision < 0 {
+		return nil, ErrInvalidRatePrecision
+	}
+	if c.maxMetrics < 0 || c.maxBytes < 0 {
+		return nil, ErrInvalidBufferLimit
+	}
+	if c.connections < 0 {
+		return nil, ErrInvalidConnections
+	}
+	if c.rt != nil {
+		if c.rt.size <= 0 {
+			return nil, ErrInvalidQueueSize
+		}
+		if c.rt.bound < 0 {
+			return nil, ErrInvalidTimeout
+		}
+		c.rt.init()
+	}
+	if !isStream(c.network) || len(c.hosts) > 0 || c.protocol == ProtocolGraphite {
+		// only the bundled decoder understands it, on whole packets
+		c.compress = false
+	}
+	if c.extensionSchema < 0 {
+		return nil, ErrInvalidExtensionSchema
+	}
+	if c.extensionSchema == 0 {
+		c.extensionSchema = ExtensionSchema
+	}
+	c.extensions = supportedExtensions(c.extensions, c.extensionSchema)
+	if c.extensions.Has(ExtIdempotencyToken) {
+		c.tokenPrefix = strconv.FormatUint(rand.Uint64(), 36)
+	}
 
-	c.conn = conn
-	c.flushticker = time.NewTicker(5 * time.Second)
+	userSink := c.sink != nil
+	if c.sink == nil && len(c.hosts) > 0 {
+		sink, err := c.newHostSink(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.sink = sink
+	}
+	if c.sink == nil && c.backup != "" {
+		sink, err := c.newFailoverSink(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.sink = sink
+	}
+	if len(c.mirrors) > 0 {
+		sink, err := c.newMirrorSink(ctx, c.sink)
+		if err != nil {
+			if c.sink != nil && !userSink {
+				c.sink.Close()
+			}
+			return nil, err
+		}
+		c.sink = sink
+	}
+	if c.sink == nil {
+		conn, err := c.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		if err := c.dialSpares(ctx); err != nil {
+			conn.Close()
+			trackConn(c.network, -1)
+			return nil, err
+		}
+	}
+
+	c.windowStart = c.clock.Now()
+	c.flushticker = c.clock.NewTicker(c.flushInterval)
+	c.done = make(chan struct{})
+	c.loopDone = make(chan struct{})
+	c.flushNow = make(chan struct{}, 1)
 	go c.bufferSendLoop()
+	if c.rt != nil {
+		go c.rt.run()
+	}
+
+	if c.banner {
+		c.handleError(c.sendBanner())
+	}
 
 	return c, nil
 }
 
+func newClient(addr string, prefix string) (*Client, error) {
+	return New(addr, WithPrefix(prefix))
+}
+
 func (c *Client) bufferSendLoop() {
-	for range c.flushticker.C {
-		c.m.Lock()
-		if len(c.buffer) == 0 {
-			c.m.Unlock()
-			continue
-		}
-		buffer := c.buffer
-		c.buffer = nil
-		c.m.Unlock()
-		for idx := range buffer {
-			c.send(buffer[idx].name, buffer[idx].count, "c", 1)
+	defer close(c.loopDone)
+	for {
+		select {
+		case <-c.flushticker.C():
+			c.handleError(c.flush())
+		case <-c.flushNow:
+			c.handleError(c.flush())
+		case <-c.done:
+			return
 		}
 	}
 }
 
-func (c *Client) addToBuffer(stat string, count int64) {
+// flush send the buffered counters
+func (c *Client) flush() error {
 	c.m.Lock()
-	for i := range c.buffer {
-		if c.buffer[i].name == stat {
-			c.buffer[i].count++
-			c.m.Unlock()
-			return
-		}
+	b := c.takeBuffers()
+	c.m.Unlock()
+
+	c.runWatches(b)
+	return c.packLines(c.flushLines(b), c.write)
+}
+
+// buffers is the content of the buffers taken by a flush
+type buffers struct {
+	counts []countBuffer
+	gauges []gaugeBuffer
+	timers []timerBuffer
+}
+
+// takeBuffers empty the buffers, c.m must be held
+func (c *clientConn) takeBuffers() buffers {
+	c.drainCounters()
+	b := buffers{counts: c.buffer, gauges: c.gauges, timers: c.timers}
+	c.buffer, c.gauges, c.timers = nil, nil, nil
+	clear(c.bufferIndex)
+	clear(c.gaugeIndex)
+	clear(c.timerIndex)
+	c.bufferedCount, c.bufferedBytes = 0, 0
+	select {
+	case <-c.flushNow: // satisfied by this flush
+	default:
+	}
+	return b
+}
+
+// flushLines return the lines sent by a flush of the given buffers
+func (c *Client) flushLines(b buffers) []string {
+	lines := make([]string, 0, len(b.counts)+len(b.gauges)+len(b.timers))
+	for idx := range b.counts {
+		lines = append(lines, c.formatLine(b.counts[idx].name, formatCount(b.counts[idx].count), "c", b.counts[idx].rate, b.counts[idx].tags))
+	}
+	lines = c.aggregateLines(lines, b)
+	if c.sortFlush {
+		sortLines(lines)
+	}
+	if c.gapThreshold > 0 {
+		lines = c.gapLines(lines)
+	}
+	if c.rt != nil {
+		lines = c.realTimeLines(lines)
+	}
+	if c.telemetry {
+		lines = append(lines, c.telemetryLines()...)
+		lines = append(lines, c.budgetLines()...)
+	}
+	return lines
+}
+
+// handleError pass the errors of the background work to the error handler
+func (c *clientConn) handleError(err error) {
+	if err != nil && c.errorHandler != nil {
+		c.errorHandler(err)
+	}
+}
+
+// addToBuffer add a signed count to the buffer shared with the children,
+// so the buffer holds the prefixed bucket. The counts of different sample
+// rates are kept apart, each one flushed with its rate for the servers to
+// scale it.
+func (c *Client) addToBuffer(stat string, count float64, sampleRate float32, tags []string) error {
+	if !c.asOf.IsZero() {
+		// back-dated, it can't join the counters of the current interval
+		return c.send(stat, formatCount(count), "c", sampleRate, tags...)
+	}
+	stat, tags = c.hashName(stat, tags)
+	return c.addBuffered(c.bucket(stat), joinTags(tags), count, sampleRate)
+}
+
+// addBuffered add a counter to the buffer, for a bucket already prefixed
+// and the call tags already joined
+func (c *clientConn) addBuffered(stat string, joined string, count float64, sampleRate float32) error {
+	c.m.Lock()
+	if c.closed.Load() {
+		c.m.Unlock()
+		return ErrClosed
 	}
-	c.buffer = append(c.buffer, countBuffer{stat, count})
+	c.addCount(stat, joined, count, sampleRate)
 	c.m.Unlock()
+	return nil
+}
+
+// addCount add count to the buffered counter, in O(1), c.m must be held
+func (c *clientConn) addCount(stat string, joined string, count float64, sampleRate float32) {
+	k := countKey{stat, joined, sampleRate}
+	if i, ok := c.bufferIndex[k]; ok {
+		c.buffer[i].count += count
+		return
+	}
+	if c.bufferIndex == nil {
+		c.bufferIndex = make(map[countKey]int)
+	}
+	c.bufferIndex[k] = len(c.buffer)
+	c.buffer = append(c.buffer, countBuffer{stat, count, joined, sampleRate})
+	c.buffered(stat, joined)
 }
 
-// Close the UDP connection
+// WithPrefix return a child client whose buckets are prefixed with sub on top
+// of the prefix of c, e.g. "api" then "checkout" give "api.checkout.<stat>".
+// The child shares the connection and the counter buffer of c, it is cheap to
+// create and closing it is a no-op.
+func (c *Client) WithPrefix(sub string) *Client {
+	return &Client{
+		prefix:       joinPrefix(c.prefix, strings.Trim(sub, ".")),
+		sampleRate:   c.sampleRate,
+		schedule:     c.schedule,
+		child:        true,
+		budget:       c.budget,
+		asOf:         c.asOf,
+		sampleKey:    c.sampleKey,
+		hasSampleKey: c.hasSampleKey,
+		clientConn:   c.clientConn,
+	}
+}
+
+// joinPrefix return prefix followed by sub, either may be empty
+func joinPrefix(prefix, sub string) string {
+	switch {
+	case prefix == "":
+		return sub
+	case sub != "":
+		return prefix + "." + sub
+	}
+	return prefix
+}
+
+// Close send the buffered counters and close the connection. It is safe to
+// call several times and concurrently with the sends: once closed, the
+// client sends nothing and its methods return ErrClosed. It does nothing on
+// a child client.
 func (c *Client) Close() error {
-	c.flushticker.Stop()
-	if c.conn == nil {
+	if c.child {
 		return nil
 	}
-	return c.conn.Close()
+
+	c.closeOnce.Do(func() {
+		c.flushticker.Stop()
+		close(c.done)
+		<-c.loopDone // a flush in progress ends before the connection is closed
+		if c.rt != nil {
+			c.rt.stop() // the queued metrics join the buffers first
+		}
+
+		// once closed is set no write is in progress and no counter joins the
+		// buffer, the last flush writes to the connection directly
+		c.closeMu.Lock()
+		c.m.Lock()
+		c.closed.Store(true)
+		b := c.takeBuffers()
+		batch := c.takeBatch()
+		c.m.Unlock()
+		c.closeMu.Unlock()
+
+		if len(batch) > 0 {
+			c.handleError(c.writeConn(batch))
+		}
+		c.runWatches(b)
+		c.handleError(c.packLines(c.flushLines(b), c.writeConn))
+
+		c.log(slog.LevelDebug, "statsd: closed")
+		if c.sink != nil {
+			c.closeErr = c.sink.Close()
+			return
+		}
+
+		c.closeErr = c.closeSpares()
+		c.connMu.Lock()
+		defer c.connMu.Unlock()
+		if c.conn == nil {
+			return
+		}
+		if err := c.conn.Close(); err != nil {
+			c.closeErr = err
+		}
+		trackConn(c.network, -1)
+	})
+	return c.closeErr
 }
 
 // See statsd data types here: http://statsd.readthedocs.org/en/latest/types.html
 // or also https://github.com/b/statsd_spec
 
+// The tags given to the methods below are appended to the tags of the client.
+
 // Incr - Increment a counter metric. Often used to note a particular event
-func (c *Client) Incr(stat string, count int64) error {
-	return c.IncrWithSampling(stat, count, 1)
+func (c *Client) Incr(stat string, count int64, tags ...string) error {
+	return c.IncrWithSampling(stat, count, c.rate(stat), tags...)
 }
 
 // IncrWithSampling - Increment a counter metric with sampling between 0 and 1
-func (c *Client) IncrWithSampling(stat string, count int64, sampleRate float32) error {
+func (c *Client) IncrWithSampling(stat string, count int64, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Incr", stat, count)
+		}
+	}()
 	if err := checkSampleRate(sampleRate); err != nil {
 		return err
 	}
 
-	if !shouldFire(sampleRate) {
+	if !c.fire(stat, sampleRate) {
 		return nil // ignore this call
 	}
 
 	if err := checkCount(count); err != nil {
 		return err
 	}
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeCount, stat, count, 0, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
 
-	c.addToBuffer(stat, count)
 	//return c.send(stat, count, "c", sampleRate)
-	return nil
+	return c.addToBuffer(stat, float64(count), sampleRate, tags)
+}
+
+// FIncr - Increment a counter metric by a fractional count, e.g. an amount
+func (c *Client) FIncr(stat string, count float64, tags ...string) error {
+	return c.FIncrWithSampling(stat, count, c.rate(stat), tags...)
+}
+
+// FIncrWithSampling increment a counter metric by a fractional count with sampling between 0 and 1
+func (c *Client) FIncrWithSampling(stat string, count float64, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "FIncr", stat, count)
+		}
+	}()
+	if err := checkSampleRate(sampleRate); err != nil {
+		return err
+	}
+
+	if !c.fire(stat, sampleRate) {
+		return nil
+	}
+
+	if err := checkFCount(count); err != nil {
+		return err
+	}
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeFCount, stat, 0, count, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
+
+	return c.addToBuffer(stat, count, sampleRate, tags)
 }
 
 // Decr - Decrement a counter metric. Often used to note a particular event
-func (c *Client) Decr(stat string, count int64) error {
-	return c.DecrWithSampling(stat, count, 1)
+func (c *Client) Decr(stat string, count int64, tags ...string) error {
+	return c.DecrWithSampling(stat, count, c.rate(stat), tags...)
 }
 
 // DecrWithSampling - Decrement a counter metric with sampling between 0 and 1
-func (c *Client) DecrWithSampling(stat string, count int64, sampleRate float32) error {
+func (c *Client) DecrWithSampling(stat string, count int64, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Decr", stat, count)
+		}
+	}()
 	if err := checkSampleRate(sampleRate); err != nil {
 		return err
 	}
 
-	if !shouldFire(sampleRate) {
+	if !c.fire(stat, sampleRate) {
 		return nil // ignore this call
 	}
 
 	if err := checkCount(count); err != nil {
 		return err
 	}
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeCount, stat, -count, 0, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
 
-	return c.send(stat, -count, "c", sampleRate)
+	return c.addToBuffer(stat, float64(-count), sampleRate, tags)
 }
 
 // Timing - Track a duration event
 // the time delta must be given in milliseconds
-func (c *Client) Timing(stat string, delta int64) error {
-	return c.TimingWithSampling(stat, delta, 1)
+func (c *Client) Timing(stat string, delta int64, tags ...string) error {
+	return c.TimingWithSampling(stat, delta, c.rate(stat), tags...)
 }
 
 // TimingWithSampling track a duration event with sampling between 0 and 1
-func (c *Client) TimingWithSampling(stat string, delta int64, sampleRate float32) error {
+func (c *Client) TimingWithSampling(stat string, delta int64, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Timing", stat, delta)
+		}
+	}()
 	if err := checkSampleRate(sampleRate); err != nil {
 		return err
 	}
 
-	if !shouldFire(sampleRate) {
+	if !c.fire(stat, sampleRate) {
 		return nil // ignore this call
 	}
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeTimer, stat, delta, 0, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
+
+	return c.timing(stat, delta, sampleRate, tags)
+}
 
-	return c.send(stat, delta, "ms", sampleRate)
+// timing send or buffer a timer once sampled
+func (c *Client) timing(stat string, delta int64, sampleRate float32, tags []string) error {
+	if c.aggregate && c.asOf.IsZero() {
+		return c.addTimer(stat, delta, sampleRate, tags)
+	}
+	return c.send(stat, delta, "ms", sampleRate, tags...)
 }
 
 // Gauge - Gauges are a constant data type. They are not subject to averaging,
@@ -165,63 +690,234 @@ func (c *Client) TimingWithSampling(stat string, delta int64, sampleRate float32
 // delta to be true, that specifies that the gauge should be updated, not set. Due to the
 // underlying protocol, you can't explicitly set a gauge to a negative number without
 // first setting it to zero.
-func (c *Client) Gauge(stat string, value int64) error {
-	return c.GaugeWithSampling(stat, value, 1)
+func (c *Client) Gauge(stat string, value int64, tags ...string) error {
+	return c.GaugeWithSampling(stat, value, c.rate(stat), tags...)
 }
 
 // GaugeWithSampling set a constant data type with sampling between 0 and 1
-func (c *Client) GaugeWithSampling(stat string, value int64, sampleRate float32) error {
+func (c *Client) GaugeWithSampling(stat string, value int64, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Gauge", stat, value)
+		}
+	}()
 	if err := checkSampleRate(sampleRate); err != nil {
 		return err
 	}
 
-	if !shouldFire(sampleRate) {
+	if !c.fire(stat, sampleRate) {
 		return nil // ignore this call
 	}
-
-	if value < 0 {
-		c.send(stat, 0, "g", 1)
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeGauge, stat, value, 0, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
 	}
 
-	return c.send(stat, value, "g", sampleRate)
+	return c.gauge(stat, value, value < 0, sampleRate, tags)
 }
 
 // FGauge -- Send a floating point value for a gauge
-func (c *Client) FGauge(stat string, value float64) error {
-	return c.FGaugeWithSampling(stat, value, 1)
+func (c *Client) FGauge(stat string, value float64, tags ...string) error {
+	return c.FGaugeWithSampling(stat, value, c.rate(stat), tags...)
 }
 
 // FGaugeWithSampling send a floating point value for a gauge with sampling between 0 and 1
-func (c *Client) FGaugeWithSampling(stat string, value float64, sampleRate float32) error {
+func (c *Client) FGaugeWithSampling(stat string, value float64, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "FGauge", stat, value)
+		}
+	}()
 	if err := checkSampleRate(sampleRate); err != nil {
 		return err
 	}
 
-	if !shouldFire(sampleRate) {
+	if !c.fire(stat, sampleRate) {
 		return nil
 	}
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeFGauge, stat, 0, value, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
 
-	if value < 0 {
-		c.send(stat, 0, "g", 1)
+	return c.gauge(stat, value, value < 0, sampleRate, tags)
+}
+
+// gauge send or buffer a gauge once sampled, value is an int64 or a float64
+func (c *Client) gauge(stat string, value interface{}, negative bool, sampleRate float32, tags []string) error {
+	if c.aggregate && c.asOf.IsZero() {
+		return c.addGauge(stat, value, sampleRate, tags)
+	}
+	if !negative || c.allowNegative {
+		return c.send(stat, value, "g", sampleRate, tags...)
 	}
 
-	return c.send(stat, value, "g", sampleRate)
+	// a bare negative value is a delta, the gauge is set to zero first in
+	// the same packet so that no server sees one line without the other
+	return c.sendLine(c.format(stat, 0, "g", 1, tags...) + "\n" + c.format(stat, value, "g", sampleRate, tags...))
+}
+
+// WithGaugeAllowNegative send the negative gauges as they are, for the
+// servers reading a negative value as absolute (e.g. DogStatsD), instead of
+// setting them to zero first
+func WithGaugeAllowNegative() Option {
+	return func(c *Client) {
+		c.allowNegative = true
+	}
 }
 
 // write a UDP packet with the statsd event
-func (c *Client) send(bucket string, value interface{}, t string, sampleRate float32) error {
+func (c *Client) send(bucket string, value interface{}, t string, sampleRate float32, tags ...string) error {
+	buf := linePool.Get().(*[]byte)
+	*buf = c.appendLine((*buf)[:0], bucket, value, t, sampleRate, tags...)
+	err := c.sendBytes(*buf)
+	linePool.Put(buf)
+	return err
+}
+
+// sendLine send formatted lines, batched with WithMaxBatchDelay
+func (c *Client) sendLine(line string) error {
+	return c.sendBytes([]byte(line))
+}
+
+// sendBytes is sendLine for a line not retained once it returns
+func (c *Client) sendBytes(line []byte) error {
+	if c.batchDelay > 0 {
+		return c.batchLine(line)
+	}
+	return c.write(line)
+}
+
+// sendLines packs the given lines into as few UDP packets as possible,
+// separating the lines of a packet by newline as the statsd protocol allows.
+// The packets are written under a single lock.
+func (c *Client) sendLines(lines []string) error {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	return c.packLines(lines, c.writeConn)
+}
+
+// packLines is sendLines writing the packets with write
+func (c *Client) packLines(lines []string, write func([]byte) error) error {
+	var packet []byte
+	var prev string // previous line of the packet, for the prefix compression
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > c.maxPacketSize {
+			if err := write(packet); err != nil {
+				return err
+			}
+			packet = packet[:0]
+			prev = ""
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		if c.compress {
+			packet = appendCompressed(packet, prev, line)
+			prev = line
+		} else {
+			packet = append(packet, line...)
+		}
+	}
+
+	if len(packet) == 0 {
+		return nil
+	}
+	return write(packet)
+}
+
+// write a packet unless the client is closed
+func (c *clientConn) write(packet []byte) error {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	return c.writeConn(packet)
+}
+
+// writeConn write a packet, terminating its last line on stream networks
+func (c *clientConn) writeConn(packet []byte) error {
+	if c.limiter != nil {
+		n := bytes.Count(packet, []byte{'\n'}) + 1
+		if !c.limiter.take(c.clock.Now(), n, len(packet)) {
+			c.stats.rateLimited.Add(int64(n))
+			c.log(slog.LevelDebug, "statsd: packet dropped", "metrics", n, "err", ErrRateLimited)
+			return ErrRateLimited
+		}
+	}
+
+	err := c.writePacket(packet)
+	c.trackWrite(packet, err)
+	if err != nil {
+		c.log(slog.LevelWarn, "statsd: write failed", "bytes", len(packet), "err", err)
+	}
+	if c.gapThreshold > 0 {
+		c.trackGap(err)
+	}
+	if err != nil && c.fallback != nil {
+		c.writeFallback(packet)
+	}
+	return err
+}
+
+// writePacket is writeConn without the gap detection
+func (c *clientConn) writePacket(packet []byte) error {
+	if c.sink != nil {
+		return c.writeSink(packet)
+	}
+	if c.spares != nil {
+		if ok, err := c.writeSpare(packet); ok {
+			return err
+		}
+	}
+	if isStream(c.network) {
+		return c.writeStream(append(packet, '\n'))
+	}
 	if c.conn == nil {
 		return ErrNotConnected
 	}
 
+	c.setWriteDeadline(c.conn)
+	_, err := c.conn.Write(packet)
+	if err != nil {
+		c.addDeadLetter(string(packet), err)
+	}
+	return err
+}
+
+// bucket return stat prefixed with the client prefix
+func (c *Client) bucket(stat string) string {
 	if c.prefix != "" {
-		bucket = fmt.Sprintf("%s.%s", c.prefix, bucket)
+		return c.prefix + "." + stat
 	}
+	return stat
+}
 
-	metric := fmt.Sprintf("%s:%v|%s|@%f", bucket, value, t, sampleRate)
+// format a statsd line, bucket is prefixed with the client prefix
+func (c *Client) format(bucket string, value interface{}, t string, sampleRate float32, tags ...string) string {
+	return string(c.appendLine(nil, bucket, value, t, sampleRate, tags...))
+}
 
-	_, err := c.conn.Write([]byte(metric))
-	return err
+// formatLine format a statsd line for a bucket already prefixed,
+// tags are the call tags already joined, appended to the client tags
+func (c *Client) formatLine(bucket string, value interface{}, t string, sampleRate float32, tags string) string {
+	return string(c.appendBucketLine(nil, bucket, value, t, sampleRate, tags))
+}
+
+// joinTags join the tags the way they are written on the wire
+func joinTags(tags []string) string {
+	return strings.Join(tags, ",")
 }
 
 func checkCount(c int64) error {
@@ -232,28 +928,34 @@ func checkCount(c int64) error {
 	return nil
 }
 
-func checkSampleRate(r float32) error {
-	if r < 0 || r > 1 {
-		return ErrInvalidSampleRate
+func checkFCount(c float64) error {
+	if !(c > 0) || math.IsInf(c, 1) {
+		return ErrInvalidCount
 	}
 
 	return nil
 }
 
-var fireRand *rand.Rand
+// formatCount format a buffered count, without exponent nor trailing zeros
+func formatCount(count float64) string {
+	return strconv.FormatFloat(count, 'f', -1, 64)
+}
 
-func init() {
-	// use rand.Seed to reset fireRand regularly?
-	fireRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+func checkSampleRate(r float32) error {
+	if r < 0 || r > 1 || math.IsNaN(float64(r)) {
+		return ErrInvalidSampleRate
+	}
+
+	return nil
 }
 
+// shouldFire tell if a metric sampled at sampleRate is kept, drawing from
+// the source of math/rand/v2: it is safe for concurrent use and, being per
+// thread in the runtime, the sampled calls don't contend on a lock
 func shouldFire(sampleRate float32) bool {
 	if sampleRate == 1 {
 		return true
 	}
 
-	// r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	// return r.Float32() <= sampleRate
-	return fireRand.Float32() <= sampleRate
+	return rand.Float32() <= sampleRate
 }
@@ -1,6 +1,15 @@
 package statsd
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func Test_shouldFire(t *testing.T) {
 	type args struct {
@@ -72,3 +81,230 @@ func Test_shouldFire(t *testing.T) {
 		})
 	}
 }
+
+func Test_shouldFireConcurrent(t *testing.T) {
+	// run with -race: the sampled calls share no source
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				shouldFire(0.5)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Benchmark_shouldFire(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			shouldFire(0.5)
+		}
+	})
+}
+
+func Test_Close(t *testing.T) {
+	c, l := newTestClient(t, "proj")
+
+	// drain the listener while the sends race, so that no packet is dropped
+	found := make(chan bool, 1)
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			l.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := l.ReadFrom(buf)
+			if err != nil {
+				found <- false
+				return
+			}
+			if strings.Contains(string(buf[:n]), "proj.pending:") {
+				found <- true
+				return
+			}
+		}
+	}()
+
+	c.Incr("pending", 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ { // fewer packets than the socket buffer holds
+				c.Timing("racing", 1)
+				c.Incr("racing", 1)
+			}
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the buffered counters are flushed by Close
+	if !<-found {
+		t.Fatal("buffered counter not flushed by Close")
+	}
+
+	if err := c.Timing("closed", 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Timing after Close: %v <=> want: %v", err, ErrClosed)
+	}
+	if err := c.Incr("closed", 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Incr after Close: %v <=> want: %v", err, ErrClosed)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func Test_CloseKeepsAcceptedCounters(t *testing.T) {
+	c, l := newTestClient(t, "proj")
+
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if c.Incr("racing", 1) == nil {
+					accepted.Add(1)
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	c.Close()
+	wg.Wait()
+
+	// every counter accepted before Close is sent
+	var sent int64
+	buf := make([]byte, 64*1024)
+	for {
+		l.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, _, err := l.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if v, ok := strings.CutPrefix(line, "proj.racing:"); ok {
+				count, _ := strconv.ParseInt(strings.SplitN(v, "|", 2)[0], 10, 64)
+				sent += count
+			}
+		}
+	}
+	if sent != accepted.Load() {
+		t.Fatalf("sent: %d <=> accepted: %d", sent, accepted.Load())
+	}
+}
+
+func Test_NegativeGauge(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		send func(c *Client)
+		want string
+	}{
+		{"gauge", nil, func(c *Client) { c.Gauge("temp", -5) }, "temp:0|g\ntemp:-5|g"},
+		{"fgauge", nil, func(c *Client) { c.FGauge("temp", -0.5, "room:a") }, "temp:0|g|#room:a\ntemp:-0.5|g|#room:a"},
+		{"positive", nil, func(c *Client) { c.Gauge("temp", 5) }, "temp:5|g"},
+		{"allow negative", []Option{WithGaugeAllowNegative()}, func(c *Client) { c.Gauge("temp", -5) }, "temp:-5|g"},
+		{"batched", []Option{WithMaxBatchDelay(time.Millisecond)}, func(c *Client) { c.Gauge("temp", -5) }, "temp:0|g\ntemp:-5|g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, l := newTestClient(t, "")
+			c, err := New(l.LocalAddr().String(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			tt.send(c)
+			if got := readPacket(t, l); got != tt.want {
+				t.Fatalf("got:\n%s\nwant, in one packet:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_NegativeGaugeVectorNotSplit(t *testing.T) {
+	c, l := newTestClient(t, "")
+	c.maxPacketSize = 15 // room for one line and a half
+
+	c.GaugeVector("t", []float64{1, -1}, "i")
+	for _, want := range []string{
+		"t:1|g|#i:0",
+		"t:0|g|#i:1\nt:-1|g|#i:1",
+	} {
+		if got := readPacket(t, l); got != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func Test_FIncr(t *testing.T) {
+	c, l := newTestClient(t, "shop")
+
+	c.FIncr("revenue", 1.25, "currency:eur")
+	c.FIncr("revenue", 2.5, "currency:eur")
+	c.Incr("orders", 1500000)
+	for _, count := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if err := c.FIncr("revenue", count); !errors.Is(err, ErrInvalidCount) {
+			t.Fatalf("FIncr(%v): %v <=> want: %v", count, err, ErrInvalidCount)
+		}
+	}
+	c.flush()
+
+	want := "shop.revenue:3.75|c|#currency:eur\nshop.orders:1500000|c"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func Test_BufferedCounts(t *testing.T) {
+	c, l := newTestClient(t, "")
+
+	c.Incr("stock", 5)
+	c.Decr("stock", 2)
+	c.Decr("slots", 3)
+	// the sampled hits, as kept by IncrWithSampling, apart from the others
+	c.addToBuffer("hits", 2, 0.5, nil)
+	c.addToBuffer("hits", 1, 0.5, nil)
+	c.Incr("hits", 4)
+	c.flush()
+
+	want := "stock:3|c\nslots:-3|c\nhits:3|c|@0.5\nhits:4|c"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func Benchmark_addToBuffer(b *testing.B) {
+	c, err := New("127.0.0.1:8125", WithPrefix("api"), WithFlushInterval(time.Hour))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = "requests." + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Incr(names[i%len(names)], 1, "route:/")
+	}
+}
@@ -0,0 +1,78 @@
+package statsd
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is the reason of the metrics dropped because the send queue
+// of the package level helpers is full
+var ErrQueueFull = errors.New("metric dropped, send queue is full")
+
+// DeadLetter is a metric that was dropped or failed to send
+type DeadLetter struct {
+	Line string // the statsd line, as it would have been written
+	Err  error  // why it wasn't delivered
+	Time time.Time
+}
+
+// deadLetters is a bounded ring of the last dead letters
+type deadLetters struct {
+	m     sync.Mutex
+	ring  []DeadLetter
+	next  int
+	count int
+}
+
+// WithDeadLetters keep the last n metrics that were dropped or failed to
+// send, retrievable with DeadLetters, to debug a data loss after the fact
+func WithDeadLetters(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.deadLetters = &deadLetters{ring: make([]DeadLetter, n)}
+		}
+	}
+}
+
+// DeadLetters return the last metrics that were dropped or failed to send,
+// oldest first. It is empty unless the client was created WithDeadLetters.
+func (c *Client) DeadLetters() []DeadLetter {
+	d := c.deadLetters
+	if d == nil {
+		return nil
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	letters := make([]DeadLetter, 0, d.count)
+	start := d.next - d.count
+	if start < 0 {
+		start += len(d.ring)
+	}
+	for i := 0; i < d.count; i++ {
+		letters = append(letters, d.ring[(start+i)%len(d.ring)])
+	}
+	return letters
+}
+
+// addDeadLetter keep the lines of a packet which couldn't be written
+func (c *clientConn) addDeadLetter(packet string, err error) {
+	d := c.deadLetters
+	if d == nil {
+		return
+	}
+
+	now := time.Now()
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	for _, line := range c.packetLines(packet) {
+		d.ring[d.next] = DeadLetter{Line: line, Err: err, Time: now}
+		d.next = (d.next + 1) % len(d.ring)
+		if d.count < len(d.ring) {
+			d.count++
+		}
+	}
+}
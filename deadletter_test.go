@@ -0,0 +1,48 @@
+package statsd
+
+import (
+	"net"
+	"testing"
+)
+
+func Test_DeadLetters(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.LocalAddr().String(), WithPrefix("proj"), WithDeadLetters(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if got := c.DeadLetters(); len(got) != 0 {
+		t.Fatalf("dead letters before any failure: %v", got)
+	}
+
+	// every write fails on a closed connection
+	c.conn.Close()
+	c.Timing("a", 1)
+	c.GaugeGroup(map[string]float64{"b": 2, "c": 3})
+
+	got := c.DeadLetters()
+	want := []string{"proj.b:2|g", "proj.c:3|g"}
+	if len(got) != len(want) {
+		t.Fatalf("dead letters: %v <=> want: %v", got, want)
+	}
+	for i := range want {
+		if got[i].Line != want[i] || got[i].Err == nil {
+			t.Fatalf("dead letters: %v <=> want: %v", got, want)
+		}
+	}
+
+	// the client without dead letters keeps nothing
+	other, _ := newTestClient(t, "")
+	other.conn.Close()
+	other.Timing("a", 1)
+	if got := other.DeadLetters(); got != nil {
+		t.Fatalf("dead letters: %v <=> want: nil", got)
+	}
+}
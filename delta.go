@@ -0,0 +1,45 @@
+package statsd
+
+import "strconv"
+
+// GaugeDelta change a gauge by delta, "+5" or "-5", instead of setting it.
+// The deltas are neither sampled nor aggregated, the server sums them.
+func (c *Client) GaugeDelta(stat string, delta int64, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "GaugeDelta", stat, delta)
+		}
+	}()
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeGaugeDelta, stat, delta, 0, 1, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
+
+	return c.gaugeDelta(stat, delta, tags)
+}
+
+func (c *Client) gaugeDelta(stat string, delta int64, tags []string) error {
+	return c.send(stat, formatDelta(delta), "g", 1, tags...)
+}
+
+// formatDelta format a gauge delta with its sign, a bare negative value
+// would be read as a delta anyway but a bare positive one as a value
+func formatDelta(delta int64) string {
+	if delta < 0 {
+		return strconv.FormatInt(delta, 10)
+	}
+	return "+" + strconv.FormatInt(delta, 10)
+}
+
+// GaugeDelta change a gauge by delta with the default client, see Client.GaugeDelta
+func GaugeDelta(stat string, delta int64, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil || !enabled.Load() {
+		return
+	}
+
+	sendInt(cfg, stat, delta, metricTypeGaugeDelta, 1, tags)
+}
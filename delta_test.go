@@ -0,0 +1,34 @@
+package statsd
+
+import "testing"
+
+func Test_GaugeDelta(t *testing.T) {
+	c, l := newTestClient(t, "pool")
+
+	tests := []struct {
+		delta int64
+		want  string
+	}{
+		{5, "pool.conns:+5|g|#db:a"},
+		{-5, "pool.conns:-5|g|#db:a"},
+		{0, "pool.conns:+0|g|#db:a"},
+	}
+
+	for _, tt := range tests {
+		if err := c.GaugeDelta("conns", tt.delta, "db:a"); err != nil {
+			t.Fatal(err)
+		}
+		if got := readPacket(t, l); got != tt.want {
+			t.Errorf("delta %d: %s <=> want: %s", tt.delta, got, tt.want)
+		}
+	}
+}
+
+func Test_PackageGaugeDelta(t *testing.T) {
+	l := setupTestDefault(t, &Config{Project: "pool", Enable: true})
+
+	GaugeDelta("conns", -2)
+	if got := readPacket(t, l); got != "pool.conns:-2|g" {
+		t.Fatalf("got: %s <=> want: pool.conns:-2|g", got)
+	}
+}
@@ -0,0 +1,25 @@
+// Package statsd is the statsd client: the Client, its options, and the
+// package level helpers writing through the default client set by Setup.
+//
+// The module is laid out as follows, the import paths are stable:
+//
+//	statsd             the client
+//	statsd/proto       the line format: ParseLine, Metric, PrefixDecoder
+//	statsd/server      the receiving side, reading packets and stream
+//	                   connections into proto.Metric
+//	statsd/statsdtest  the Recorder and the fake Server for the tests
+//	statsd/httpstat, statsd/sqlstats, statsd/runtimestats
+//	                   the integrations depending on the standard library only
+//	statsd/fxstats, statsd/wirestats, statsd/grpcstats, statsd/promstats, statsd/otelstats,
+//	statsd/gometricstats
+//	                   the integrations of third party frameworks, each one a
+//	                   module of its own
+//
+// The integrations stay at the top of the module rather than under a
+// statsd/contrib directory: their import paths are already published, and
+// moving them would churn the imports the layout is meant to keep stable.
+// The new integrations are added next to them.
+//
+// The new code about the wire format goes to proto, the client keeps aliases
+// of what moved there (PrefixDecoder, ErrInvalidCompressedLine).
+package statsd
@@ -0,0 +1,125 @@
+package statsd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// linePool hold the buffers the lines sent one by one are encoded into
+var linePool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 256)
+		return &buf
+	},
+}
+
+// appendLine append a statsd line to dst, stat is prefixed with the client prefix
+func (c *Client) appendLine(dst []byte, stat string, value interface{}, t string, sampleRate float32, tags ...string) []byte {
+	stat, tags = c.hashName(stat, tags)
+	if c.prefix != "" {
+		dst = append(dst, c.prefix...)
+		dst = append(dst, '.')
+	}
+	dst = append(dst, stat...)
+	if c.protocol != ProtocolDogStatsD {
+		dst = c.appendNameTags(dst, tags, "")
+	}
+	if c.protocol == ProtocolGraphite {
+		return c.appendGraphiteValue(dst, value, t, sampleRate)
+	}
+	dst = appendValue(dst, value, t, sampleRate, c.ratePrecision)
+
+	if c.protocol == ProtocolDogStatsD && (len(c.tags) > 0 || len(tags) > 0) {
+		dst = append(dst, "|#"...)
+		dst = appendTags(dst, c.tags)
+		if len(c.tags) > 0 && len(tags) > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendTags(dst, tags)
+	}
+	return c.appendExtensions(dst, c.asOf)
+}
+
+// appendBucketLine append a statsd line to dst for a bucket already prefixed,
+// tags are the call tags already joined, appended to the client tags
+func (c *Client) appendBucketLine(dst []byte, bucket string, value interface{}, t string, sampleRate float32, tags string) []byte {
+	dst = append(dst, bucket...)
+	if c.protocol != ProtocolDogStatsD {
+		dst = c.appendNameTags(dst, nil, tags)
+	}
+	if c.protocol == ProtocolGraphite {
+		return c.appendGraphiteValue(dst, value, t, sampleRate)
+	}
+	dst = appendValue(dst, value, t, sampleRate, c.ratePrecision)
+
+	if c.protocol == ProtocolDogStatsD && (len(c.tags) > 0 || tags != "") {
+		dst = append(dst, "|#"...)
+		dst = appendTags(dst, c.tags)
+		if len(c.tags) > 0 && tags != "" {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, tags...)
+	}
+	return c.appendExtensions(dst, c.asOf)
+}
+
+// appendValue append ":<value>|<type>[|@<rate>]", the value formatted as by %v
+// and the rate rounded to precision decimals if precision > 0
+func appendValue(dst []byte, value interface{}, t string, sampleRate float32, precision int) []byte {
+	dst = append(dst, ':')
+	dst = appendRawValue(dst, value)
+	dst = append(dst, '|')
+	dst = append(dst, t...)
+	if sampleRate >= 1 {
+		return dst // the servers take 1 by default
+	}
+	dst = append(dst, "|@"...)
+	if precision > 0 {
+		scale := math.Pow10(precision)
+		if rounded := math.Round(float64(sampleRate)*scale) / scale; rounded > 0 {
+			return strconv.AppendFloat(dst, rounded, 'f', -1, 64)
+		}
+		// a rate rounded to 0 would make the servers divide by 0
+	}
+	return strconv.AppendFloat(dst, float64(sampleRate), 'f', -1, 32)
+}
+
+// appendRawValue append value formatted as by %v
+func appendRawValue(dst []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case float64:
+		return strconv.AppendFloat(dst, v, 'g', -1, 64)
+	case string:
+		return append(dst, v...)
+	}
+	return fmt.Append(dst, value)
+}
+
+// WithSampleRatePrecision round the sample rates written to digits decimals,
+// e.g. "@0.33" in place of "@0.333333" with 2, to shrink the packets. The
+// rates are written at the shortest exact precision by default.
+func WithSampleRatePrecision(digits int) Option {
+	return func(c *Client) {
+		c.ratePrecision = digits
+		if digits <= 0 {
+			c.ratePrecision = -1 // rejected by NewContext
+		}
+	}
+}
+
+// appendTags append the tags separated by commas
+func appendTags(dst []byte, tags []string) []byte {
+	for i, tag := range tags {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, tag...)
+	}
+	return dst
+}
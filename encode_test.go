@@ -0,0 +1,115 @@
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func Test_appendValue(t *testing.T) {
+	values := []interface{}{int64(-42), 7, 1.5, 1234567.0, 1e21, -0.25, "+5", uint32(3)}
+	rates := []struct {
+		rate   float32
+		suffix string
+	}{
+		{1, ""},
+		{0.1, "|@0.1"},
+		{0.333, "|@0.333"},
+	}
+	for _, v := range values {
+		for _, r := range rates {
+			rate := r.rate
+			want := fmt.Sprintf(":%v|g", v) + r.suffix
+			if got := string(appendValue(nil, v, "g", rate, 0)); got != want {
+				t.Errorf("%T %v @%v: %s <=> want: %s", v, v, rate, got, want)
+			}
+		}
+	}
+}
+
+func Test_appendLine(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		tags []string
+		want string
+	}{
+		{"bare", nil, nil, "db:3|ms"},
+		{"call tags", nil, []string{"op:select", "shard:1"}, "db:3|ms|#op:select,shard:1"},
+		{"client tags", []Option{WithPrefix("api"), WithTags("env:prod")}, nil, "api.db:3|ms|#env:prod"},
+		{"both", []Option{WithTags("env:prod")}, []string{"op:select"}, "db:3|ms|#env:prod,op:select"},
+		{"extensions", []Option{WithExtensions(ExtContainerID), WithContainerID("abc")}, nil, "db:3|ms|c:abc"},
+	}
+
+	for _, tt := range tests {
+		c, err := New("127.0.0.1:8125", tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(c.appendLine(nil, "db", int64(3), "ms", 1, tt.tags...)); got != tt.want {
+			t.Errorf("[%s] appendLine: %s <=> want: %s", tt.name, got, tt.want)
+		}
+		if got := c.formatLine(c.bucket("db"), int64(3), "ms", 1, joinTags(tt.tags)); got != tt.want {
+			t.Errorf("[%s] formatLine: %s <=> want: %s", tt.name, got, tt.want)
+		}
+		c.Close()
+	}
+}
+
+func Benchmark_send(b *testing.B) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithTags("env:prod"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Timing("db.query", 12, "op:select")
+	}
+}
+
+func Benchmark_appendLine(b *testing.B) {
+	c, err := New("127.0.0.1:8125", WithPrefix("api"), WithTags("env:prod"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	b.ReportAllocs()
+	buf := make([]byte, 0, 256)
+	for i := 0; i < b.N; i++ {
+		buf = c.appendLine(buf[:0], "db.query", int64(12), "ms", 1, "op:select")
+	}
+}
+
+func Test_WithSampleRatePrecision(t *testing.T) {
+	tests := []struct {
+		precision int
+		rate      float32
+		want      string
+	}{
+		{0, 0.333333, ":1|c|@0.333333"},
+		{2, 0.333333, ":1|c|@0.33"},
+		{2, 0.5, ":1|c|@0.5"},
+		{3, 0.1, ":1|c|@0.1"},
+		{2, 0.001, ":1|c|@0.001"}, // not rounded to 0
+		{2, 1, ":1|c"},
+	}
+
+	for _, tt := range tests {
+		if got := string(appendValue(nil, int64(1), "c", tt.rate, tt.precision)); got != tt.want {
+			t.Errorf("%v at %d: %s <=> want: %s", tt.rate, tt.precision, got, tt.want)
+		}
+	}
+
+	if _, err := New("127.0.0.1:8125", WithSampleRatePrecision(0)); err != ErrInvalidRatePrecision {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidRatePrecision)
+	}
+}
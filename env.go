@@ -0,0 +1,69 @@
+package statsd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the environment variables read by ConfigFromEnv
+const (
+	EnvHost       = "STATSD_HOST"        // 127.0.0.1 if unset
+	EnvPort       = "STATSD_PORT"        // 8125 if unset
+	EnvPrefix     = "STATSD_PREFIX"      // Config.Project
+	EnvSampleRate = "STATSD_SAMPLE_RATE" // between 0 and 1
+	EnvTags       = "STATSD_TAGS"        // comma separated, e.g. "env:prod,dc:ams1"
+	EnvEnabled    = "STATSD_ENABLED"     // true if unset
+)
+
+// ConfigFromEnv return the config described by the STATSD_* environment
+// variables, or an error naming the first malformed one
+func ConfigFromEnv() (*Config, error) {
+	cfg := &Config{Host: "127.0.0.1", Port: 8125, Enable: true}
+
+	if v, ok := os.LookupEnv(EnvHost); ok && v != "" {
+		cfg.Host = v
+	}
+	if v, ok := os.LookupEnv(EnvPort); ok && v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("statsd: invalid %s %q", EnvPort, v)
+		}
+		cfg.Port = port
+	}
+	cfg.Project = os.Getenv(EnvPrefix)
+	if v, ok := os.LookupEnv(EnvSampleRate); ok && v != "" {
+		rate, err := strconv.ParseFloat(v, 32)
+		if err != nil || checkSampleRate(float32(rate)) != nil {
+			return nil, fmt.Errorf("statsd: invalid %s %q", EnvSampleRate, v)
+		}
+		cfg.SampleRate = float32(rate)
+	}
+	if v := os.Getenv(EnvTags); v != "" {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				cfg.Tags = append(cfg.Tags, tag)
+			}
+		}
+	}
+	if v, ok := os.LookupEnv(EnvEnabled); ok && v != "" {
+		enable, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("statsd: invalid %s %q", EnvEnabled, v)
+		}
+		cfg.Enable = enable
+	}
+	return cfg, nil
+}
+
+// SetupFromEnv is Setup with the config of ConfigFromEnv, for the
+// deployments configured by the environment
+func SetupFromEnv() error {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	Setup(cfg)
+	return nil
+}
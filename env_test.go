@@ -0,0 +1,64 @@
+package statsd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want *Config
+		err  bool
+	}{
+		{"defaults", nil, &Config{Host: "127.0.0.1", Port: 8125, Enable: true}, false},
+		{"all", map[string]string{
+			EnvHost:       "statsd.local",
+			EnvPort:       "9125",
+			EnvPrefix:     "shop",
+			EnvSampleRate: "0.5",
+			EnvTags:       "env:prod, dc:ams1,",
+			EnvEnabled:    "false",
+		}, &Config{Host: "statsd.local", Port: 9125, Project: "shop", SampleRate: 0.5, Tags: []string{"env:prod", "dc:ams1"}}, false},
+		{"port", map[string]string{EnvPort: "http"}, nil, true},
+		{"port range", map[string]string{EnvPort: "70000"}, nil, true},
+		{"sample rate", map[string]string{EnvSampleRate: "2"}, nil, true},
+		{"sample rate NaN", map[string]string{EnvSampleRate: "NaN"}, nil, true},
+		{"enabled", map[string]string{EnvEnabled: "maybe"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{EnvHost, EnvPort, EnvPrefix, EnvSampleRate, EnvTags, EnvEnabled} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.err || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got: %+v (%v) <=> want: %+v (error: %t)", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func Test_SetupFromEnv(t *testing.T) {
+	restoreDefault(t)
+	t.Setenv(EnvPrefix, "env")
+	t.Setenv(EnvEnabled, "true")
+
+	if err := SetupFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	defaultMu.Lock()
+	cfg, a := config.Load(), addr
+	defaultMu.Unlock()
+	if cfg.Project != "env" || a != "127.0.0.1:8125" || !Enabled() {
+		t.Fatalf("config: %+v, addr: %s", cfg, a)
+	}
+
+	t.Setenv(EnvPort, "x")
+	if err := SetupFromEnv(); err == nil {
+		t.Fatal("malformed environment accepted")
+	}
+}
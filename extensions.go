@@ -0,0 +1,129 @@
+package statsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extension is a capability flag enabling an extended field after the tags
+// of every line. Third-party servers reject or misread fields they don't
+// know, so each one is sent only when declared, once the server is known to
+// support it.
+type Extension uint32
+
+// ExtensionSchema is the version of the extended fields written by this
+// package, bumped when a field is added or changes
+const ExtensionSchema = 1
+
+// extensions, see extensionSchemas for the schema version of each one
+const (
+	// ExtTimestamp append the emission time as unix seconds: "|T1700000000"
+	ExtTimestamp Extension = 1 << iota
+	// ExtContainerID append the container id set with WithContainerID: "|c:<id>"
+	ExtContainerID
+	// ExtIdempotencyToken append a token unique to the line, "|i:<client>-<seq>",
+	// so a server can drop the lines a retry sends twice
+	ExtIdempotencyToken
+)
+
+// extensionSchemas is the schema version each extension appeared in
+var extensionSchemas = map[Extension]int{
+	ExtTimestamp:        1,
+	ExtContainerID:      1,
+	ExtIdempotencyToken: 1,
+}
+
+// supportedExtensions return the extensions of e a server understanding the
+// given schema version knows, the later ones are dropped
+func supportedExtensions(e Extension, schema int) Extension {
+	for ext, version := range extensionSchemas {
+		if version > schema {
+			e &^= ext
+		}
+	}
+	return e
+}
+
+// extensionNames are the names accepted by ParseExtensions
+var extensionNames = map[string]Extension{
+	"timestamp":         ExtTimestamp,
+	"container_id":      ExtContainerID,
+	"idempotency_token": ExtIdempotencyToken,
+}
+
+// Has tell whether every extension of ext is enabled in e
+func (e Extension) Has(ext Extension) bool {
+	return e&ext == ext
+}
+
+// ParseExtensions parse a comma separated list of extension names, e.g.
+// "timestamp,container_id", as found in a config file
+func ParseExtensions(s string) (Extension, error) {
+	var e Extension
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		ext, ok := extensionNames[name]
+		if !ok {
+			return 0, fmt.Errorf("statsd: unknown extension %q", name)
+		}
+		e |= ext
+	}
+	return e, nil
+}
+
+// WithExtensions enable the extended fields of ext
+func WithExtensions(ext Extension) Option {
+	return func(c *Client) {
+		c.extensions |= ext
+	}
+}
+
+// WithExtensionSchema declare the schema version of the extended fields the
+// server understands, ExtensionSchema by default. The extensions of later
+// versions aren't sent even if enabled, so that a client can be upgraded
+// before the servers it sends to.
+func WithExtensionSchema(version int) Option {
+	return func(c *Client) {
+		c.extensionSchema = version
+	}
+}
+
+// WithContainerID set the container id sent with ExtContainerID
+func WithContainerID(id string) Option {
+	return func(c *Client) {
+		c.containerID = id
+	}
+}
+
+// appendExtensions append the enabled extended fields to a line, the
+// timestamp is at, or now if zero
+func (c *clientConn) appendExtensions(metric []byte, at time.Time) []byte {
+	if c.extensions == 0 {
+		return metric
+	}
+
+	if c.extensions.Has(ExtTimestamp) {
+		if at.IsZero() {
+			at = c.clock.Now()
+		}
+		metric = append(metric, "|T"...)
+		metric = strconv.AppendInt(metric, at.Unix(), 10)
+	}
+	if c.extensions.Has(ExtContainerID) && c.containerID != "" {
+		metric = append(metric, "|c:"...)
+		metric = append(metric, c.containerID...)
+	}
+	if c.extensions.Has(ExtIdempotencyToken) {
+		metric = append(metric, "|i:"...)
+		metric = append(metric, c.tokenPrefix...)
+		metric = append(metric, '-')
+		metric = strconv.AppendUint(metric, c.tokenSeq.Add(1), 36)
+	}
+	return metric
+}
@@ -0,0 +1,107 @@
+package statsd
+
+import (
+	"net"
+	"regexp"
+	"testing"
+)
+
+func Test_ParseExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Extension
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			s:    "",
+			want: 0,
+		},
+		{
+			name: "single",
+			s:    "timestamp",
+			want: ExtTimestamp,
+		},
+		{
+			name: "several",
+			s:    "container_id, idempotency_token",
+			want: ExtContainerID | ExtIdempotencyToken,
+		},
+		{
+			name:    "unknown",
+			s:       "timestamp,compression",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExtensions(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("[%s] err: %v, wantErr: %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("[%s] got: %b <=> want: %b", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Extensions(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.LocalAddr().String(), WithPrefix("proj"), WithTags("env:prod"),
+		WithExtensions(ExtTimestamp|ExtContainerID|ExtIdempotencyToken), WithContainerID("abc123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	re := regexp.MustCompile(`^proj\.query:4\|ms\|#env:prod\|T\d+\|c:abc123\|i:[0-9a-z]+-([0-9a-z]+)$`)
+	for _, seq := range []string{"1", "2"} {
+		c.Timing("query", 4)
+		got := readPacket(t, l)
+		m := re.FindStringSubmatch(got)
+		if m == nil || m[1] != seq {
+			t.Fatalf("unexpected line: %s", got)
+		}
+	}
+}
+
+func Test_WithExtensionSchema(t *testing.T) {
+	// pretend the idempotency tokens appeared in the next schema
+	extensionSchemas[ExtIdempotencyToken] = ExtensionSchema + 1
+	defer func() { extensionSchemas[ExtIdempotencyToken] = 1 }()
+
+	_, l := newTestClient(t, "")
+	all := ExtTimestamp | ExtIdempotencyToken
+	tests := []struct {
+		name   string
+		schema int
+		want   Extension
+	}{
+		{"latest-by-default", 0, ExtTimestamp},
+		{"current", ExtensionSchema, ExtTimestamp},
+		{"next", ExtensionSchema + 1, all},
+	}
+
+	for _, tt := range tests {
+		c, err := New(l.LocalAddr().String(), WithExtensions(all), WithExtensionSchema(tt.schema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+		if c.extensions != tt.want {
+			t.Fatalf("[%s] extensions: %b <=> want: %b", tt.name, c.extensions, tt.want)
+		}
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithExtensionSchema(-1)); err != ErrInvalidExtensionSchema {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidExtensionSchema)
+	}
+}
@@ -0,0 +1,134 @@
+package statsd
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// FailoverPolicy tell when a client switches to its backup server and back
+type FailoverPolicy struct {
+	// Failures is the number of consecutive failed writes to the primary
+	// server before switching to the backup, 3 if 0
+	Failures int
+
+	// ProbeInterval is how often a packet is tried on the primary server
+	// while on the backup, to switch back once it succeeds, 10s if 0
+	ProbeInterval time.Duration
+}
+
+const (
+	defaultFailoverFailures = 3
+	defaultProbeInterval    = 10 * time.Second
+)
+
+// WithFailover write to the backup server ("host:port") once the writes to
+// addr fail repeatedly, e.g. during the maintenance of an aggregator, and
+// back to addr once it accepts a write again. The packet of the failed write
+// switching to the backup is written to the backup. It is ignored with
+// WithSink or WithHosts.
+func WithFailover(backup string, policy FailoverPolicy) Option {
+	return func(c *Client) {
+		if policy.Failures <= 0 {
+			policy.Failures = defaultFailoverFailures
+		}
+		if policy.ProbeInterval <= 0 {
+			policy.ProbeInterval = defaultProbeInterval
+		}
+		c.backup = backup
+		c.failoverPolicy = policy
+	}
+}
+
+// failoverSink is the Sink of WithFailover
+type failoverSink struct {
+	c       *clientConn // the client failing over, for its logger
+	primary *Client
+	backup  *Client
+	policy  FailoverPolicy
+
+	m         sync.Mutex
+	failures  int       // consecutive failed writes to the primary
+	onBackup  bool      // writing to the backup
+	lastProbe time.Time // of the primary, while on the backup
+}
+
+// newFailoverSink connect to addr and to the backup of WithFailover
+func (c *clientConn) newFailoverSink(ctx context.Context) (*failoverSink, error) {
+	opts := c.endpointOptions()
+	primary, err := NewContext(ctx, c.addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := NewContext(ctx, c.backup, opts...)
+	if err != nil {
+		primary.Close()
+		return nil, err
+	}
+	return &failoverSink{c: c, primary: primary, backup: backup, policy: c.failoverPolicy}, nil
+}
+
+func (s *failoverSink) Write(packet []byte) error {
+	s.m.Lock()
+	probe := !s.onBackup || time.Since(s.lastProbe) >= s.policy.ProbeInterval
+	if s.onBackup && probe {
+		s.lastProbe = time.Now()
+	}
+	s.m.Unlock()
+
+	if probe {
+		err := s.primary.write(packet)
+		if !s.primaryFailed(err) {
+			return err
+		}
+	}
+	return s.backup.write(packet)
+}
+
+// primaryFailed record the result of a write to the primary and tell if the
+// packet goes to the backup
+func (s *failoverSink) primaryFailed(err error) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err == nil {
+		if s.onBackup {
+			s.c.log(slog.LevelInfo, "statsd: back on the primary server")
+		}
+		s.failures = 0
+		s.onBackup = false
+		return false
+	}
+	if s.onBackup {
+		return true // the probe failed
+	}
+	s.failures++
+	if s.failures < s.policy.Failures {
+		return false
+	}
+	s.onBackup = true
+	s.lastProbe = time.Now()
+	s.c.log(slog.LevelWarn, "statsd: failing over to the backup server", "backup", s.backup.addr, "err", err)
+	return true
+}
+
+// OnBackup tell if the writes go to the backup server of WithFailover, false
+// without WithFailover
+func (c *Client) OnBackup() bool {
+	s, ok := c.sink.(*failoverSink)
+	if !ok {
+		return false
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.onBackup
+}
+
+func (s *failoverSink) Close() error {
+	err := s.primary.Close()
+	if berr := s.backup.Close(); err == nil {
+		err = berr
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package statsd
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// toggleSink fail the writes while down
+type toggleSink struct {
+	down  atomic.Bool
+	lines []string
+}
+
+func (s *toggleSink) Write(packet []byte) error {
+	if s.down.Load() {
+		return errFailSink
+	}
+	s.lines = append(s.lines, string(packet))
+	return nil
+}
+
+func (s *toggleSink) Close() error { return nil }
+
+func Test_WithFailover(t *testing.T) {
+	primarySink := &toggleSink{}
+	primary, err := New("", WithSink(primarySink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backupBuf bytes.Buffer
+	backup, err := New("", WithSink(WriterSink(&backupBuf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &failoverSink{c: &clientConn{}, primary: primary, backup: backup, policy: FailoverPolicy{Failures: 2, ProbeInterval: time.Hour}}
+	c, err := New("", WithSink(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Timing("a", 1)
+	primarySink.down.Store(true)
+	c.Timing("b", 1) // first failure, still on the primary
+	c.Timing("c", 1) // switch, written to the backup
+	c.Timing("d", 1)
+	if !c.OnBackup() {
+		t.Fatal("not on the backup")
+	}
+
+	// the primary is back, the next probe fails back
+	primarySink.down.Store(false)
+	c.Timing("e", 1)
+	s.lastProbe = time.Time{}
+	c.Timing("f", 1)
+	if c.OnBackup() {
+		t.Fatal("still on the backup")
+	}
+
+	if got, want := strings.Join(primarySink.lines, "\n"), "a:1|ms\nf:1|ms"; got != want {
+		t.Fatalf("primary got:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := backupBuf.String(), "c:1|ms\nd:1|ms\ne:1|ms\n"; got != want {
+		t.Fatalf("backup got:\n%s\nwant:\n%s", got, want)
+	}
+}
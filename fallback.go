@@ -0,0 +1,81 @@
+package statsd
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// FallbackPolicy select the lines written to a fallback
+type FallbackPolicy struct {
+	// Prefixes keep only the lines whose bucket, client prefix included,
+	// starts with one of them, e.g. the critical counters. Empty keeps all.
+	Prefixes []string
+
+	// SampleRate keep this part of the lines, 1 if 0
+	SampleRate float32
+}
+
+// WithFallback write to w, one per Write, the lines of the packets the
+// connection fails to write (server down, total outage of the metrics
+// network, ...) selected by policy, so that a trail of them survives, see
+// NewSyslogFallback. The errors of w go to the error handler.
+func WithFallback(w io.Writer, policy FallbackPolicy) Option {
+	return func(c *Client) {
+		if policy.SampleRate == 0 {
+			policy.SampleRate = 1
+		}
+		c.fallback = &fallback{w: w, policy: policy}
+	}
+}
+
+type fallback struct {
+	m      sync.Mutex // a plain io.Writer may not be safe for concurrent use
+	w      io.Writer
+	policy FallbackPolicy
+}
+
+// keep tell if the policy selects line
+func (p *FallbackPolicy) keep(line string) bool {
+	if len(p.Prefixes) > 0 {
+		found := false
+		for _, prefix := range p.Prefixes {
+			if strings.HasPrefix(line, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return shouldFire(p.SampleRate)
+}
+
+// writeFallback write the lines of a packet which couldn't be written to the fallback
+func (c *clientConn) writeFallback(packet []byte) {
+	f := c.fallback
+	f.m.Lock()
+	defer f.m.Unlock()
+	for _, line := range c.packetLines(string(packet)) {
+		if !f.policy.keep(line) {
+			continue
+		}
+		if _, err := io.WriteString(f.w, line); err != nil {
+			c.handleError(err)
+			return
+		}
+	}
+}
+
+// packetLines return the lines of a packet, expanded if compressed
+func (c *clientConn) packetLines(packet string) []string {
+	lines := strings.Split(strings.TrimSuffix(packet, "\n"), "\n")
+	if c.compress {
+		var dec PrefixDecoder
+		for i := range lines {
+			lines[i], _ = dec.Decode(lines[i])
+		}
+	}
+	return lines
+}
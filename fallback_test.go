@@ -0,0 +1,50 @@
+package statsd
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// lineWriter keep the lines written, one per Write
+type lineWriter struct {
+	m     sync.Mutex
+	lines []string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.m.Lock()
+	w.lines = append(w.lines, string(p))
+	w.m.Unlock()
+	return len(p), nil
+}
+
+func Test_WithFallback(t *testing.T) {
+	_, l := newTestClient(t, "")
+	w := &lineWriter{}
+	c, err := New(l.LocalAddr().String(), WithPrefix("shop"), WithFallback(w, FallbackPolicy{Prefixes: []string{"shop.orders."}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// written to the server, not to the fallback
+	c.Timing("orders.latency", 1)
+	readPacket(t, l)
+
+	conn := c.conn
+	c.conn = nil
+	c.Timing("orders.latency", 2, "shop:a")
+	c.Timing("db", 3)
+	c.sendLines([]string{c.format("orders.created", 1, "c", 1), c.format("orders.failed", 1, "c", 1)})
+	c.conn = conn
+
+	want := []string{
+		"shop.orders.latency:2|ms|#shop:a",
+		"shop.orders.created:1|c",
+		"shop.orders.failed:1|c",
+	}
+	if !reflect.DeepEqual(w.lines, want) {
+		t.Fatalf("fallback: %q <=> want: %q", w.lines, want)
+	}
+}
@@ -0,0 +1,77 @@
+package statsd
+
+import (
+	"fmt"
+	"path"
+)
+
+// WithAllow send only the metrics matching one of the patterns, globs of
+// path.Match as "http.*", matched against the stat given to the call and
+// against the stat with the prefix of the client. The other calls send
+// nothing and return nil.
+func WithAllow(patterns ...string) Option {
+	return func(c *Client) {
+		c.allowed = append(c.allowed, patterns...)
+	}
+}
+
+// WithDeny drop the metrics matching one of the patterns, e.g. "debug.*" in
+// production, as WithAllow does the ones not matching. Deny wins over Allow.
+func WithDeny(patterns ...string) Option {
+	return func(c *Client) {
+		c.denied = append(c.denied, patterns...)
+	}
+}
+
+// checkPatterns return the first malformed pattern of WithAllow or WithDeny
+func checkPatterns(name string, patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("statsd: %s %q: %w", name, p, err)
+		}
+	}
+	return nil
+}
+
+// admit apply the name rules, the filters and the budget of the client to
+// stat, ok is false for a metric to drop, with the error to return if any
+func (c *Client) admit(stat string, tags []string) (_ string, ok bool, err error) {
+	if stat, err = c.checkName(stat); err != nil {
+		return stat, false, err
+	}
+	if !c.passes(stat) {
+		return stat, false, nil // filtered out
+	}
+	if !c.allow(stat, tags) {
+		return stat, false, ErrBudgetExceeded
+	}
+	return stat, true, nil
+}
+
+// passes tell if the filters of WithAllow and WithDeny let stat through
+func (c *Client) passes(stat string) bool {
+	if len(c.allowed) == 0 && len(c.denied) == 0 {
+		return true
+	}
+
+	bucket := c.bucket(stat)
+	if matchAny(c.denied, stat, bucket) {
+		return false
+	}
+	return len(c.allowed) == 0 || matchAny(c.allowed, stat, bucket)
+}
+
+// matchAny tell if stat or bucket match one of the patterns
+func matchAny(patterns []string, stat, bucket string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, stat); ok {
+			return true
+		}
+		if bucket != stat {
+			if ok, _ := path.Match(p, bucket); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package statsd
+
+import (
+	"errors"
+	"path"
+	"testing"
+)
+
+func Test_WithAllowDeny(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"),
+		WithAllow("http.*", "api.db.*", "jobs.*"), WithDeny("debug.*", "*.trace", "jobs.tmp.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		c    *Client
+		stat string
+		want bool
+	}{
+		{c, "http.requests", true},
+		{c, "db.queries", true}, // by the prefixed bucket
+		{c, "cache.hits", false},
+		{c, "debug.loops", false},
+		{c, "http.trace", false}, // deny wins
+		{c, "jobs.tmp.size", false},
+		{c, "jobs.done", true},
+		{c.WithPrefix("db"), "queries", true},
+	}
+	for _, tt := range tests {
+		if got := tt.c.passes(tt.stat); got != tt.want {
+			t.Errorf("passes(%q): %t <=> want: %t", tt.stat, got, tt.want)
+		}
+	}
+
+	// the filtered calls send nothing and return nil
+	if err := c.Timing("debug.loops", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.NewGauge("cache.size").Set(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Timing("http.latency", 2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPacket(t, l), "api.http.latency:2|ms"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithDeny("[")); !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("err: %v <=> want: %v", err, path.ErrBadPattern)
+	}
+}
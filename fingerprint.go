@@ -0,0 +1,98 @@
+package statsd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// statStarted is the counter sent once on connect by WithStartupBanner
+const statStarted = "statsd.client.started"
+
+// WithStartupBanner make the client send, once connected, a
+// statsd.client.started counter tagged with "config:<fingerprint>", so that
+// fleet operators can spot the instances running a stale or divergent config
+func WithStartupBanner() Option {
+	return func(c *Client) {
+		c.banner = true
+	}
+}
+
+// ConfigFingerprint return a short hash of the effective config of the client,
+// identical for the clients configured the same way
+func (c *Client) ConfigFingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "prefix=%s\n", c.prefix)
+	fmt.Fprintf(h, "sample_rate=%g\n", c.sampleRate)
+	fmt.Fprintf(h, "sample_rate_precision=%d\n", c.ratePrecision)
+	if c.schedule != nil {
+		loc := "Local"
+		if c.schedule.Location != nil {
+			loc = c.schedule.Location.String()
+		}
+		fmt.Fprintf(h, "sample_schedule=%s %v\n", loc, c.schedule.Windows)
+	}
+	if len(c.sampleRates) > 0 {
+		keys := make([]string, 0, len(c.sampleRates))
+		for key := range c.sampleRates {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(h, "sample_rates[%s]=%g\n", key, c.sampleRates[key])
+		}
+	}
+	fmt.Fprintf(h, "hash_sampling=%t %s\n", c.hashSampling, c.hashInterval)
+	fmt.Fprintf(h, "tags=%s\n", strings.Join(c.tags, ","))
+	fmt.Fprintf(h, "network=%s\n", c.network)
+	fmt.Fprintf(h, "protocol=%s\n", c.protocol)
+	fmt.Fprintf(h, "hosts=%s\n", strings.Join(c.hosts, ","))
+	fmt.Fprintf(h, "backup=%s %+v\n", c.backup, c.failoverPolicy)
+	fmt.Fprintf(h, "mirrors=%s\n", strings.Join(c.mirrors, ","))
+	fmt.Fprintf(h, "connections=%d\n", c.connections)
+	fmt.Fprintf(h, "dialer=%t\n", c.dialer != nil)
+	fmt.Fprintf(h, "socket_options=%d %t\n", c.socketOptions.SendBuffer, c.socketOptions.Control != nil)
+	fmt.Fprintf(h, "timeouts=%s %s\n", c.connectTimeout, c.writeTimeout)
+	if c.sink != nil {
+		fmt.Fprintf(h, "sink=%T\n", c.sink)
+	}
+	fmt.Fprintf(h, "flush_interval=%s\n", c.flushInterval)
+	fmt.Fprintf(h, "max_packet_size=%d\n", c.maxPacketSize)
+	fmt.Fprintf(h, "max_buffered_metrics=%d\n", c.maxMetrics)
+	fmt.Fprintf(h, "max_buffered_bytes=%d\n", c.maxBytes)
+	fmt.Fprintf(h, "timing_unit=%s\n", c.timingUnit)
+	fmt.Fprintf(h, "float_timings=%t\n", c.floatTimings)
+	fmt.Fprintf(h, "prefix_compression=%t\n", c.compress)
+	fmt.Fprintf(h, "extensions=%d\n", c.extensions)
+	fmt.Fprintf(h, "extension_schema=%d\n", c.extensionSchema)
+	fmt.Fprintf(h, "telemetry=%t\n", c.telemetry)
+	fmt.Fprintf(h, "aggregation=%t\n", c.aggregate)
+	fmt.Fprintf(h, "timer_percentiles=%t\n", c.percentiles)
+	fmt.Fprintf(h, "sorted_flush=%t\n", c.sortFlush)
+	fmt.Fprintf(h, "gauge_allow_negative=%t\n", c.allowNegative)
+	fmt.Fprintf(h, "max_batch_delay=%s\n", c.batchDelay)
+	fmt.Fprintf(h, "gap_threshold=%s\n", c.gapThreshold)
+	fmt.Fprintf(h, "fallback=%t\n", c.fallback != nil)
+	if c.rt != nil {
+		fmt.Fprintf(h, "realtime_queue=%d %s\n", c.rt.size, c.rt.bound)
+	}
+	fmt.Fprintf(h, "tls=%t\n", c.tlsConfig != nil)
+	fmt.Fprintf(h, "name_hashing=%t\n", c.hasher != nil)
+	fmt.Fprintf(h, "sanitizer=%t\n", c.sanitizer != nil)
+	if c.tagLimit != nil {
+		fmt.Fprintf(h, "tag_limit=%d\n", c.tagLimit.limit)
+	}
+	fmt.Fprintf(h, "allow=%s\n", strings.Join(c.allowed, ","))
+	fmt.Fprintf(h, "deny=%s\n", strings.Join(c.denied, ","))
+	fmt.Fprintf(h, "rate_limit=%g %g\n", c.rateLimit.Metrics, c.rateLimit.Bytes)
+	fmt.Fprintf(h, "name_rules=%d %t %t\n", c.nameRules.MaxLength, c.nameRules.Truncate, c.nameRules.Allowed != nil)
+
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// sendBanner send the startup banner
+func (c *Client) sendBanner() error {
+	return c.send(statStarted, 1, "c", 1, "config:"+c.ConfigFingerprint())
+}
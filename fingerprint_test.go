@@ -0,0 +1,39 @@
+package statsd
+
+import (
+	"net"
+	"testing"
+)
+
+func Test_StartupBanner(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	newClient := func(opts ...Option) *Client {
+		c, err := New(l.LocalAddr().String(), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	a := newClient(WithPrefix("proj"), WithSampleRate(0.5), WithStartupBanner())
+	want := "proj.statsd.client.started:1|c|#config:" + a.ConfigFingerprint()
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+
+	same := newClient(WithSampleRate(0.5), WithPrefix("proj."))
+	if a.ConfigFingerprint() != same.ConfigFingerprint() {
+		t.Fatal("same configs have different fingerprints")
+	}
+
+	other := newClient(WithPrefix("proj"), WithSampleRate(0.1))
+	if a.ConfigFingerprint() == other.ConfigFingerprint() {
+		t.Fatal("different configs have the same fingerprint")
+	}
+}
@@ -0,0 +1,29 @@
+// Package fxstats provide a statsd.Statter to go.uber.org/fx applications
+package fxstats
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// Module provide a statsd.Statter built from the *statsd.Config of the
+// application, closed when the application stops
+var Module = fx.Module("statsd", fx.Provide(New))
+
+// New build a statsd.Statter from cfg and close it on stop
+func New(lc fx.Lifecycle, cfg *statsd.Config) (statsd.Statter, error) {
+	s, err := statsd.NewStatter(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return s.Close()
+		},
+	})
+	return s, nil
+}
@@ -0,0 +1,45 @@
+package fxstats
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+func Test_Module(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	cfg := &statsd.Config{
+		Project: "fx",
+		Host:    "127.0.0.1",
+		Port:    l.LocalAddr().(*net.UDPAddr).Port,
+		Enable:  true,
+	}
+
+	var s statsd.Statter
+	app := fxtest.New(t, fx.Supply(cfg), Module, fx.Populate(&s))
+	app.RequireStart()
+
+	if err := s.Timing("start", 1); err != nil {
+		t.Fatal(err)
+	}
+	app.RequireStop()
+
+	// the statter is closed with the application
+	if err := s.Timing("stopped", 1); !errors.Is(err, statsd.ErrClosed) {
+		t.Fatalf("err: %v <=> want: %v", err, statsd.ErrClosed)
+	}
+
+	if _, err := New(fxtest.NewLifecycle(t), &statsd.Config{Enable: false}); err != nil {
+		t.Fatal(err)
+	}
+}
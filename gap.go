@@ -0,0 +1,56 @@
+package statsd
+
+import (
+	"sync"
+	"time"
+)
+
+// WithGapDetection send, once the client can write again after having failed
+// to for at least threshold (agent down, network partition, ...), the timer
+// statsd.client.gap holding the duration of the blind spot, for the
+// dashboards to annotate it. It is sent with the next flush.
+func WithGapDetection(threshold time.Duration) Option {
+	return func(c *Client) {
+		c.gapThreshold = threshold
+	}
+}
+
+// gapStats track the failing writes for WithGapDetection
+type gapStats struct {
+	sync.Mutex
+	failingSince time.Time // first failed write of the current gap, zero while writing
+	gaps         []time.Duration
+}
+
+// trackGap record the result of a write
+func (c *clientConn) trackGap(err error) {
+	c.gapStats.Lock()
+	defer c.gapStats.Unlock()
+
+	if err != nil {
+		if c.gapStats.failingSince.IsZero() {
+			c.gapStats.failingSince = time.Now()
+		}
+		return
+	}
+	if c.gapStats.failingSince.IsZero() {
+		return
+	}
+	if gap := time.Since(c.gapStats.failingSince); gap >= c.gapThreshold {
+		c.gapStats.gaps = append(c.gapStats.gaps, gap)
+	}
+	c.gapStats.failingSince = time.Time{}
+}
+
+// gapLines append the lines of the gaps detected since the last flush
+func (c *Client) gapLines(lines []string) []string {
+	c.gapStats.Lock()
+	gaps := c.gapStats.gaps
+	c.gapStats.gaps = nil
+	c.gapStats.Unlock()
+
+	for _, gap := range gaps {
+		lines = append(lines, c.format("statsd.client.gap", milliseconds(gap), "ms", 1))
+	}
+	return lines
+}
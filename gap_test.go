@@ -0,0 +1,48 @@
+package statsd
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WithGapDetection(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithGapDetection(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// a short failure is no gap
+	conn := c.conn
+	c.conn = nil
+	if err := c.Timing("lost", 1); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrNotConnected)
+	}
+	c.conn = conn
+	c.Timing("found", 1)
+	readPacket(t, l)
+
+	c.conn = nil
+	c.Timing("lost", 1)
+	time.Sleep(30 * time.Millisecond)
+	c.Timing("lost", 1)
+	c.conn = conn
+	c.Timing("found", 1)
+	readPacket(t, l)
+
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+	got := readPacket(t, l)
+	if !strings.HasPrefix(got, "api.statsd.client.gap:") || !strings.HasSuffix(got, "|ms") || strings.Contains(got, "\n") {
+		t.Fatalf("got: %s <=> want: one api.statsd.client.gap timer", got)
+	}
+	value := strings.TrimSuffix(strings.TrimPrefix(got, "api.statsd.client.gap:"), "|ms")
+	if ms, err := strconv.ParseFloat(value, 64); err != nil || ms < 30 {
+		t.Fatalf("gap: %s <=> want: at least 30ms", got)
+	}
+}
@@ -0,0 +1,64 @@
+package statsd
+
+import (
+	"sync"
+	"time"
+)
+
+// GaugePoller send a gauge read from a function every interval, see
+// Client.GaugeFunc
+type GaugePoller struct {
+	c      *Client
+	stat   string
+	fn     func() float64
+	tags   []string
+	ticker Ticker
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// GaugeFunc call fn every interval and send its result as the gauge stat,
+// until Stop is called or the client is closed. The errors go to the error
+// handler of the client.
+func (c *Client) GaugeFunc(stat string, fn func() float64, interval time.Duration, tags ...string) (*GaugePoller, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if c.closed.Load() {
+		return nil, ErrClosed
+	}
+
+	p := &GaugePoller{
+		c:      c,
+		stat:   stat,
+		fn:     fn,
+		tags:   append([]string(nil), tags...),
+		ticker: c.clock.NewTicker(interval),
+		done:   make(chan struct{}),
+	}
+	go p.loop()
+	return p, nil
+}
+
+// Stop polling
+func (p *GaugePoller) Stop() {
+	p.stopOnce.Do(func() {
+		p.ticker.Stop()
+		close(p.done)
+	})
+}
+
+func (p *GaugePoller) loop() {
+	defer p.ticker.Stop()
+	for {
+		select {
+		case <-p.ticker.C():
+			p.c.handleError(p.c.FGauge(p.stat, p.fn(), p.tags...))
+		case <-p.done:
+			return
+		case <-p.c.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package statsd
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_GaugeFunc(t *testing.T) {
+	c, l := newTestClient(t, "api")
+
+	var depth atomic.Int64
+	depth.Store(3)
+	p, err := c.GaugeFunc("queue.depth", func() float64 { return float64(depth.Load()) }, 10*time.Millisecond, "q:orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readPacket(t, l); got != "api.queue.depth:3|g|#q:orders" {
+		t.Fatalf("got: %s <=> want: api.queue.depth:3|g|#q:orders", got)
+	}
+	depth.Store(5)
+	for got := readPacket(t, l); got != "api.queue.depth:5|g|#q:orders"; got = readPacket(t, l) {
+		if got != "api.queue.depth:3|g|#q:orders" {
+			t.Fatalf("got: %s", got)
+		}
+	}
+	p.Stop()
+	p.Stop()
+
+	if _, err := c.GaugeFunc("queue.depth", func() float64 { return 0 }, 0); err != ErrInvalidInterval {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+}
+
+func Test_GaugeFuncStopsOnClose(t *testing.T) {
+	c, _ := newTestClient(t, "")
+
+	var calls atomic.Int64
+	p, err := c.GaugeFunc("queue.depth", func() float64 { calls.Add(1); return 1 }, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	time.Sleep(5 * time.Millisecond)
+	n := calls.Load()
+	time.Sleep(10 * time.Millisecond)
+	if calls.Load() != n {
+		t.Fatalf("polled after Close: %d <=> %d", calls.Load(), n)
+	}
+	p.Stop()
+
+	if _, err := c.GaugeFunc("queue.depth", func() float64 { return 0 }, time.Second); err != ErrClosed {
+		t.Fatalf("err: %v <=> want: %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/sunlit-coder/statsd
+
+go 1.22
@@ -0,0 +1,10 @@
+module github.com/sunlit-coder/statsd/gometricstats
+
+go 1.22
+
+require (
+	github.com/rcrowley/go-metrics v0.0.0-20250401214520-65e299d6c5c9
+	github.com/sunlit-coder/statsd v0.0.0
+)
+
+replace github.com/sunlit-coder/statsd => ../
@@ -0,0 +1,155 @@
+// Package gometricstats mirror a github.com/rcrowley/go-metrics Registry
+// through a statsd.Statter, so that the libraries instrumented with go-metrics
+// share the statsd export path
+package gometricstats
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// ErrInvalidInterval is returned by Start for an interval <= 0
+var ErrInvalidInterval = errors.New("gometricstats: interval is less than or equal to 0")
+
+// percentiles sent for the histograms and the timers, as WithTimerPercentiles does
+var percentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p95", 0.95},
+	{"p99", 0.99},
+}
+
+// Reporter periodically read the registry and send its metrics:
+//   - counters as statsd counters of their change since the last report,
+//   - gauges as gauges,
+//   - meters as counters of their increase,
+//   - histograms and timers as a <name>.count counter of the increase and
+//     <name>.min, .max, .mean, .p95 and .p99 gauges of their sample, the
+//     timers in milliseconds.
+type Reporter struct {
+	registry metrics.Registry
+	client   statsd.Statter
+
+	ticker   *time.Ticker
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// the counts already sent, by metric name
+	m    sync.Mutex
+	sent map[string]int64
+}
+
+// Start mirroring r to s every interval. The counts read now only set the
+// baseline of the counters. A nil s is statsd.DefaultStatter.
+func Start(r metrics.Registry, s statsd.Statter, interval time.Duration) (*Reporter, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if s == nil {
+		s = statsd.DefaultStatter()
+	}
+
+	rep := &Reporter{
+		registry: r,
+		client:   s,
+		done:     make(chan struct{}),
+		sent:     make(map[string]int64),
+	}
+	rep.report(false)
+
+	rep.ticker = time.NewTicker(interval)
+	go rep.loop()
+	return rep, nil
+}
+
+// Stop mirroring
+func (rep *Reporter) Stop() {
+	rep.stopOnce.Do(func() {
+		rep.ticker.Stop()
+		close(rep.done)
+	})
+}
+
+func (rep *Reporter) loop() {
+	for {
+		select {
+		case <-rep.ticker.C:
+			rep.report(true)
+		case <-rep.done:
+			return
+		}
+	}
+}
+
+// report read the registry and send the metrics, or only record the counts
+// when send is false
+func (rep *Reporter) report(send bool) {
+	rep.m.Lock()
+	defer rep.m.Unlock()
+
+	rep.registry.Each(func(name string, i interface{}) {
+		switch m := i.(type) {
+		case metrics.Counter:
+			rep.counter(name, m.Snapshot().Count(), true, send)
+		case metrics.Gauge:
+			if send {
+				rep.client.Gauge(name, m.Snapshot().Value())
+			}
+		case metrics.GaugeFloat64:
+			if send {
+				rep.client.FGauge(name, m.Snapshot().Value())
+			}
+		case metrics.Meter:
+			rep.counter(name, m.Snapshot().Count(), false, send)
+		case metrics.Histogram:
+			h := m.Snapshot()
+			rep.sample(name, h.Count(), float64(h.Min()), float64(h.Max()), h.Mean(), h.Percentiles, 1, send)
+		case metrics.Timer:
+			t := m.Snapshot()
+			rep.sample(name, t.Count(), float64(t.Min()), float64(t.Max()), t.Mean(), t.Percentiles, float64(time.Millisecond), send)
+		}
+	})
+}
+
+// counter send the change of a count since the last report. A count going
+// down is a decrement when signed, a reset of the instrumented code otherwise.
+func (rep *Reporter) counter(name string, count int64, signed bool, send bool) {
+	prev, seen := rep.sent[name]
+	rep.sent[name] = count
+	if !send || !seen {
+		return
+	}
+
+	switch delta := count - prev; {
+	case delta > 0:
+		rep.client.Incr(name, delta)
+	case delta < 0 && signed:
+		rep.client.Decr(name, -delta)
+	}
+}
+
+// sample send the count and the statistics of a histogram or a timer, the
+// values divided by unit
+func (rep *Reporter) sample(name string, count int64, min, max, mean float64, quantiles func([]float64) []float64, unit float64, send bool) {
+	rep.counter(name+".count", count, false, send)
+	if !send || count == 0 {
+		return
+	}
+
+	rep.client.FGauge(name+".min", min/unit)
+	rep.client.FGauge(name+".max", max/unit)
+	rep.client.FGauge(name+".mean", mean/unit)
+	qs := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		qs[i] = p.q
+	}
+	for i, v := range quantiles(qs) {
+		rep.client.FGauge(name+"."+percentiles[i].name, v/unit)
+	}
+}
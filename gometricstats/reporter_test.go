@@ -0,0 +1,99 @@
+package gometricstats
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// recordStatter record the metrics sent by the reporter
+type recordStatter struct {
+	statsd.Statter
+	lines []string
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%d|c", stat, count))
+	return nil
+}
+
+func (r *recordStatter) Decr(stat string, count int64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:-%d|c", stat, count))
+	return nil
+}
+
+func (r *recordStatter) Gauge(stat string, value int64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%d|g", stat, value))
+	return nil
+}
+
+func (r *recordStatter) FGauge(stat string, value float64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%g|g", stat, value))
+	return nil
+}
+
+func Test_Reporter(t *testing.T) {
+	reg := metrics.NewRegistry()
+	jobs := metrics.NewRegisteredCounter("jobs", reg)
+	queue := metrics.NewRegisteredGauge("queue", reg)
+	load := metrics.NewRegisteredGaugeFloat64("load", reg)
+	hits := metrics.NewRegisteredMeter("hits", reg)
+	sizes := metrics.NewRegisteredHistogram("sizes", reg, metrics.NewUniformSample(100))
+	db := metrics.NewRegisteredTimer("db", reg)
+	defer hits.Stop()
+	defer db.Stop()
+
+	jobs.Inc(5)
+	hits.Mark(2)
+	r := &recordStatter{}
+	rep, err := Start(reg, r, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Stop()
+	if len(r.lines) != 0 {
+		t.Fatalf("baseline report sent: %v", r.lines)
+	}
+
+	jobs.Dec(2)
+	queue.Update(7)
+	load.Update(0.5)
+	hits.Mark(3)
+	sizes.Update(10)
+	sizes.Update(30)
+	db.Update(4 * time.Millisecond)
+	rep.report(true)
+
+	want := []string{
+		"db.count:1|c",
+		"db.max:4|g",
+		"db.mean:4|g",
+		"db.min:4|g",
+		"db.p95:4|g",
+		"db.p99:4|g",
+		"hits:3|c",
+		"jobs:-2|c",
+		"load:0.5|g",
+		"queue:7|g",
+		"sizes.count:2|c",
+		"sizes.max:30|g",
+		"sizes.mean:20|g",
+		"sizes.min:10|g",
+		"sizes.p95:30|g",
+		"sizes.p99:30|g",
+	}
+	sort.Strings(r.lines)
+	if !reflect.DeepEqual(r.lines, want) {
+		t.Fatalf("got:\n%v\nwant:\n%v", r.lines, want)
+	}
+
+	if _, err := Start(reg, r, 0); err != ErrInvalidInterval {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+}
@@ -0,0 +1,38 @@
+package statsd
+
+import "strconv"
+
+// appendGraphiteValue end a Graphite plaintext line, " <value> <timestamp>",
+// after its name and tags. The counters sampled are scaled to the estimated
+// count, Graphite having no sample rate.
+func (c *Client) appendGraphiteValue(dst []byte, value interface{}, t string, sampleRate float32) []byte {
+	dst = append(dst, ' ')
+	if t == "c" && sampleRate > 0 && sampleRate < 1 {
+		dst = strconv.AppendFloat(dst, floatValue(value)/float64(sampleRate), 'f', -1, 64)
+	} else {
+		dst = appendRawValue(dst, value)
+	}
+	dst = append(dst, ' ')
+
+	at := c.asOf
+	if at.IsZero() {
+		at = c.clock.Now()
+	}
+	return strconv.AppendInt(dst, at.Unix(), 10)
+}
+
+// floatValue return a value of appendRawValue as a float64
+func floatValue(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package statsd
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_ProtocolGraphite(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	c, err := New(l.Addr().String(), WithNetwork("tcp"), WithProtocol(ProtocolGraphite), WithClock(clock),
+		WithPrefix("web"), WithTags("env:prod"), WithPrefixCompression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	lines := bufio.NewScanner(conn)
+
+	c.Timing("render", 12, "page:home")
+	c.FGauge("load", 0.5)
+	c.addToBuffer("hits", 2, 0.5, nil) // sampled, without the randomness
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"web.render;env=prod;page=home 12 1700000000",
+		"web.load;env=prod 0.5 1700000000",
+		// scaled by the sample rate
+		"web.hits;env=prod 4 1700000000",
+	} {
+		if !lines.Scan() {
+			t.Fatalf("no line, want: %q (%v)", want, lines.Err())
+		}
+		if got := lines.Text(); got != want {
+			t.Fatalf("line: %q <=> want: %q", got, want)
+		}
+	}
+
+	// the default client of the config connects over TCP
+	cfg := &Client{clientConn: &clientConn{}}
+	for _, opt := range (&Config{Protocol: ProtocolGraphite}).options() {
+		opt(cfg)
+	}
+	if cfg.network != "tcp" {
+		t.Fatalf("network: %s <=> want: tcp", cfg.network)
+	}
+}
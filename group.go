@@ -0,0 +1,111 @@
+package statsd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GaugeGroup set a group of related gauges in a single packet, so that a
+// server never sees some of them from a flush and the others from the next
+// one (e.g. used and total of a pool, whose ratio is graphed). The group is
+// written at once even if it is larger than the max packet size. The gauges
+// are checked, filtered and budgeted one by one as the metrics of c, those
+// left out don't prevent the others and the first error is returned.
+func (c *Client) GaugeGroup(gauges map[string]float64, tags ...string) error {
+	if len(gauges) == 0 {
+		return nil
+	}
+
+	stats := make([]string, 0, len(gauges))
+	for stat := range gauges {
+		stats = append(stats, stat)
+	}
+	sort.Strings(stats)
+
+	var first error
+	lines := make([]string, 0, len(stats))
+	for _, name := range stats {
+		value := gauges[name]
+		stat, ok, err := c.admit(name, tags)
+		if !ok {
+			if err != nil && first == nil {
+				first = newSendError(err, "GaugeGroup", name, value)
+			}
+			continue
+		}
+		if value < 0 && !c.allowNegative {
+			lines = append(lines, c.format(stat, 0, "g", 1, tags...))
+		}
+		lines = append(lines, c.format(stat, value, "g", 1, tags...))
+	}
+
+	if len(lines) > 0 {
+		if err := c.sendPacket(lines); err != nil {
+			return err
+		}
+	}
+	return first
+}
+
+// GaugeVector set one gauge per element of an array-like reading (e.g. per-CPU
+// utilization), each tagged "<indexTag>:<index>" on top of tags, in a single
+// call written under a single lock. The name is checked and filtered once,
+// each element is budgeted as a metric.
+func (c *Client) GaugeVector(stat string, values []float64, indexTag string, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "GaugeVector", stat, values)
+		}
+	}()
+	if len(values) == 0 {
+		return nil
+	}
+	if stat, err = c.checkName(stat); err != nil {
+		return err
+	}
+	if !c.passes(stat) {
+		return nil // filtered out
+	}
+
+	lines := make([]string, 0, len(values))
+	all := append(tags[:len(tags):len(tags)], "")
+	for i, value := range values {
+		all[len(all)-1] = indexTag + ":" + strconv.Itoa(i)
+		if !c.allow(stat, all) {
+			err = ErrBudgetExceeded // the elements past the budget are shed
+			break
+		}
+		line := c.format(stat, value, "g", 1, all...)
+		if value < 0 && !c.allowNegative {
+			// a single line for sendLines, so that both are in the same packet
+			line = c.format(stat, 0, "g", 1, all...) + "\n" + line
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) > 0 {
+		if err := c.sendLines(lines); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// sendPacket write the lines in a single packet
+func (c *Client) sendPacket(lines []string) error {
+	if !c.compress {
+		return c.write([]byte(strings.Join(lines, "\n")))
+	}
+
+	var packet []byte
+	for i, line := range lines {
+		if i == 0 {
+			packet = append(packet, line...)
+			continue
+		}
+		packet = append(packet, '\n')
+		packet = appendCompressed(packet, lines[i-1], line)
+	}
+	return c.write(packet)
+}
@@ -0,0 +1,89 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_GaugeGroup(t *testing.T) {
+	c, l := newTestClient(t, "proj")
+
+	err := c.GaugeGroup(map[string]float64{
+		"pool.used":  3,
+		"pool.total": 10,
+		"pool.delta": -2.5,
+	}, "pool:db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "proj.pool.delta:0|g|#pool:db\n" +
+		"proj.pool.delta:-2.5|g|#pool:db\n" +
+		"proj.pool.total:10|g|#pool:db\n" +
+		"proj.pool.used:3|g|#pool:db"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+
+	if err := c.GaugeGroup(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_GaugeVector(t *testing.T) {
+	c, l := newTestClient(t, "host")
+	c.maxPacketSize = 64 // the vector spans several packets
+
+	if err := c.GaugeVector("cpu.util", []float64{0.5, 12, -1}, "cpu", "env:prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for len(got) < 4 {
+		got = append(got, strings.Split(readPacket(t, l), "\n")...)
+	}
+	want := []string{
+		"host.cpu.util:0.5|g|#env:prod,cpu:0",
+		"host.cpu.util:12|g|#env:prod,cpu:1",
+		"host.cpu.util:0|g|#env:prod,cpu:2",
+		"host.cpu.util:-1|g|#env:prod,cpu:2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got: %v <=> want: %v", got, want)
+	}
+}
+
+func Test_GaugeGroupFiltered(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithDeny("debug.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.GaugeGroup(map[string]float64{"pool.used": 3, "debug.loops": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPacket(t, l), "pool.used:3|g"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+}
+
+func Test_GaugeVectorFiltered(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithDeny("debug.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.GaugeVector("debug.cpu", []float64{1, 2}, "cpu"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GaugeVector("cpu.util", []float64{1}, "cpu"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPacket(t, l), "cpu.util:1|g|#cpu:0"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+}
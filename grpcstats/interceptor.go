@@ -0,0 +1,54 @@
+// Package grpcstats instrument gRPC servers with statsd metrics
+package grpcstats
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// buckets of the server metrics, tagged with "method:<full method>" and "code:<status code>"
+const (
+	statRequests = "grpc.server.requests" // counter
+	statLatency  = "grpc.server.latency"  // timer
+)
+
+// UnaryServerInterceptor count and time the unary calls per method and status
+// code, sending to client or to statsd.DefaultStatter if nil
+func UnaryServerInterceptor(client statsd.Statter) grpc.UnaryServerInterceptor {
+	if client == nil {
+		client = statsd.DefaultStatter()
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		record(client, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor count and time the streams per method and status code,
+// the latency is the lifetime of the stream. A nil client is
+// statsd.DefaultStatter as for UnaryServerInterceptor.
+func StreamServerInterceptor(client statsd.Statter) grpc.StreamServerInterceptor {
+	if client == nil {
+		client = statsd.DefaultStatter()
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		record(client, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func record(client statsd.Statter, method string, start time.Time, err error) {
+	tags := []string{"method:" + method, "code:" + status.Code(err).String()}
+
+	client.Timing(statLatency, int64(time.Since(start)/time.Millisecond), tags...)
+	client.Incr(statRequests, 1, tags...)
+}
@@ -0,0 +1,102 @@
+package grpcstats
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunlit-coder/statsd"
+	"github.com/sunlit-coder/statsd/statsdtest"
+)
+
+// recordStatter record the bucket and tags of the counters and timers
+type recordStatter struct {
+	statsd.Statter
+	lines []string
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.lines = append(r.lines, stat+"|c|"+strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) Timing(stat string, delta int64, tags ...string) error {
+	r.lines = append(r.lines, stat+"|ms|"+strings.Join(tags, ","))
+	return nil
+}
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	r := &recordStatter{}
+	interceptor := UnaryServerInterceptor(r)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such order")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("err: %v", err)
+	}
+
+	want := []string{
+		"grpc.server.latency|ms|method:/orders.Orders/Get,code:NotFound",
+		"grpc.server.requests|c|method:/orders.Orders/Get,code:NotFound",
+	}
+	if strings.Join(r.lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got: %v <=> want: %v", r.lines, want)
+	}
+}
+
+func Test_StreamServerInterceptor(t *testing.T) {
+	r := &recordStatter{}
+	interceptor := StreamServerInterceptor(r)
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch"}
+
+	err := interceptor(nil, nil, info, func(interface{}, grpc.ServerStream) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"grpc.server.latency|ms|method:/orders.Orders/Watch,code:OK",
+		"grpc.server.requests|c|method:/orders.Orders/Watch,code:OK",
+	}
+	if strings.Join(r.lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got: %v <=> want: %v", r.lines, want)
+	}
+}
+
+func Test_InterceptorDefaultClient(t *testing.T) {
+	srv, err := statsdtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	c, err := statsd.New(srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	statsd.SetDefault(c)
+	defer statsd.SetDefault(nil)
+
+	// a nil client is the default one, not a panic
+	interceptor := UnaryServerInterceptor(nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+	interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if err := srv.Wait(1, time.Second); err != nil { // the timer, sent by the send loop
+		t.Fatal(err)
+	}
+	c.Close() // flush the counter
+	if err := srv.Wait(2, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertIncr(t, "grpc.server.requests", 1, "method:/orders.Orders/Get", "code:OK")
+}
@@ -0,0 +1,105 @@
+package statsd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+// NameHasher replace configured segments of the buckets and values of the tags
+// by a truncated HMAC-SHA256, for the environments where even coarse
+// identifiers (tenant, user, ...) can't leave the host in clear text. The same
+// input gives the same hash as long as the key doesn't change, so the series
+// stay graphable.
+type NameHasher struct {
+	segments map[int]bool    // indexes of the dot-separated segments of the stat
+	tagKeys  map[string]bool // tags whose value is hashed
+	key      atomic.Pointer[[]byte]
+}
+
+// NewNameHasher return a hasher of the given segments of the stat (0 is the
+// first one, the client prefix excluded) and of the values of the tags of the
+// given keys, e.g. segments 1 and tag "tenant" turn "orders.acme.placed" with
+// "tenant:acme" into "orders.<hash>.placed" with "tenant:<hash>"
+func NewNameHasher(key []byte, segments []int, tagKeys ...string) *NameHasher {
+	h := &NameHasher{segments: make(map[int]bool), tagKeys: make(map[string]bool)}
+	for _, i := range segments {
+		h.segments[i] = true
+	}
+	for _, k := range tagKeys {
+		h.tagKeys[k] = true
+	}
+	h.Rotate(key)
+	return h
+}
+
+// Rotate replace the key, the metrics sent afterwards use the new one
+func (h *NameHasher) Rotate(key []byte) {
+	key = append([]byte(nil), key...)
+	h.key.Store(&key)
+}
+
+// WithNameHasher hash the names and tags of the client with h before they are sent
+func WithNameHasher(h *NameHasher) Option {
+	return func(c *Client) {
+		c.hasher = h
+	}
+}
+
+// sum return the hash of s with the current key
+func (h *NameHasher) sum(s string) string {
+	mac := hmac.New(sha256.New, *h.key.Load())
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// stat hash the configured segments of stat
+func (h *NameHasher) stat(stat string) string {
+	if len(h.segments) == 0 {
+		return stat
+	}
+
+	segments := strings.Split(stat, ".")
+	for i := range segments {
+		if h.segments[i] {
+			segments[i] = h.sum(segments[i])
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// tags hash the values of the configured tags, tags is left untouched
+func (h *NameHasher) tags(tags []string) []string {
+	if len(h.tagKeys) == 0 {
+		return tags
+	}
+
+	var hashed []string
+	for i, tag := range tags {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok || !h.tagKeys[k] {
+			continue
+		}
+		if hashed == nil {
+			hashed = append([]string(nil), tags...)
+		}
+		hashed[i] = k + ":" + h.sum(v)
+	}
+	if hashed == nil {
+		return tags
+	}
+	return hashed
+}
+
+// hashName apply the sanitizer, the tag limit and the name hasher of the
+// client, if any
+func (c *Client) hashName(stat string, tags []string) (string, []string) {
+	stat, tags = c.sanitize(stat, tags)
+	tags = c.tagLimit.limitTags(c.prefix, stat, tags)
+	if c.hasher == nil {
+		return stat, tags
+	}
+	return c.hasher.stat(stat), c.hasher.tags(tags)
+}
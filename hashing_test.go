@@ -0,0 +1,70 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_NameHasher(t *testing.T) {
+	_, l := newTestClient(t, "")
+	h := NewNameHasher([]byte("k1"), []int{1}, "tenant")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithNameHasher(h))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	acme := h.sum("acme")
+	if len(acme) != 16 || strings.Contains(acme, "acme") {
+		t.Fatalf("hash: %s", acme)
+	}
+
+	c.Timing("orders.acme.placed", 3, "tenant:acme", "route:home")
+	want := "api.orders." + acme + ".placed:3|ms|#tenant:" + acme + ",route:home"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+
+	// the buffered counters are hashed too, before they are merged
+	c.Incr("orders.acme.count", 1, "tenant:acme")
+	c.m.Lock()
+	buffered := c.buffer[0]
+	c.m.Unlock()
+	if buffered.name != "api.orders."+acme+".count" || buffered.tags != "tenant:"+acme {
+		t.Fatalf("buffer: %v", buffered)
+	}
+
+	// a rotated key gives new hashes
+	h.Rotate([]byte("k2"))
+	if h.sum("acme") == acme {
+		t.Fatal("hash unchanged by the key rotation")
+	}
+
+	tags := []string{"tenant:acme"}
+	h.tags(tags)
+	if tags[0] != "tenant:acme" {
+		t.Fatalf("call tags modified: %v", tags)
+	}
+}
+
+func Test_NameHasherInstrument(t *testing.T) {
+	_, l := newTestClient(t, "")
+	h := NewNameHasher([]byte("k1"), []int{1}, "tenant")
+	c, err := New(l.LocalAddr().String(), WithNameHasher(h))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the instruments built before a rotation send with the new key
+	g := c.NewGauge("orders.acme", "tenant:acme")
+	g.Set(1)
+	if got, want := readPacket(t, l), "orders."+h.sum("acme")+":1|g|#tenant:"+h.sum("acme"); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+	h.Rotate([]byte("k2"))
+	g.Set(2)
+	if got, want := readPacket(t, l), "orders."+h.sum("acme")+":2|g|#tenant:"+h.sum("acme"); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+}
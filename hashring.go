@@ -0,0 +1,58 @@
+package statsd
+
+import (
+	"crypto/md5"
+	"sort"
+	"strconv"
+)
+
+// ketamaPoints is the number of md5 digests per host of a HashRing, each
+// digest giving 4 points of the ring
+const ketamaPoints = 40
+
+// HashRing is a ketama consistent hash ring of hosts, the hashing of the
+// statsd proxies: a key is mapped to the same host as long as the host is in
+// the ring, and adding or removing a host only moves the keys of its share.
+type HashRing struct {
+	points []ringPoint // sorted by hash
+}
+
+type ringPoint struct {
+	hash uint32
+	host string
+}
+
+// NewHashRing return the ring of hosts ("host:port"), nil if there is none
+func NewHashRing(hosts []string) *HashRing {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	r := &HashRing{points: make([]ringPoint, 0, len(hosts)*ketamaPoints*4)}
+	for _, host := range hosts {
+		for i := 0; i < ketamaPoints; i++ {
+			d := md5.Sum([]byte(host + "-" + strconv.Itoa(i)))
+			for h := 0; h < 4; h++ {
+				r.points = append(r.points, ringPoint{ketamaHash(d[h*4:]), host})
+			}
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+	return r
+}
+
+// Host return the host of key, usually a bucket
+func (r *HashRing) Host(key string) string {
+	d := md5.Sum([]byte(key))
+	hash := ketamaHash(d[:])
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= hash })
+	if i == len(r.points) {
+		i = 0 // past the last point, the ring wraps
+	}
+	return r.points[i].host
+}
+
+// ketamaHash read the first 4 bytes of a digest as a little endian uint32
+func ketamaHash(d []byte) uint32 {
+	return uint32(d[3])<<24 | uint32(d[2])<<16 | uint32(d[1])<<8 | uint32(d[0])
+}
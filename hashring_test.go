@@ -0,0 +1,40 @@
+package statsd
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_HashRing(t *testing.T) {
+	hosts := []string{"a:8125", "b:8125", "c:8125"}
+	r := NewHashRing(hosts)
+
+	shares := make(map[string]int)
+	placed := make(map[string]string)
+	for i := 0; i < 3000; i++ {
+		key := "api.requests." + strconv.Itoa(i)
+		host := r.Host(key)
+		if host != r.Host(key) {
+			t.Fatalf("%s: not stable", key)
+		}
+		shares[host]++
+		placed[key] = host
+	}
+	for _, host := range hosts {
+		if shares[host] < 600 {
+			t.Fatalf("shares: %v, %s is underused", shares, host)
+		}
+	}
+
+	// removing a host only moves its keys
+	r = NewHashRing(hosts[:2])
+	for key, host := range placed {
+		if host != "c:8125" && r.Host(key) != host {
+			t.Fatalf("%s moved from %s to %s", key, host, r.Host(key))
+		}
+	}
+
+	if NewHashRing(nil) != nil {
+		t.Fatal("ring of no host")
+	}
+}
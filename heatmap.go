@@ -0,0 +1,76 @@
+package statsd
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidHeatmap is returned for heatmap buckets that can't grow from Min to Max
+var ErrInvalidHeatmap = errors.New("heatmap base must be larger than 1 and 0 < min <= max")
+
+// HeatmapBuckets define fixed log-scale buckets: Min, Min*Base, Min*Base^2, ...
+// up to Max, bounds are rounded to the microsecond
+type HeatmapBuckets struct {
+	Base float64
+	Min  time.Duration
+	Max  time.Duration
+}
+
+// DefaultHeatmapBuckets go from 1ms to 8.192s, doubling each time
+var DefaultHeatmapBuckets = HeatmapBuckets{Base: 2, Min: time.Millisecond, Max: 10 * time.Second}
+
+// Heatmap turn timer samples into counters of fixed log-scale buckets, e.g.
+// latency.bucket.1ms, latency.bucket.2ms, latency.bucket.4ms, ... and
+// latency.bucket.inf, suitable for Grafana heatmaps. A sample is counted in
+// the first bucket whose bound is larger or equal to it. The counters go
+// through the buffer of the client, so each flush sends the window counts.
+// The samples are observed one by one, the client keeps no timer sketch to
+// convert from, and they are never sampled since the buffer is sent at @1.
+type Heatmap struct {
+	client *Client
+	bounds []time.Duration
+	names  []string // bucket of each bound, plus the inf one
+}
+
+// NewHeatmap return a heatmap sending its buckets under stat
+func (c *Client) NewHeatmap(stat string, b HeatmapBuckets) (*Heatmap, error) {
+	if b.Base <= 1 || b.Min <= 0 || b.Max < b.Min {
+		return nil, ErrInvalidHeatmap
+	}
+
+	h := &Heatmap{client: c}
+	for bound := b.Min; bound <= b.Max; {
+		h.bounds = append(h.bounds, bound)
+		h.names = append(h.names, stat+".bucket."+durationLabel(bound))
+
+		next := time.Duration(float64(bound) * b.Base).Round(time.Microsecond)
+		if next <= bound {
+			next = bound + time.Microsecond
+		}
+		bound = next
+	}
+	h.names = append(h.names, stat+".bucket.inf")
+
+	return h, nil
+}
+
+// Observe count a timer sample in its bucket
+func (h *Heatmap) Observe(d time.Duration, tags ...string) error {
+	i := sort.Search(len(h.bounds), func(i int) bool { return h.bounds[i] >= d })
+	return h.client.IncrWithSampling(h.names[i], 1, 1, tags...)
+}
+
+// durationLabel format a bucket bound in the largest unit it is a whole number of
+func durationLabel(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	case d%time.Millisecond == 0:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+	case d%time.Microsecond == 0:
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + "us"
+	}
+	return strconv.FormatInt(int64(d), 10) + "ns"
+}
@@ -0,0 +1,96 @@
+package statsd
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_NewHeatmap(t *testing.T) {
+	c, _ := newTestClient(t, "")
+
+	h, err := c.NewHeatmap("latency", HeatmapBuckets{Base: 2, Min: time.Millisecond, Max: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"latency.bucket.1ms",
+		"latency.bucket.2ms",
+		"latency.bucket.4ms",
+		"latency.bucket.8ms",
+		"latency.bucket.inf",
+	}
+	if len(h.names) != len(want) {
+		t.Fatalf("buckets: %v <=> want: %v", h.names, want)
+	}
+	for i := range want {
+		if h.names[i] != want[i] {
+			t.Fatalf("buckets: %v <=> want: %v", h.names, want)
+		}
+	}
+
+	for _, b := range []HeatmapBuckets{
+		{Base: 1, Min: time.Millisecond, Max: time.Second},
+		{Base: 2, Min: 0, Max: time.Second},
+		{Base: 2, Min: time.Second, Max: time.Millisecond},
+	} {
+		if _, err := c.NewHeatmap("latency", b); err != ErrInvalidHeatmap {
+			t.Fatalf("%+v err: %v <=> want: %v", b, err, ErrInvalidHeatmap)
+		}
+	}
+}
+
+func Test_HeatmapObserve(t *testing.T) {
+	c, _ := newTestClient(t, "")
+
+	h, err := c.NewHeatmap("latency", HeatmapBuckets{Base: 10, Min: 100 * time.Microsecond, Max: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{50 * time.Microsecond, "latency.bucket.100us"},
+		{100 * time.Microsecond, "latency.bucket.100us"},
+		{3 * time.Millisecond, "latency.bucket.10ms"},
+		{time.Second, "latency.bucket.1s"},
+		{time.Minute, "latency.bucket.inf"},
+	}
+
+	for _, tt := range tests {
+		h.Observe(tt.d)
+
+		c.m.Lock()
+		last := c.buffer[len(c.buffer)-1].name
+		c.m.Unlock()
+		if last != tt.want {
+			t.Fatalf("[%v] bucket: %s <=> want: %s", tt.d, last, tt.want)
+		}
+	}
+}
+
+func Test_HeatmapNotSampled(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithSampleRate(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	h, err := c.NewHeatmap("latency", DefaultHeatmapBuckets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		h.Observe(time.Millisecond)
+	}
+
+	// the buffer is flushed at @1, so every sample must be counted
+	c.m.Lock()
+	defer c.m.Unlock()
+	if len(c.buffer) != 1 || c.buffer[0].count != 100 {
+		t.Fatalf("buffer: %v <=> want: 100 samples", c.buffer)
+	}
+}
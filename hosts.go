@@ -0,0 +1,110 @@
+package statsd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// WithHosts shard the metrics over several servers ("host:port"), the addr
+// given to New is then ignored: each line goes to the host of its bucket on
+// a HashRing, so that a metric always lands on the same aggregator of a
+// cluster behind no load balancer. The hosts are reached with the network,
+// the TLS config and the connections of the client.
+func WithHosts(hosts ...string) Option {
+	return func(c *Client) {
+		c.hosts = append(c.hosts, hosts...)
+	}
+}
+
+// hostSink is the Sink of WithHosts, each host written by a client of its own
+type hostSink struct {
+	ring    *HashRing
+	clients map[string]*Client
+}
+
+// newHostSink connect to the hosts of WithHosts
+func (c *clientConn) newHostSink(ctx context.Context) (*hostSink, error) {
+	opts := c.endpointOptions()
+	s := &hostSink{ring: NewHashRing(c.hosts), clients: make(map[string]*Client, len(c.hosts))}
+	for _, host := range c.hosts {
+		if _, ok := s.clients[host]; ok {
+			continue
+		}
+		client, err := NewContext(ctx, host, opts...)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.clients[host] = client
+	}
+	return s, nil
+}
+
+// endpointOptions return the options of the clients writing to the servers
+// of a client, e.g. the hosts of WithHosts: the options of the wire layer
+func (c *clientConn) endpointOptions() []Option {
+	opts := []Option{WithNetwork(c.network), WithMaxPacketSize(c.maxPacketSize), WithConnections(c.connections)}
+	if c.tlsConfig != nil {
+		opts = append(opts, WithTLS(c.tlsConfig))
+	}
+	if c.logger != nil {
+		opts = append(opts, WithLogger(c.logger))
+	}
+	if c.writeTimeout > 0 {
+		opts = append(opts, WithWriteTimeout(c.writeTimeout))
+	}
+	opts = append(opts, WithConnectTimeout(c.connectTimeout), WithSocketOptions(c.socketOptions))
+	if c.dialer != nil {
+		opts = append(opts, WithDialer(c.dialer))
+	}
+	return opts
+}
+
+// Write split the packet by host, keeping the order of the lines of a host
+func (s *hostSink) Write(packet []byte) error {
+	if len(s.clients) == 1 {
+		for _, client := range s.clients {
+			return client.write(packet)
+		}
+	}
+
+	var hosts []string
+	packets := make(map[string][]byte, len(s.clients))
+	for len(packet) > 0 {
+		line := packet
+		if i := bytes.IndexByte(packet, '\n'); i >= 0 {
+			line, packet = packet[:i], packet[i+1:]
+		} else {
+			packet = nil
+		}
+		bucket := line
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			bucket = line[:i]
+		}
+
+		host := s.ring.Host(string(bucket))
+		p, ok := packets[host]
+		if !ok {
+			hosts = append(hosts, host)
+		}
+		if len(p) > 0 {
+			p = append(p, '\n')
+		}
+		packets[host] = append(p, line...)
+	}
+
+	var errs []error
+	for _, host := range hosts {
+		errs = append(errs, s.clients[host].write(packets[host]))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *hostSink) Close() error {
+	var errs []error
+	for _, client := range s.clients {
+		errs = append(errs, client.Close())
+	}
+	return errors.Join(errs...)
+}
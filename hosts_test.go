@@ -0,0 +1,54 @@
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_WithHosts(t *testing.T) {
+	listeners := make(map[string]net.PacketConn)
+	var hosts []string
+	for i := 0; i < 2; i++ {
+		l, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		listeners[l.LocalAddr().String()] = l
+		hosts = append(hosts, l.LocalAddr().String())
+	}
+
+	c, err := New("", WithPrefix("api"), WithHosts(hosts...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// find buckets of both hosts
+	ring := NewHashRing(hosts)
+	byHost := make(map[string][]string)
+	for i := 0; len(byHost[hosts[0]]) < 2 || len(byHost[hosts[1]]) < 2; i++ {
+		stat := "m" + strconv.Itoa(i)
+		host := ring.Host("api." + stat)
+		byHost[host] = append(byHost[host], stat)
+	}
+
+	// a packet of lines of both hosts is split between them
+	var lines []string
+	for _, host := range hosts {
+		for _, stat := range byHost[host][:2] {
+			lines = append(lines, c.format(stat, 1, "g", 1))
+		}
+	}
+	if err := c.sendLines(lines); err != nil {
+		t.Fatal(err)
+	}
+	for i, host := range hosts {
+		want := strings.Join(lines[2*i:2*i+2], "\n")
+		if got := readPacket(t, listeners[host]); got != want {
+			t.Fatalf("%s got:\n%s\nwant:\n%s", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package statsd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HTTPSink configure the sink of NewHTTPSink
+type HTTPSink struct {
+	URL string // of the endpoint, "http" or "https"
+
+	// Header is added to each request, e.g. an "Authorization" or an API key
+	Header http.Header
+
+	// Client send the requests, one with a 10s timeout if nil
+	Client *http.Client
+
+	// MaxBatchBytes is the largest body posted, 64KiB if 0: a batch is
+	// posted once full, or at most FlushInterval after its first packet
+	MaxBatchBytes int
+
+	// FlushInterval is how long the packets wait for their batch, 1s if 0
+	FlushInterval time.Duration
+
+	// OnError is called with the errors of the requests, if not nil
+	OnError func(err error)
+}
+
+// NewHTTPSink return a Sink, for WithSink, batching the lines and posting
+// them to an HTTP endpoint as a text/plain body of lines, for the
+// environments blocking UDP egress without a local agent. The batches are
+// posted by a goroutine of the sink, a write returns ErrQueueFull while a
+// batch waits for the previous one to be posted. A failed request drops its
+// batch and is passed to OnError. Closing the sink posts the last batch.
+func NewHTTPSink(cfg HTTPSink) (Sink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("statsd: HTTP sink URL %q is not http nor https", cfg.URL)
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.MaxBatchBytes <= 0 {
+		cfg.MaxBatchBytes = 64 << 10
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	s := &httpSink{
+		cfg:      cfg,
+		batches:  make(chan []byte, 1),
+		done:     make(chan struct{}),
+		loopDone: make(chan struct{}),
+		ticker:   time.NewTicker(cfg.FlushInterval),
+	}
+	go s.loop()
+	return s, nil
+}
+
+type httpSink struct {
+	cfg      HTTPSink
+	batches  chan []byte // full batches, for the loop to post
+	done     chan struct{}
+	loopDone chan struct{}
+	ticker   *time.Ticker
+
+	m         sync.Mutex
+	pending   []byte // batch filling up
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (s *httpSink) Write(packet []byte) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(s.pending) > 0 && len(s.pending)+len(packet)+1 > s.cfg.MaxBatchBytes {
+		select {
+		case s.batches <- s.pending:
+			s.pending = nil
+		default:
+			return ErrQueueFull
+		}
+	}
+	s.pending = append(append(s.pending, packet...), '\n')
+	return nil
+}
+
+// take return the pending batch, nil if empty
+func (s *httpSink) take() []byte {
+	s.m.Lock()
+	defer s.m.Unlock()
+	b := s.pending
+	s.pending = nil
+	return b
+}
+
+func (s *httpSink) loop() {
+	defer close(s.loopDone)
+	for {
+		select {
+		case b := <-s.batches:
+			s.handleError(s.post(b))
+		case <-s.ticker.C:
+			if b := s.take(); b != nil {
+				s.handleError(s.post(b))
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *httpSink) handleError(err error) {
+	if err != nil && s.cfg.OnError != nil {
+		s.cfg.OnError(err)
+	}
+}
+
+// post send a batch
+func (s *httpSink) post(batch []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.cfg.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body) // for the connection to be reused
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("statsd: POST %s: %s", s.cfg.URL, resp.Status)
+	}
+	return nil
+}
+
+// Close post the batches left, returning the errors of their requests
+func (s *httpSink) Close() error {
+	s.closeOnce.Do(func() {
+		s.ticker.Stop()
+		close(s.done)
+		<-s.loopDone
+
+		var errs []error
+		select {
+		case b := <-s.batches:
+			errs = append(errs, s.post(b))
+		default:
+		}
+		if b := s.take(); b != nil {
+			errs = append(errs, s.post(b))
+		}
+		s.closeErr = errors.Join(errs...)
+	})
+	return s.closeErr
+}
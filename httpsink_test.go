@@ -0,0 +1,87 @@
+package statsd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_NewHTTPSink(t *testing.T) {
+	var (
+		m      sync.Mutex
+		bodies []string
+		auth   []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		m.Lock()
+		bodies = append(bodies, string(body))
+		auth = append(auth, r.Header.Get("Authorization"))
+		m.Unlock()
+		if strings.Contains(string(body), "fail") {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	var errs []error
+	sink, err := NewHTTPSink(HTTPSink{
+		URL:           srv.URL + "/v1/statsd",
+		Header:        http.Header{"Authorization": {"Bearer t0k"}},
+		MaxBatchBytes: 20,
+		FlushInterval: time.Hour,
+		OnError:       func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New("", WithSink(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Timing("db", 1)
+	c.Timing("db", 2)
+	c.Timing("cache", 3) // over the max batch size, the first batch is posted
+	for i := 0; i < 100; i++ {
+		m.Lock()
+		n := len(bodies)
+		m.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"db:1|ms\ndb:2|ms\n", "cache:3|ms\n"}
+	if strings.Join(bodies, "|") != strings.Join(want, "|") {
+		t.Fatalf("bodies: %q <=> want: %q", bodies, want)
+	}
+	if auth[0] != "Bearer t0k" {
+		t.Fatalf("authorization: %q", auth[0])
+	}
+	if len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	// the failed requests are reported
+	sink, err = NewHTTPSink(HTTPSink{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Write([]byte("fail:1|c"))
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err: %v <=> want: 401", err)
+	}
+
+	if _, err := NewHTTPSink(HTTPSink{URL: "udp://127.0.0.1:8125"}); err == nil {
+		t.Fatal("udp URL accepted")
+	}
+}
@@ -0,0 +1,57 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+type deadLetter struct {
+	Line  string    `json:"line"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// DeadLettersHandler serve the dead letters of c as JSON, for an admin endpoint
+func DeadLettersHandler(c *statsd.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		letters := c.DeadLetters()
+		out := make([]deadLetter, 0, len(letters))
+		for _, l := range letters {
+			out = append(out, deadLetter{Line: l.Line, Error: l.Err.Error(), Time: l.Time})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+}
+
+// EnabledHandler serve {"enabled": <bool>} telling if the package level
+// helpers send metrics, and turn them on or off on a POST with the form value
+// enabled=true|false, for an admin endpoint, see statsd.SetEnabled
+func EnabledHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			b, err := strconv.ParseBool(r.FormValue("enabled"))
+			if err != nil {
+				http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+			statsd.SetEnabled(b)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Enabled bool `json:"enabled"`
+		}{statsd.Enabled()})
+	})
+}
@@ -0,0 +1,70 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+func Test_DeadLettersHandler(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := statsd.New(l.LocalAddr().String(), statsd.WithDeadLetters(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	w := httptest.NewRecorder()
+	DeadLettersHandler(c).ServeHTTP(w, httptest.NewRequest("GET", "/debug/statsd/dead-letters", nil))
+
+	var got []deadLetter
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("dead letters: %v <=> want: none", got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content type: %s", ct)
+	}
+}
+
+func Test_EnabledHandler(t *testing.T) {
+	statsd.Setup(&statsd.Config{Host: "127.0.0.1", Port: 8125, Enable: true})
+	defer statsd.Setup(&statsd.Config{Host: "127.0.0.1", Port: 8125})
+
+	tests := []struct {
+		method string
+		target string
+		code   int
+		want   string
+	}{
+		{"GET", "/", http.StatusOK, `{"enabled":true}`},
+		{"POST", "/?enabled=false", http.StatusOK, `{"enabled":false}`},
+		{"GET", "/", http.StatusOK, `{"enabled":false}`},
+		{"POST", "/?enabled=maybe", http.StatusBadRequest, ""},
+		{"POST", "/?enabled=true", http.StatusOK, `{"enabled":true}`},
+		{"DELETE", "/", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		EnabledHandler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s %s: %d <=> want: %d", tt.method, tt.target, w.Code, tt.code)
+		}
+		if got := strings.TrimSpace(w.Body.String()); tt.want != "" && got != tt.want {
+			t.Errorf("%s %s: %s <=> want: %s", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
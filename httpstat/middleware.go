@@ -0,0 +1,198 @@
+// Package httpstat instrument net/http handlers and clients with statsd metrics
+package httpstat
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// buckets of the server metrics, tagged with "route:<route>"
+const (
+	statRequests = "http.server.requests"  // counter
+	statLatency  = "http.server.latency"   // timer
+	statStatus   = "http.server.status."   // counter by status class: 2xx, 4xx, ...
+	statInFlight = "http.server.in_flight" // gauge
+	routeOther   = "other"                 // route of the requests out of a known route
+)
+
+// Middleware instrument next with the default client: for each route it counts
+// the requests and the status classes, times them, and gauges the requests in
+// flight. When next is a *http.ServeMux the route is the pattern matching the
+// request, otherwise it is "other", see Route to name it explicitly.
+func Middleware(next http.Handler) http.Handler {
+	return MiddlewareWith(nil, next)
+}
+
+// MiddlewareWith is Middleware sending the metrics to s, the default client if nil
+func MiddlewareWith(s statsd.Statter, next http.Handler) http.Handler {
+	m := &middleware{next: next, stats: stats{s}}
+	if mux, ok := next.(*http.ServeMux); ok {
+		m.route = func(r *http.Request) string {
+			_, pattern := mux.Handler(r)
+			return pattern
+		}
+	}
+	return m
+}
+
+// Route instrument next like Middleware, under the given route name
+func Route(route string, next http.Handler) http.Handler {
+	return RouteWith(nil, route, next)
+}
+
+// RouteWith is Route sending the metrics to s, the default client if nil
+func RouteWith(s statsd.Statter, route string, next http.Handler) http.Handler {
+	return &middleware{
+		next:  next,
+		route: func(*http.Request) string { return route },
+		stats: stats{s},
+	}
+}
+
+type middleware struct {
+	next  http.Handler
+	route func(r *http.Request) string
+	stats stats
+
+	inFlight sync.Map // route => *int64
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := ""
+	if m.route != nil {
+		route = m.route(r)
+	}
+	if route == "" {
+		route = routeOther
+	}
+	tag := "route:" + sanitize(route)
+
+	v, _ := m.inFlight.LoadOrStore(route, new(int64))
+	inFlight := v.(*int64)
+	m.stats.gauge(statInFlight, atomic.AddInt64(inFlight, 1), tag)
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	defer func() {
+		m.stats.timing(statLatency, time.Since(start), tag)
+		m.stats.incr(statRequests, tag)
+		m.stats.incr(statStatus+statusClass(rec.status), tag)
+		m.stats.gauge(statInFlight, atomic.AddInt64(inFlight, -1), tag)
+	}()
+
+	m.next.ServeHTTP(rec, r)
+}
+
+// stats send the metrics to a Statter, or to statsd.DefaultStatter if it is nil
+type stats struct {
+	s statsd.Statter
+}
+
+func (s stats) statter() statsd.Statter {
+	if s.s == nil {
+		return statsd.DefaultStatter()
+	}
+	return s.s
+}
+
+func (s stats) incr(stat string, tags ...string) {
+	s.statter().Incr(stat, 1, tags...)
+}
+
+func (s stats) timing(stat string, d time.Duration, tags ...string) {
+	s.statter().Timing(stat, int64(d/time.Millisecond), tags...)
+}
+
+func (s stats) gauge(stat string, value int64, tags ...string) {
+	s.statter().Gauge(stat, value, tags...)
+}
+
+// statusRecorder capture the status code written by a handler. It forwards
+// http.Flusher, http.Hijacker and io.ReaderFrom to the writer it wraps, so that
+// the streaming and websocket handlers keep working behind the middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, it does nothing if the writer can't flush
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, it fails with http.ErrNotSupported if the
+// writer can't be hijacked
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := h.Hijack()
+	if err == nil && !r.wroteHeader {
+		// the handler takes over the connection, e.g. to switch to websocket
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// ReadFrom implements io.ReaderFrom, so that the writer can use sendfile
+func (r *statusRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.wroteHeader = true
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(src)
+	}
+	return io.Copy(writerOnly{r.ResponseWriter}, src)
+}
+
+// writerOnly hide the ReadFrom of a writer from io.Copy
+type writerOnly struct {
+	io.Writer
+}
+
+// Unwrap let http.ResponseController reach the optional interfaces of the writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// statusClass return the class of a status code: "2xx", "5xx", ...
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// tagReplacer replace the characters a tag value can't hold on the wire
+var tagReplacer = strings.NewReplacer(" ", "_", "|", "_", ",", "_", "#", "_", "\n", "_")
+
+func sanitize(s string) string {
+	return tagReplacer.Replace(s)
+}
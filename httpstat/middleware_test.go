@@ -0,0 +1,151 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+func Test_statusClass(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{200, "2xx"},
+		{204, "2xx"},
+		{301, "3xx"},
+		{404, "4xx"},
+		{503, "5xx"},
+		{42, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := statusClass(tt.code); got != tt.want {
+			t.Fatalf("[%d] got: %s <=> want: %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.WriteHeader(http.StatusOK) // ignored, the status is already written
+	})
+
+	h := Middleware(mux).(*middleware)
+	if got := h.route(httptest.NewRequest("GET", "/orders/42", nil)); got != "GET /orders/{id}" {
+		t.Fatalf("route: %s", got)
+	}
+
+	rec := &statusRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	h.next.ServeHTTP(rec, httptest.NewRequest("GET", "/orders/42", nil))
+	if rec.status != http.StatusTeapot {
+		t.Fatalf("status: %d <=> want: %d", rec.status, http.StatusTeapot)
+	}
+
+	// serving without a configured client is a no-op
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/orders/42", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("code: %d <=> want: %d", w.Code, http.StatusTeapot)
+	}
+	if got := sanitize("GET /orders/{id}"); got != "GET_/orders/{id}" {
+		t.Fatalf("sanitize: %s", got)
+	}
+}
+
+// recordStatter record the bucket and tags of the metrics
+type recordStatter struct {
+	statsd.Statter
+	lines []string
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.lines = append(r.lines, stat+"|c|"+strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) Timing(stat string, delta int64, tags ...string) error {
+	r.lines = append(r.lines, stat+"|ms|"+strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) Gauge(stat string, value int64, tags ...string) error {
+	r.lines = append(r.lines, stat+"|g|"+strconv.FormatInt(value, 10)+"|"+strings.Join(tags, ","))
+	return nil
+}
+
+func Test_RouteWith(t *testing.T) {
+	r := &recordStatter{}
+	h := RouteWith(r, "orders", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+
+	want := []string{
+		"http.server.in_flight|g|1|route:orders",
+		"http.server.latency|ms|route:orders",
+		"http.server.requests|c|route:orders",
+		"http.server.status.4xx|c|route:orders",
+		"http.server.in_flight|g|0|route:orders",
+	}
+	if strings.Join(r.lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got: %v <=> want: %v", r.lines, want)
+	}
+}
+
+func Test_statusRecorderInterfaces(t *testing.T) {
+	// a streaming handler flushes through the middleware
+	flushed := false
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("http.Flusher hidden by the middleware")
+		}
+		w.Write([]byte("chunk"))
+		f.Flush()
+		flushed = true
+
+		if _, ok := w.(io.ReaderFrom); !ok {
+			t.Fatal("io.ReaderFrom hidden by the middleware")
+		}
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+	if !flushed || !w.Flushed {
+		t.Fatal("response not flushed")
+	}
+
+	// a websocket handler hijacks the connection
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		rw.Flush()
+	})))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status: %d <=> want: %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	// the writers that can't be hijacked report it
+	rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+	if _, _, err := rec.Hijack(); err != http.ErrNotSupported {
+		t.Fatalf("err: %v <=> want: %v", err, http.ErrNotSupported)
+	}
+}
@@ -0,0 +1,47 @@
+package httpstat
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// buckets of the client metrics, tagged with "host:<host>"
+const (
+	statClientLatency = "http.client.latency" // timer
+	statClientErrors  = "http.client.errors"  // counter of the requests without response
+	statClientStatus  = "http.client.status." // counter by status class, also tagged "code:<code>"
+)
+
+// Transport is a http.RoundTripper recording, per host, the latency of the
+// outbound requests, their errors and their status codes
+type Transport struct {
+	// Base is the RoundTripper sending the requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+	// Statter receive the metrics, the default client if nil
+	Statter statsd.Statter
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	tag := "host:" + sanitize(r.URL.Host)
+	start := time.Now()
+	resp, err := base.RoundTrip(r)
+	st := stats{t.Statter}
+	st.timing(statClientLatency, time.Since(start), tag)
+
+	if err != nil {
+		st.incr(statClientErrors, tag)
+		return resp, err
+	}
+
+	st.incr(statClientStatus+statusClass(resp.StatusCode), tag, "code:"+strconv.Itoa(resp.StatusCode))
+	return resp, nil
+}
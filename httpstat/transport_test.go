@@ -0,0 +1,50 @@
+package httpstat
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func Test_Transport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status: %d <=> want: %d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	r := &recordStatter{}
+	client.Transport = &Transport{Statter: r}
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(r.lines) != 2 || r.lines[1] != "http.client.status.2xx|c|host:"+srv.Listener.Addr().String()+",code:202" {
+		t.Fatalf("lines: %v", r.lines)
+	}
+
+	errDown := errors.New("down")
+	client.Transport = &Transport{Base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errDown
+	})}
+	if _, err := client.Get(srv.URL); !errors.Is(err, errDown) {
+		t.Fatalf("err: %v <=> want: %v", err, errDown)
+	}
+}
@@ -0,0 +1,227 @@
+package statsd
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// instrument is the metric name and tags of a Counter, a Gauge or a Timer,
+// with the bucket and the tags as written on the wire built once per key of
+// the name hasher
+type instrument struct {
+	c       *Client
+	stat    string
+	tags    []string
+	names   atomic.Pointer[wireNames]
+	invalid error // of the name rules, returned by the calls
+	denied  bool  // by WithAllow or WithDeny, the calls send nothing
+}
+
+// wireNames is the bucket and the tags of an instrument as written on the wire
+type wireNames struct {
+	bucket string  // prefixed and hashed
+	joined string  // hashed call tags, joined
+	key    *[]byte // of the name hasher they were hashed with, nil without
+}
+
+func (i *instrument) init(c *Client, stat string, tags []string) {
+	i.c, i.tags = c, append([]string(nil), tags...)
+	i.stat, i.invalid = c.checkName(stat)
+	i.denied = !c.passes(i.stat)
+	i.names.Store(i.hash())
+}
+
+// hash build the wire names with the current key of the name hasher
+func (i *instrument) hash() *wireNames {
+	n := &wireNames{}
+	if i.c.hasher != nil {
+		n.key = i.c.hasher.key.Load() // before hashing, a rotation meanwhile is seen by the next call
+	}
+	bucket, hashed := i.c.hashName(i.stat, i.tags)
+	n.bucket, n.joined = i.c.bucket(bucket), joinTags(hashed)
+	return n
+}
+
+// wire return the wire names, hashed again once NameHasher.Rotate is called
+func (i *instrument) wire() *wireNames {
+	n := i.names.Load()
+	if i.c.hasher != nil && n.key != i.c.hasher.key.Load() {
+		n = i.hash()
+		i.names.Store(n)
+	}
+	return n
+}
+
+// direct tell if a sample can be written with the prebuilt bucket, the
+// other cases take the path of the Client methods
+func (i *instrument) direct() bool {
+	return i.c.rt == nil && i.c.asOf.IsZero()
+}
+
+// sample tell if a sample is kept, once sampled and within the budget
+func (i *instrument) sample(sampleRate float32) (bool, error) {
+	if i.invalid != nil || i.denied {
+		return false, i.invalid
+	}
+	if !i.c.fire(i.stat, sampleRate) {
+		return false, nil
+	}
+	if !i.c.allow(i.stat, i.tags) {
+		return false, ErrBudgetExceeded
+	}
+	return true, nil
+}
+
+// Counter is a counter of a fixed name and tags, see Client.NewCounter
+type Counter struct {
+	instrument
+	shards []counterShard // unsampled counts since the last flush
+}
+
+// counterShard is a part of the count of a Counter, on its own cache line
+// so that the cores incrementing different shards don't contend
+type counterShard struct {
+	n atomic.Int64
+	_ [56]byte
+}
+
+// NewCounter return a counter of stat with tags, registered once for the hot
+// paths: it is safe for concurrent use and cheaper than Incr. The unsampled
+// counts add to atomic shards without locking, summed into the buffer by each
+// flush, the counter stays registered for the life of the client.
+func (c *Client) NewCounter(stat string, tags ...string) *Counter {
+	k := &Counter{shards: make([]counterShard, runtime.GOMAXPROCS(0))}
+	k.init(c, stat, tags)
+	c.m.Lock()
+	c.counters = append(c.counters, k)
+	c.m.Unlock()
+	return k
+}
+
+// drainCounters add the counts of the counters of NewCounter to the buffer,
+// c.m must be held
+func (c *clientConn) drainCounters() {
+	for _, k := range c.counters {
+		k.drain()
+	}
+}
+
+// drain add the counts of the shards to the buffer, c.m must be held
+func (k *Counter) drain() {
+	var n int64
+	for i := range k.shards {
+		n += k.shards[i].n.Swap(0)
+	}
+	if n != 0 {
+		names := k.wire()
+		k.c.addCount(names.bucket, names.joined, float64(n), 1)
+	}
+}
+
+// Inc increment the counter by one
+func (k *Counter) Inc() error {
+	return k.Add(1)
+}
+
+// Add increment the counter by n
+func (k *Counter) Add(n int64) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Counter.Add", k.stat, n)
+		}
+	}()
+	if !k.direct() {
+		return k.c.Incr(k.stat, n, k.tags...)
+	}
+
+	if err := checkCount(n); err != nil {
+		return err
+	}
+	rate := k.c.rate(k.stat)
+	if ok, err := k.sample(rate); !ok {
+		return err
+	}
+	if rate < 1 {
+		names := k.wire()
+		return k.c.addBuffered(names.bucket, names.joined, float64(n), rate)
+	}
+
+	k.shards[rand.Uint32()%uint32(len(k.shards))].n.Add(n)
+	if k.c.closed.Load() {
+		// closed meanwhile, the last flush may have drained the shards
+		// before the add
+		return ErrClosed
+	}
+	return nil
+}
+
+// GaugeInstrument is a gauge of a fixed name and tags, see Client.NewGauge.
+// It can't be named Gauge which is already the package helper.
+type GaugeInstrument struct {
+	instrument
+}
+
+// NewGauge return a gauge of stat with tags, registered once for the hot paths
+func (c *Client) NewGauge(stat string, tags ...string) *GaugeInstrument {
+	g := &GaugeInstrument{}
+	g.init(c, stat, tags)
+	return g
+}
+
+// Set the value of the gauge
+func (g *GaugeInstrument) Set(value float64) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Gauge.Set", g.stat, value)
+		}
+	}()
+	if !g.direct() || g.c.aggregate || (value < 0 && !g.c.allowNegative) {
+		return g.c.FGauge(g.stat, value, g.tags...)
+	}
+
+	rate := g.c.rate(g.stat)
+	if ok, err := g.sample(rate); !ok {
+		return err
+	}
+	names := g.wire()
+	return g.c.sendLine(g.c.formatLine(names.bucket, value, "g", rate, names.joined))
+}
+
+// Timer is a timer of a fixed name and tags, see Client.NewTimer
+type Timer struct {
+	instrument
+}
+
+// NewTimer return a timer of stat with tags, registered once for the hot
+// paths. The durations are converted as by TimingDuration.
+func (c *Client) NewTimer(stat string, tags ...string) *Timer {
+	t := &Timer{}
+	t.init(c, stat, tags)
+	return t
+}
+
+// Observe track a duration
+func (t *Timer) Observe(d time.Duration) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "Timer.Observe", t.stat, d)
+		}
+	}()
+	if !t.direct() || t.c.aggregate {
+		return t.c.TimingDuration(t.stat, d, t.tags...)
+	}
+
+	rate := t.c.rate(t.stat)
+	if ok, err := t.sample(rate); !ok {
+		return err
+	}
+	names := t.wire()
+	return t.c.sendLine(t.c.formatLine(names.bucket, t.c.durationValue(d), "ms", rate, names.joined))
+}
+
+// Since track the time elapsed since start
+func (t *Timer) Since(start time.Time) error {
+	return t.Observe(time.Since(start))
+}
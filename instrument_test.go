@@ -0,0 +1,124 @@
+package statsd
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Instruments(t *testing.T) {
+	c, l := newTestClient(t, "api")
+
+	requests := c.NewCounter("requests", "route:/")
+	requests.Inc()
+	requests.Add(2)
+	c.Incr("requests", 1, "route:/") // joins the same buffered counter
+	c.flush()
+	if got := readPacket(t, l); got != "api.requests:4|c|#route:/" {
+		t.Fatalf("got: %s <=> want: api.requests:4|c|#route:/", got)
+	}
+	if err := requests.Add(0); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidCount)
+	}
+
+	tests := []struct {
+		name string
+		send func()
+		want string
+	}{
+		{"gauge", func() { c.NewGauge("queue", "q:a").Set(2.5) }, "api.queue:2.5|g|#q:a"},
+		{"negative gauge", func() { c.NewGauge("queue").Set(-1) }, "api.queue:0|g\napi.queue:-1|g"},
+		{"timer", func() { c.NewTimer("db", "op:select").Observe(12 * time.Millisecond) }, "api.db:12|ms|#op:select"},
+		{"child", func() { c.WithPrefix("v2").NewTimer("db").Observe(time.Millisecond) }, "api.v2.db:1|ms"},
+	}
+
+	for _, tt := range tests {
+		tt.send()
+		if got := readPacket(t, l); got != tt.want {
+			t.Fatalf("[%s] got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_InstrumentsAggregated(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithAggregation(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.NewGauge("queue").Set(1)
+	c.NewGauge("queue").Set(3)
+	c.flush()
+	if got := readPacket(t, l); got != "queue:3|g" {
+		t.Fatalf("got: %s <=> want: queue:3|g", got)
+	}
+}
+
+func Benchmark_CounterInc(b *testing.B) {
+	c, err := New("127.0.0.1:8125", WithPrefix("api"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	b.Run("Incr", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.Incr("requests", 1, "route:/", "method:GET")
+		}
+	})
+	b.Run("Counter", func(b *testing.B) {
+		requests := c.NewCounter("requests", "route:/", "method:GET")
+		for i := 0; i < b.N; i++ {
+			requests.Inc()
+		}
+	})
+	b.Run("IncrParallel", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Incr("requests", 1, "route:/", "method:GET")
+			}
+		})
+	})
+	b.Run("CounterParallel", func(b *testing.B) {
+		requests := c.NewCounter("requests", "route:/", "method:GET")
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				requests.Inc()
+			}
+		})
+	})
+}
+
+func Test_CounterConcurrent(t *testing.T) {
+	c, l := newTestClient(t, "")
+
+	requests := c.NewCounter("requests")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				requests.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Snapshot().Counters; len(got) != 1 || got[0].Value != 8000 {
+		t.Fatalf("snapshot: %v <=> want: 8000 requests", got)
+	}
+
+	requests.Add(2)
+	c.flush()
+	if got := readPacket(t, l); got != "requests:8002|c" {
+		t.Fatalf("got: %s <=> want: requests:8002|c", got)
+	}
+
+	c.Close()
+	if err := requests.Inc(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrClosed)
+	}
+}
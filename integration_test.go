@@ -0,0 +1,222 @@
+//go:build integration
+
+// The conformance suite runs the client against real statsd implementations
+// started with docker, it needs a docker daemon and network access:
+//
+//	go test -tags integration -run Test_Conformance ./...
+package statsd
+
+import (
+	"bufio"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dockerRun start a detached container and return its id,
+// the container is removed at the end of the test
+func dockerRun(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("docker", append([]string{"run", "-d", "--rm"}, args...)...).Output()
+	if err != nil {
+		t.Skipf("docker run %v: %v", args, err)
+	}
+	id := strings.TrimSpace(string(out))
+
+	t.Cleanup(func() {
+		exec.Command("docker", "rm", "-f", id).Run()
+	})
+	return id
+}
+
+// dockerPort return the host address published for the container port
+func dockerPort(t *testing.T, id string, port string) string {
+	t.Helper()
+
+	out, err := exec.Command("docker", "port", id, port).Output()
+	if err != nil {
+		t.Fatalf("docker port %s: %v", port, err)
+	}
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+}
+
+// dockerLogs return the output of the container so far
+func dockerLogs(t *testing.T, id string) string {
+	t.Helper()
+
+	out, err := exec.Command("docker", "logs", id).CombinedOutput()
+	if err != nil {
+		t.Fatalf("docker logs: %v", err)
+	}
+	return string(out)
+}
+
+// eventually call check until it returns true or the timeout expires
+func eventually(t *testing.T, timeout time.Duration, check func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if check() {
+			return true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return false
+}
+
+// emitAll send one metric of every type the client supports
+func emitAll(t *testing.T, addr string, opts ...Option) {
+	t.Helper()
+
+	opts = append([]Option{WithPrefix("conformance"), WithFlushInterval(100 * time.Millisecond)}, opts...)
+	c, err := New(addr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the server may still be starting, keep sending until the end of the check
+	for i := 0; i < 10; i++ {
+		c.Incr("counter", 1)
+		c.Decr("decr", 1)
+		c.Gauge("gauge", 42)
+		c.Gauge("negative", -5)
+		c.FGauge("fgauge", 1.5)
+		c.Timing("timer", 12)
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+var conformanceMetrics = []string{
+	"conformance.counter",
+	"conformance.decr",
+	"conformance.gauge",
+	"conformance.negative",
+	"conformance.fgauge",
+	"conformance.timer",
+}
+
+// etsyAdmin run a command of the etsy statsd admin interface
+func etsyAdmin(addr string, cmd string) string {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte(cmd + "\n"))
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if scanner.Text() == "END" {
+			break
+		}
+		b.WriteString(scanner.Text())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func Test_ConformanceEtsy(t *testing.T) {
+	id := dockerRun(t, "-p", "127.0.0.1::8125/udp", "-p", "127.0.0.1::8126", "statsd/statsd")
+	udp := dockerPort(t, id, "8125/udp")
+	admin := dockerPort(t, id, "8126")
+
+	emitAll(t, udp)
+
+	ok := eventually(t, 30*time.Second, func() bool {
+		dump := etsyAdmin(admin, "counters") + etsyAdmin(admin, "gauges") + etsyAdmin(admin, "timers")
+		for _, m := range conformanceMetrics {
+			if !strings.Contains(dump, m) {
+				return false
+			}
+		}
+		return true
+	})
+	if !ok {
+		t.Fatalf("metrics not ingested by etsy statsd, logs:\n%s", dockerLogs(t, id))
+	}
+}
+
+const telegrafConfig = `
+[agent]
+  interval = "1s"
+  flush_interval = "1s"
+[[inputs.statsd]]
+  service_address = ":8125"
+  datadog_extensions = true
+  delete_gauges = false
+  delete_counters = false
+  delete_timings = false
+[[outputs.file]]
+  files = ["stdout"]
+  data_format = "influx"
+`
+
+func Test_ConformanceTelegraf(t *testing.T) {
+	// the config is passed through the environment to avoid a bind mount
+	id := dockerRun(t, "-p", "127.0.0.1::8125/udp", "-e", "CONF="+telegrafConfig, "--entrypoint", "sh", "telegraf",
+		"-c", "printf '%s' \"$CONF\" > /tmp/telegraf.conf && exec telegraf --config /tmp/telegraf.conf",
+	)
+	udp := dockerPort(t, id, "8125/udp")
+
+	emitAll(t, udp, WithTags("env:test", "dc:ams1"))
+
+	ok := eventually(t, 30*time.Second, func() bool {
+		logs := dockerLogs(t, id)
+		for _, m := range conformanceMetrics {
+			// telegraf turns the dots into underscores and the tags into influx tags
+			name := strings.ReplaceAll(m, ".", "_")
+			found := false
+			for _, line := range strings.Split(logs, "\n") {
+				if strings.HasPrefix(line, name+",") && strings.Contains(line, "env=test") && strings.Contains(line, "dc=ams1") {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	})
+	if !ok {
+		t.Fatalf("metrics not ingested by telegraf, logs:\n%s", dockerLogs(t, id))
+	}
+}
+
+func Test_ConformanceDatadog(t *testing.T) {
+	id := dockerRun(t, "-p", "127.0.0.1::8125/udp",
+		"-e", "DD_API_KEY=00000000000000000000000000000000",
+		"-e", "DD_DOGSTATSD_NON_LOCAL_TRAFFIC=true",
+		"-e", "DD_DOGSTATSD_METRICS_STATS_ENABLE=true",
+		"-e", "DD_HOSTNAME=conformance",
+		"gcr.io/datadoghq/agent:7",
+	)
+	udp := dockerPort(t, id, "8125/udp")
+
+	emitAll(t, udp, WithTags("env:test", "dc:ams1"))
+
+	ok := eventually(t, 90*time.Second, func() bool {
+		out, err := exec.Command("docker", "exec", id, "agent", "dogstatsd-stats").CombinedOutput()
+		if err != nil {
+			return false
+		}
+		stats := string(out)
+		for _, m := range conformanceMetrics {
+			if !strings.Contains(stats, m) {
+				return false
+			}
+		}
+		return strings.Contains(stats, "env:test") && strings.Contains(stats, "dc:ams1")
+	})
+	if !ok {
+		t.Fatalf("metrics not ingested by the datadog agent, logs:\n%s", dockerLogs(t, id))
+	}
+}
@@ -0,0 +1,23 @@
+package statsd
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger log the life of the connections (connected, lost, reconnected),
+// the failed writes and the dropped metrics to l, through slog levels: Debug
+// for the connections, Warn for the losses. Nothing is logged by default.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// log a message of the client if it has a logger and the level is enabled
+func (c *clientConn) log(level slog.Level, msg string, args ...any) {
+	if c.logger == nil || !c.logger.Enabled(context.Background(), level) {
+		return
+	}
+	c.logger.Log(context.Background(), level, msg, append(args, "network", c.network, "addr", c.addr)...)
+}
@@ -0,0 +1,44 @@
+package statsd
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+)
+
+func Test_WithLogger(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := New(l.LocalAddr().String(), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	failing, err := New("", WithSink(failSink{}), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing.Timing("db", 3)
+	failing.Close()
+
+	logs := buf.String()
+	for _, want := range []string{
+		`msg="statsd: connected" connect_time=`,
+		"network=udp addr=" + l.LocalAddr().String(),
+		`msg="statsd: closed"`,
+		`level=WARN msg="statsd: write failed" bytes=7 err="sink down"`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Fatalf("logs:\n%s\nwant: %s", logs, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+)
+
+// WithMirrors write every packet to the servers ("host:port") too, besides
+// addr or the sink of WithSink, WithHosts or WithFailover, e.g. to both the
+// old and the new backend during a migration. The
+// mirrors are reached with the network, the TLS config and the connections of
+// the client, a failing mirror doesn't stop the writes to the others.
+func WithMirrors(addrs ...string) Option {
+	return func(c *Client) {
+		c.mirrors = append(c.mirrors, addrs...)
+	}
+}
+
+// MultiSink return a Sink writing every packet to each of sinks in turn, the
+// errors are joined. Closing it closes them all.
+func MultiSink(sinks ...Sink) Sink {
+	return multiSink(append([]Sink(nil), sinks...))
+}
+
+type multiSink []Sink
+
+func (s multiSink) Write(packet []byte) error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.Write(packet))
+	}
+	return errors.Join(errs...)
+}
+
+func (s multiSink) Close() error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// clientSink is a Sink writing to the connection of a client
+type clientSink struct {
+	c *Client
+}
+
+func (s clientSink) Write(packet []byte) error { return s.c.write(packet) }
+func (s clientSink) Close() error              { return s.c.Close() }
+
+// newMirrorSink return the Sink of WithMirrors, writing to primary if not nil
+// or else to addr, and to the mirrors
+func (c *clientConn) newMirrorSink(ctx context.Context, primary Sink) (Sink, error) {
+	opts := c.endpointOptions()
+	var mirrors multiSink
+	for _, addr := range c.mirrors {
+		client, err := NewContext(ctx, addr, opts...)
+		if err != nil {
+			mirrors.Close()
+			return nil, err
+		}
+		mirrors = append(mirrors, clientSink{client})
+	}
+
+	if primary == nil {
+		client, err := NewContext(ctx, c.addr, opts...)
+		if err != nil {
+			mirrors.Close()
+			return nil, err
+		}
+		primary = clientSink{client}
+	}
+	return append(multiSink{primary}, mirrors...), nil
+}
@@ -0,0 +1,52 @@
+package statsd
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func Test_WithMirrors(t *testing.T) {
+	var listeners []net.PacketConn
+	for i := 0; i < 3; i++ {
+		l, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		listeners = append(listeners, l)
+	}
+
+	c, err := New(listeners[0].LocalAddr().String(), WithPrefix("api"),
+		WithMirrors(listeners[1].LocalAddr().String(), listeners[2].LocalAddr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Timing("db", 3)
+	for i, l := range listeners {
+		if got := readPacket(t, l); got != "api.db:3|ms" {
+			t.Fatalf("destination %d got: %s <=> want: api.db:3|ms", i, got)
+		}
+	}
+}
+
+func Test_MultiSink(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := New("", WithSink(MultiSink(WriterSink(&buf), failSink{}, WriterSink(&buf))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a failing sink doesn't stop the others
+	if err := c.Timing("db", 3); err == nil {
+		t.Fatal("error of the failing sink not returned")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "db:3|ms\ndb:3|ms\n"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+}
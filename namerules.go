@@ -0,0 +1,80 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrNameTooLong is returned for the buckets over NameRules.MaxLength
+	ErrNameTooLong = errors.New("metric name is too long")
+	// ErrNameChar is returned for the stats with a character not in NameRules.Allowed
+	ErrNameChar = errors.New("metric name has a disallowed character")
+)
+
+// NameRules bound the stats of a client, so that one bad dynamic name can't
+// explode the cardinality of the server, see WithNameRules. The rules are
+// checked on the stats as given to the calls, before WithSanitizer.
+type NameRules struct {
+	// MaxLength is the longest bucket in bytes, the prefix of the client
+	// included, unlimited if 0
+	MaxLength int
+
+	// Truncate cut the buckets over MaxLength instead of rejecting them,
+	// the error handler is then given ErrNameTooLong
+	Truncate bool
+
+	// Allowed tell if a character may appear in a stat, any if nil, e.g.
+	// SafeNameChar
+	Allowed func(r rune) bool
+}
+
+// WithNameRules check the stats of the client with r: the calls of a stat
+// breaking them return ErrNameTooLong or ErrNameChar and send nothing
+func WithNameRules(r NameRules) Option {
+	return func(c *Client) {
+		c.nameRules = r
+	}
+}
+
+// SafeNameChar allow the ASCII letters and digits, '.', '_' and '-', the
+// characters of a bucket all the servers take as they are
+func SafeNameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '.' || r == '_' || r == '-'
+}
+
+// checkName apply the name rules of the client to stat, returning it
+// truncated if over the length and NameRules.Truncate is set
+func (c *Client) checkName(stat string) (string, error) {
+	r := &c.nameRules
+	if r.Allowed != nil {
+		if i := strings.IndexFunc(stat, func(ch rune) bool { return !r.Allowed(ch) }); i >= 0 {
+			ch, _ := utf8.DecodeRuneInString(stat[i:])
+			return stat, fmt.Errorf("%w: %q", ErrNameChar, ch)
+		}
+	}
+	if r.MaxLength == 0 {
+		return stat, nil
+	}
+
+	n := len(stat)
+	if c.prefix != "" {
+		n += len(c.prefix) + 1
+	}
+	if n <= r.MaxLength {
+		return stat, nil
+	}
+	max := len(stat) - (n - r.MaxLength)
+	if !r.Truncate || max <= 0 {
+		return stat, fmt.Errorf("%w: %d bytes, at most %d", ErrNameTooLong, n, r.MaxLength)
+	}
+
+	for max > 0 && !utf8.RuneStart(stat[max]) {
+		max-- // not in the middle of a character
+	}
+	c.handleError(fmt.Errorf("statsd: %s: %w, truncated to %d bytes", c.bucket(stat), ErrNameTooLong, r.MaxLength))
+	return stat[:max], nil
+}
@@ -0,0 +1,74 @@
+package statsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_WithNameRules(t *testing.T) {
+	_, l := newTestClient(t, "")
+	var handled []error
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"),
+		WithNameRules(NameRules{MaxLength: 18, Allowed: SafeNameChar}),
+		WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		name string
+		stat string
+		want error
+	}{
+		{"valid", "search.hits", nil},
+		{"at the limit", "search.hits.ab", nil},
+		{"too long", "search.hits.abc", ErrNameTooLong},
+		{"disallowed", "search hits", ErrNameChar},
+		{"disallowed utf-8", "search.hé", ErrNameChar},
+	}
+	for _, tt := range tests {
+		err := c.Timing(tt.stat, 1)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v <=> want: %v", tt.name, err, tt.want)
+		}
+		if tt.want == nil {
+			readPacket(t, l)
+		}
+	}
+	if err := c.NewCounter("user.12345678901").Inc(); !errors.Is(err, ErrNameTooLong) {
+		t.Fatalf("counter: %v <=> want: %v", err, ErrNameTooLong)
+	}
+	if len(handled) != 0 {
+		t.Fatalf("handled: %v", handled)
+	}
+
+	// truncated, the error handler is told
+	tc, err := New(l.LocalAddr().String(), WithPrefix("api"), WithNameRules(NameRules{MaxLength: 12, Truncate: true}),
+		WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+	if err := tc.Gauge("queue.size.é", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPacket(t, l), "api.queue.si:1|g"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+	if err := tc.Gauge("queue.sé.x", 2); err != nil {
+		t.Fatal(err)
+	}
+	// not cut inside a character
+	if got, want := readPacket(t, l), "api.queue.s:2|g"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+	if len(handled) != 2 || !errors.Is(handled[0], ErrNameTooLong) || !strings.Contains(handled[0].Error(), "api.queue.size.é") {
+		t.Fatalf("handled: %v", handled)
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithNameRules(NameRules{MaxLength: -1})); err != ErrInvalidNameLength {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidNameLength)
+	}
+}
@@ -0,0 +1,65 @@
+package statsd
+
+import (
+	"strings"
+	"time"
+)
+
+// Option configure a Client created by New
+type Option func(c *Client)
+
+// WithPrefix set the prefix prepended to every bucket, usually the project name
+func WithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = strings.TrimRight(prefix, ".")
+	}
+}
+
+// WithFlushInterval set how often the buffered counters are sent, 5s by default
+func WithFlushInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.flushInterval = d
+	}
+}
+
+// WithMaxPacketSize set the largest payload of a packet carrying several lines,
+// 1432 bytes by default
+func WithMaxPacketSize(n int) Option {
+	return func(c *Client) {
+		c.maxPacketSize = n
+	}
+}
+
+// WithTags set tags (e.g. "env:prod") appended to every metric in the DogStatsD format
+func WithTags(tags ...string) Option {
+	return func(c *Client) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// WithSampleRate set the sample rate used by the methods without explicit sampling, 1 by default
+func WithSampleRate(rate float32) Option {
+	return func(c *Client) {
+		c.sampleRate = rate
+	}
+}
+
+// WithTelemetry make the client send, at each flush, metrics about the package
+// itself: statsd.client.conns.<network> gauges the connections open, the
+// counters statsd.client.metrics_sent, .bytes_written, .dropped and
+// .write_errors count the Telemetry of the interval and, on stream networks,
+// statsd.client.conn.* tagged with the endpoint count the reconnections and
+// time the connections, TLS handshakes and disconnections
+func WithTelemetry() Option {
+	return func(c *Client) {
+		c.telemetry = true
+	}
+}
+
+// WithErrorHandler set the function called with the errors of the background
+// work of the client, e.g. a failed flush of the buffered counters
+func WithErrorHandler(fn func(err error)) Option {
+	return func(c *Client) {
+		c.errorHandler = fn
+	}
+}
@@ -0,0 +1,114 @@
+package statsd
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_New(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr error
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "all-options",
+			opts: []Option{
+				WithPrefix("proj."),
+				WithFlushInterval(time.Second),
+				WithMaxPacketSize(512),
+				WithTags("env:prod", "dc:ams1"),
+				WithSampleRate(0.5),
+			},
+		},
+		{
+			name:    "invalid-sample-rate",
+			opts:    []Option{WithSampleRate(2)},
+			wantErr: ErrInvalidSampleRate,
+		},
+		{
+			name:    "invalid-flush-interval",
+			opts:    []Option{WithFlushInterval(0)},
+			wantErr: ErrInvalidFlushInterval,
+		},
+		{
+			name:    "invalid-packet-size",
+			opts:    []Option{WithMaxPacketSize(-1)},
+			wantErr: ErrInvalidPacketSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(l.LocalAddr().String(), tt.opts...)
+			if err != tt.wantErr {
+				t.Fatalf("[%s] err: %v <=> want: %v", tt.name, err, tt.wantErr)
+			}
+			if c != nil {
+				c.Close()
+			}
+		})
+	}
+}
+
+func Test_ClientTags(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.LocalAddr().String(), WithPrefix("proj"), WithTags("env:prod", "dc:ams1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Timing("db.query", 12); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "proj.db.query:12|ms|#env:prod,dc:ams1"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+}
+
+func Test_ClientWithPrefix(t *testing.T) {
+	c, l := newTestClient(t, "api")
+
+	checkout := c.WithPrefix("checkout.")
+	if err := checkout.Timing("pay", 3); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "api.checkout.pay:3|ms", readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+
+	// children share the counter buffer of their parent
+	checkout.WithPrefix("card").Incr("declined", 1)
+	c.m.Lock()
+	if len(c.buffer) != 1 || c.buffer[0].name != "api.checkout.card.declined" {
+		t.Fatalf("buffer: %v", c.buffer)
+	}
+	c.m.Unlock()
+
+	// closing a child keeps the shared connection open
+	checkout.Close()
+	if err := c.Timing("alive", 1); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "api.alive:1|ms", readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+}
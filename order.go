@@ -0,0 +1,43 @@
+package statsd
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithSortedFlush sort the lines of each flush by bucket then tags, so that
+// the packets of a flush don't depend on the order of the calls, e.g. for
+// golden-file tests of the emitted batches. The lines of the same metric
+// keep their order and the telemetry stays at the end.
+func WithSortedFlush() Option {
+	return func(c *Client) {
+		c.sortFlush = true
+	}
+}
+
+// sortLines sort the statsd lines by bucket then tags
+func sortLines(lines []string) {
+	sort.SliceStable(lines, func(i, j int) bool {
+		bi, ti := lineKey(lines[i])
+		bj, tj := lineKey(lines[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return ti < tj
+	})
+}
+
+// lineKey return the bucket and the tags of a statsd line
+func lineKey(line string) (bucket string, tags string) {
+	bucket = line
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		bucket = line[:i]
+	}
+	if i := strings.Index(line, "|#"); i >= 0 {
+		tags = line[i+2:]
+		if j := strings.IndexByte(tags, '|'); j >= 0 {
+			tags = tags[:j]
+		}
+	}
+	return bucket, tags
+}
@@ -0,0 +1,58 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WithSortedFlush(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithSortedFlush(), WithAggregation(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Timing("b.latency", 2)
+	c.Incr("b.hits", 1, "route:/b")
+	c.Incr("b.hits", 1, "route:/a")
+	c.Gauge("a.queue", -1)
+	c.Timing("b.latency", 1)
+	c.Incr("a", 1)
+
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a:1|c",
+		"a.queue:0|g",
+		"a.queue:-1|g",
+		"b.hits:1|c|#route:/a",
+		"b.hits:1|c|#route:/b",
+		"b.latency:2|ms",
+		"b.latency:1|ms",
+	}
+	if got := readPacket(t, l); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func Test_lineKey(t *testing.T) {
+	tests := []struct {
+		line   string
+		bucket string
+		tags   string
+	}{
+		{"a.b:1|c", "a.b", ""},
+		{"a:1|c|#env:prod,route:/", "a", "env:prod,route:/"},
+		{"a:1|c|#env:prod|c:abc", "a", "env:prod"},
+	}
+
+	for _, tt := range tests {
+		bucket, tags := lineKey(tt.line)
+		if bucket != tt.bucket || tags != tt.tags {
+			t.Errorf("%s: %q %q <=> want: %q %q", tt.line, bucket, tags, tt.bucket, tt.tags)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package otelstats provide an OpenTelemetry metric exporter writing through a
+// statsd.Statter, so that the services instrumented with the OTel SDK keep
+// the statsd backend
+package otelstats
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// Exporter is a metric.Exporter mapping the OTel instruments to statsd lines,
+// with their attributes as "key:value" tags:
+//   - monotonic sums (counters) to counters of their increase,
+//   - non-monotonic sums (up-down counters) and gauges to gauges,
+//   - histograms to .count counters and .sum, .min and .max gauges, in ms for
+//     a duration unit ("s", "ms", "us", "ns"). They are never sampled: a
+//     timer of the mean at @1/count would be dropped by the client sampling.
+//
+// Use it with metric.NewPeriodicReader. Shutdown doesn't close the Statter.
+type Exporter struct {
+	client   statsd.Statter
+	shutdown atomic.Bool
+
+	// the fractional part not sent yet of the float counters, by series key
+	m     sync.Mutex
+	carry map[string]float64
+}
+
+var _ metric.Exporter = (*Exporter)(nil)
+
+// New return an exporter sending to s, or to statsd.DefaultStatter if nil
+func New(s statsd.Statter) *Exporter {
+	if s == nil {
+		s = statsd.DefaultStatter()
+	}
+	return &Exporter{client: s, carry: make(map[string]float64)}
+}
+
+// Temporality is delta for the counters and histograms, sent as increases,
+// and cumulative for the instruments sent as gauges
+func (e *Exporter) Temporality(kind metric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case metric.InstrumentKindCounter, metric.InstrumentKindObservableCounter, metric.InstrumentKindHistogram:
+		return metricdata.DeltaTemporality
+	}
+	return metricdata.CumulativeTemporality
+}
+
+// Aggregation is the default one of the SDK
+func (e *Exporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(kind)
+}
+
+// Export send the metrics
+func (e *Exporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if e.shutdown.Load() {
+		return metric.ErrExporterShutdown
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			e.export(m)
+		}
+	}
+	return nil
+}
+
+// ForceFlush does nothing, the metrics are handed to the Statter on Export
+func (e *Exporter) ForceFlush(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Shutdown make the next exports fail
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.shutdown.Store(true)
+	return ctx.Err()
+}
+
+func (e *Exporter) export(m metricdata.Metrics) {
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		for _, dp := range data.DataPoints {
+			tags := attributeTags(dp.Attributes)
+			if data.IsMonotonic {
+				e.client.Incr(m.Name, dp.Value, tags...)
+			} else {
+				e.client.Gauge(m.Name, dp.Value, tags...)
+			}
+		}
+	case metricdata.Sum[float64]:
+		for _, dp := range data.DataPoints {
+			tags := attributeTags(dp.Attributes)
+			if data.IsMonotonic {
+				e.counter(m.Name, dp.Value, tags)
+			} else {
+				e.client.FGauge(m.Name, dp.Value, tags...)
+			}
+		}
+	case metricdata.Gauge[int64]:
+		for _, dp := range data.DataPoints {
+			e.client.Gauge(m.Name, dp.Value, attributeTags(dp.Attributes)...)
+		}
+	case metricdata.Gauge[float64]:
+		for _, dp := range data.DataPoints {
+			e.client.FGauge(m.Name, dp.Value, attributeTags(dp.Attributes)...)
+		}
+	case metricdata.Histogram[int64]:
+		for _, dp := range data.DataPoints {
+			e.histogram(m.Name, m.Unit, dp.Count, float64(dp.Sum), extrema(dp.Min), extrema(dp.Max), attributeTags(dp.Attributes))
+		}
+	case metricdata.Histogram[float64]:
+		for _, dp := range data.DataPoints {
+			e.histogram(m.Name, m.Unit, dp.Count, dp.Sum, extrema(dp.Min), extrema(dp.Max), attributeTags(dp.Attributes))
+		}
+	case metricdata.ExponentialHistogram[int64]:
+		for _, dp := range data.DataPoints {
+			e.histogram(m.Name, m.Unit, dp.Count, float64(dp.Sum), extrema(dp.Min), extrema(dp.Max), attributeTags(dp.Attributes))
+		}
+	case metricdata.ExponentialHistogram[float64]:
+		for _, dp := range data.DataPoints {
+			e.histogram(m.Name, m.Unit, dp.Count, dp.Sum, extrema(dp.Min), extrema(dp.Max), attributeTags(dp.Attributes))
+		}
+	}
+}
+
+// counter send the whole part of a float increase, the fractional part is
+// carried over to the next export
+func (e *Exporter) counter(name string, value float64, tags []string) {
+	key := name + "|" + strings.Join(tags, ",")
+	value += e.carry[key]
+	whole := math.Trunc(value)
+	e.carry[key] = value - whole
+	if whole > 0 {
+		e.client.Incr(name, int64(whole), tags...)
+	}
+}
+
+func (e *Exporter) histogram(name, unit string, count uint64, sum float64, min, max *float64, tags []string) {
+	if count == 0 {
+		return
+	}
+
+	scale, isDuration := durationUnits[unit]
+	if !isDuration {
+		scale = 1
+	}
+	e.client.IncrWithSampling(name+".count", int64(count), 1, tags...)
+	e.client.FGaugeWithSampling(name+".sum", sum*scale, 1, tags...)
+	if min != nil {
+		e.client.FGaugeWithSampling(name+".min", *min*scale, 1, tags...)
+	}
+	if max != nil {
+		e.client.FGaugeWithSampling(name+".max", *max*scale, 1, tags...)
+	}
+}
+
+// durationUnits give the factor turning a value of a duration unit into ms
+var durationUnits = map[string]float64{
+	"s":  float64(time.Second / time.Millisecond),
+	"ms": 1,
+	"us": 1 / float64(time.Millisecond/time.Microsecond),
+	"ns": 1 / float64(time.Millisecond),
+}
+
+func extrema[N int64 | float64](e metricdata.Extrema[N]) *float64 {
+	v, ok := e.Value()
+	if !ok {
+		return nil
+	}
+	f := float64(v)
+	return &f
+}
+
+// attributeTags turn the attributes into "key:value" tags, sorted by key
+func attributeTags(set attribute.Set) []string {
+	tags := make([]string, 0, set.Len())
+	for iter := set.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		tags = append(tags, tagReplacer.Replace(string(kv.Key))+":"+tagReplacer.Replace(kv.Value.Emit()))
+	}
+	return tags
+}
+
+// tagReplacer replace the characters a tag can't hold on the wire
+var tagReplacer = strings.NewReplacer(" ", "_", "|", "_", ",", "_", "#", "_", "\n", "_")
@@ -0,0 +1,161 @@
+package otelstats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/sunlit-coder/statsd"
+	"github.com/sunlit-coder/statsd/statsdtest"
+)
+
+// recordStatter record the metrics sent by the exporter
+type recordStatter struct {
+	statsd.Statter
+	lines []string
+}
+
+func (r *recordStatter) add(format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.add("%s:%d|c|%s", stat, count, strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) Gauge(stat string, value int64, tags ...string) error {
+	r.add("%s:%d|g|%s", stat, value, strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) FGauge(stat string, value float64, tags ...string) error {
+	r.add("%s:%g|g|%s", stat, value, strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) IncrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error {
+	r.add("%s:%d|c|@%g|%s", stat, count, sampleRate, strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) FGaugeWithSampling(stat string, value float64, sampleRate float32, tags ...string) error {
+	r.add("%s:%g|g|@%g|%s", stat, value, sampleRate, strings.Join(tags, ","))
+	return nil
+}
+
+func Test_Exporter(t *testing.T) {
+	ctx := context.Background()
+	r := &recordStatter{}
+	exp := New(r)
+	reader := metric.NewManualReader(metric.WithTemporalitySelector(exp.Temporality))
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+	meter := provider.Meter("test")
+
+	requests, _ := meter.Int64Counter("http.requests")
+	bytes, _ := meter.Float64Counter("http.bytes")
+	inFlight, _ := meter.Int64UpDownCounter("http.in_flight")
+	latency, _ := meter.Float64Histogram("http.latency", otelmetric.WithUnit("s"))
+	size, _ := meter.Int64Histogram("http.size", otelmetric.WithUnit("By"))
+
+	route := otelmetric.WithAttributes(attribute.String("route", "/orders"))
+	requests.Add(ctx, 3, route)
+	bytes.Add(ctx, 1.5)
+	inFlight.Add(ctx, 2)
+	latency.Record(ctx, 0.1, route)
+	latency.Record(ctx, 0.3, route)
+	size.Record(ctx, 100)
+
+	export := func() []string {
+		r.lines = nil
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatal(err)
+		}
+		if err := exp.Export(ctx, &rm); err != nil {
+			t.Fatal(err)
+		}
+		return r.lines
+	}
+
+	want := []string{
+		"http.requests:3|c|route:/orders",
+		"http.bytes:1|c|",
+		"http.in_flight:2|g|",
+		"http.latency.count:2|c|@1|route:/orders",
+		"http.latency.sum:400|g|@1|route:/orders",
+		"http.latency.min:100|g|@1|route:/orders",
+		"http.latency.max:300|g|@1|route:/orders",
+		"http.size.count:1|c|@1|",
+		"http.size.sum:100|g|@1|",
+		"http.size.min:100|g|@1|",
+		"http.size.max:100|g|@1|",
+	}
+	if got := strings.Join(export(), "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// the counters are sent as increases, the fraction is carried over
+	bytes.Add(ctx, 0.5)
+	want = []string{
+		"http.bytes:1|c|",
+		"http.in_flight:2|g|",
+	}
+	if got := strings.Join(export(), "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	exp.Shutdown(ctx)
+	if err := exp.Export(ctx, &metricdata.ResourceMetrics{}); err != metric.ErrExporterShutdown {
+		t.Fatalf("err: %v <=> want: %v", err, metric.ErrExporterShutdown)
+	}
+}
+
+func Test_ExporterClient(t *testing.T) {
+	ctx := context.Background()
+	s, err := statsdtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c, err := statsd.New(s.Addr(), statsd.WithSampleRate(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := New(c)
+	reader := metric.NewManualReader(metric.WithTemporalitySelector(exp.Temporality))
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+	latency, _ := provider.Meter("test").Float64Histogram("db.latency", otelmetric.WithUnit("ms"))
+
+	// every export arrives, whatever the count and the client sampling
+	const exports = 50
+	for i := 0; i < exports; i++ {
+		for j := 0; j < 10; j++ {
+			latency.Record(ctx, 2)
+		}
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatal(err)
+		}
+		if err := exp.Export(ctx, &rm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Close() // flush the counters
+
+	if err := s.Wait(1+3*exports, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	s.AssertIncr(t, "db.latency.count", 10*exports)
+	if got := len(s.Find("db.latency.sum", "g")); got != exports {
+		t.Fatalf("db.latency.sum gauges: %d <=> want: %d", got, exports)
+	}
+}
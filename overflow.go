@@ -0,0 +1,105 @@
+package statsd
+
+import (
+	"fmt"
+	"time"
+)
+
+// OverflowPolicy is what the package level helpers do with a metric once
+// their send queue is full, see Config.OverflowPolicy
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest drop the metric of the call, the default
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest drop the oldest metric queued to make room
+	OverflowDropOldest
+	// OverflowBlock wait up to Config.OverflowTimeout for room, then drop
+	// the metric of the call
+	OverflowBlock
+)
+
+const (
+	defaultQueueSize       = 1024
+	defaultOverflowTimeout = 10 * time.Millisecond
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowBlock:
+		return "block"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+// valid tell if p is one of the policies above
+func (p OverflowPolicy) valid() bool {
+	return p >= OverflowDropNewest && p <= OverflowBlock
+}
+
+// queueSize return the size of the send queue of cfg
+func (cfg *Config) queueSize() int {
+	if cfg == nil || cfg.QueueSize <= 0 {
+		return defaultQueueSize
+	}
+	return cfg.QueueSize
+}
+
+// sendWorkers return the number of goroutines draining the send queue of cfg
+func (cfg *Config) sendWorkers() int {
+	if cfg == nil || cfg.SendWorkers <= 0 {
+		return 1
+	}
+	return cfg.SendWorkers
+}
+
+// overflow return the overflow policy of cfg and its timeout
+func (cfg *Config) overflow() (OverflowPolicy, time.Duration) {
+	if cfg == nil {
+		return OverflowDropNewest, 0
+	}
+	if cfg.OverflowTimeout <= 0 {
+		return cfg.OverflowPolicy, defaultOverflowTimeout
+	}
+	return cfg.OverflowPolicy, cfg.OverflowTimeout
+}
+
+// pushItem queue item in ch, applying the policy once ch is full, and pass
+// the metrics dropped to drop: item or, with OverflowDropOldest, the oldest
+// ones queued
+func pushItem(ch chan *sendItem, item *sendItem, policy OverflowPolicy, timeout time.Duration, drop func(item *sendItem)) {
+	select {
+	case ch <- item:
+		return
+	default:
+	}
+
+	switch policy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case oldest := <-ch:
+				drop(oldest)
+			default: // drained meanwhile
+			}
+			select {
+			case ch <- item:
+				return
+			default: // refilled by the other callers meanwhile
+			}
+		}
+	case OverflowBlock:
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		select {
+		case ch <- item:
+			return
+		case <-t.C:
+		}
+	}
+	drop(item)
+}
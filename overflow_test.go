@@ -0,0 +1,56 @@
+package statsd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_pushItem(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      OverflowPolicy
+		wantQueued  []string
+		wantDropped []string
+	}{
+		{"drop newest", OverflowDropNewest, []string{"a", "b"}, []string{"c"}},
+		{"drop oldest", OverflowDropOldest, []string{"b", "c"}, []string{"a"}},
+		{"block", OverflowBlock, []string{"a", "b"}, []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan *sendItem, 2)
+			var dropped []string
+			drop := func(item *sendItem) { dropped = append(dropped, item.stat) }
+			for _, stat := range []string{"a", "b", "c"} {
+				pushItem(ch, &sendItem{stat: stat}, tt.policy, time.Millisecond, drop)
+			}
+			close(ch)
+
+			var queued []string
+			for item := range ch {
+				queued = append(queued, item.stat)
+			}
+			if !reflect.DeepEqual(queued, tt.wantQueued) || !reflect.DeepEqual(dropped, tt.wantDropped) {
+				t.Fatalf("queued: %v, dropped: %v <=> want: %v, %v", queued, dropped, tt.wantQueued, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func Test_pushItemBlock(t *testing.T) {
+	ch := make(chan *sendItem, 1)
+	ch <- &sendItem{stat: "a"}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-ch // room before the timeout
+	}()
+
+	pushItem(ch, &sendItem{stat: "b"}, OverflowBlock, time.Second, func(item *sendItem) {
+		t.Errorf("dropped: %s", item.stat)
+	})
+	if item := <-ch; item.stat != "b" {
+		t.Fatalf("queued: %s <=> want: b", item.stat)
+	}
+}
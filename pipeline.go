@@ -0,0 +1,101 @@
+package statsd
+
+import "time"
+
+// Pipeline queue metrics to send them together, see Client.Pipeline. It is
+// not safe for concurrent use.
+type Pipeline struct {
+	c     *Client
+	lines []string
+}
+
+// Pipeline return a pipeline of metrics for c: the metrics queued are sent
+// by Send, packed into as few packets as the max packet size allows, e.g. a
+// consistent snapshot of related metrics. They are neither sampled nor
+// buffered, the counters included, but they are checked, filtered and
+// budgeted as the metrics of c.
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Incr queue a counter increment
+func (p *Pipeline) Incr(stat string, count int64, tags ...string) error {
+	if err := checkCount(count); err != nil {
+		return newSendError(err, "Pipeline.Incr", stat, count)
+	}
+	return p.add("Pipeline.Incr", stat, count, "c", false, tags)
+}
+
+// FIncr queue a floating point counter increment
+func (p *Pipeline) FIncr(stat string, count float64, tags ...string) error {
+	if err := checkFCount(count); err != nil {
+		return newSendError(err, "Pipeline.FIncr", stat, count)
+	}
+	return p.add("Pipeline.FIncr", stat, count, "c", false, tags)
+}
+
+// Decr queue a counter decrement
+func (p *Pipeline) Decr(stat string, count int64, tags ...string) error {
+	if err := checkCount(count); err != nil {
+		return newSendError(err, "Pipeline.Decr", stat, count)
+	}
+	return p.add("Pipeline.Decr", stat, -count, "c", false, tags)
+}
+
+// Timing queue a timing, in milliseconds
+func (p *Pipeline) Timing(stat string, delta int64, tags ...string) error {
+	return p.add("Pipeline.Timing", stat, delta, "ms", false, tags)
+}
+
+// TimingDuration queue a timing as TimingDuration does
+func (p *Pipeline) TimingDuration(stat string, d time.Duration, tags ...string) error {
+	return p.add("Pipeline.TimingDuration", stat, p.c.durationValue(d), "ms", false, tags)
+}
+
+// Gauge queue a gauge value
+func (p *Pipeline) Gauge(stat string, value int64, tags ...string) error {
+	return p.add("Pipeline.Gauge", stat, value, "g", value < 0, tags)
+}
+
+// FGauge queue a floating point gauge value
+func (p *Pipeline) FGauge(stat string, value float64, tags ...string) error {
+	return p.add("Pipeline.FGauge", stat, value, "g", value < 0, tags)
+}
+
+// Len return the number of metrics queued
+func (p *Pipeline) Len() int {
+	return len(p.lines)
+}
+
+// Send write the metrics queued, the pipeline is then empty and may be reused
+func (p *Pipeline) Send() error {
+	lines := p.lines
+	p.lines = nil
+	if len(lines) == 0 {
+		return nil
+	}
+	return p.c.sendLines(lines)
+}
+
+// add queue the line of a metric, negative tell a gauge must be set to
+// zero first
+func (p *Pipeline) add(op, stat string, value interface{}, t string, negative bool, tags []string) (err error) {
+	c := p.c
+	defer func() {
+		if err != nil {
+			err = newSendError(err, op, stat, value)
+		}
+	}()
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
+
+	line := c.format(stat, value, t, 1, tags...)
+	if negative && !c.allowNegative {
+		// a single line for sendLines, so that both are in the same packet
+		line = c.format(stat, 0, "g", 1, tags...) + "\n" + line
+	}
+	p.lines = append(p.lines, line)
+	return nil
+}
@@ -0,0 +1,67 @@
+package statsd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Pipeline(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("pool"), WithSampleRate(0.01), WithMaxPacketSize(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	p := c.Pipeline()
+	for _, err := range []error{
+		p.Gauge("used", 3, "pool:db"),
+		p.Gauge("idle", -1, "pool:db"),
+		p.FGauge("ratio", 0.75, "pool:db"),
+		p.Incr("checkouts", 2, "pool:db"),
+		p.Decr("waiters", 1),
+		p.Timing("wait", 12),
+		p.TimingDuration("hold", 3*time.Millisecond),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Incr("checkouts", 0); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidCount)
+	}
+	if p.Len() != 7 {
+		t.Fatalf("len: %d <=> want: 7", p.Len())
+	}
+	if err := p.Send(); err != nil {
+		t.Fatal(err)
+	}
+
+	// unsampled, packed up to the max packet size, nothing buffered
+	want := []string{
+		"pool.used:3|g|#pool:db\npool.idle:0|g|#pool:db\npool.idle:-1|g|#pool:db",
+		"pool.ratio:0.75|g|#pool:db\npool.checkouts:2|c|#pool:db\npool.waiters:-1|c",
+		"pool.wait:12|ms\npool.hold:3|ms",
+	}
+	for _, w := range want {
+		if got := readPacket(t, l); got != w {
+			t.Fatalf("packet:\n%s\nwant:\n%s", got, w)
+		}
+	}
+	if p.Len() != 0 {
+		t.Fatalf("len after send: %d", p.Len())
+	}
+	if err := p.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.buffer) != 0 {
+		t.Fatalf("buffered: %v", c.buffer)
+	}
+
+	c.Close()
+	p.Gauge("used", 1)
+	if err := p.Send(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrClosed)
+	}
+}
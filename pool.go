@@ -0,0 +1,106 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// WithConnections open n connections to the server instead of one, for the
+// packets to be written in parallel on the ones idle, e.g. on a TCP or high
+// latency link. A packet is written whole on one connection, so the lines of
+// the concurrent writes never interleave. It is ignored with WithSink.
+func WithConnections(n int) Option {
+	return func(c *Client) {
+		c.connections = n
+	}
+}
+
+// spareConn is a connection of WithConnections besides the first one, nil
+// once lost on a stream network until redialed
+type spareConn struct {
+	conn    net.Conn
+	lastTry time.Time // of the last dial, at most once per reconnectInterval
+}
+
+// dialSpares open the spare connections of WithConnections
+func (c *clientConn) dialSpares(ctx context.Context) error {
+	if c.connections <= 1 {
+		return nil
+	}
+
+	c.spares = make(chan *spareConn, c.connections-1)
+	for i := 1; i < c.connections; i++ {
+		c.connMu.Lock() // dial records the connection statistics
+		conn, err := c.dial(ctx)
+		c.connMu.Unlock()
+		if err != nil {
+			c.closeSpares()
+			return err
+		}
+		c.spares <- &spareConn{conn: conn, lastTry: time.Now()}
+	}
+	return nil
+}
+
+// writeSpare write a packet on an idle spare connection, ok is false when
+// they are all busy
+func (c *clientConn) writeSpare(packet []byte) (ok bool, err error) {
+	var s *spareConn
+	select {
+	case s = <-c.spares:
+	default:
+		return false, nil
+	}
+	defer func() { c.spares <- s }()
+
+	if s.conn == nil {
+		if time.Since(s.lastTry) < reconnectInterval {
+			return true, ErrNotConnected
+		}
+		s.lastTry = time.Now()
+		c.connMu.Lock()
+		conn, err := c.dial(context.Background())
+		c.connMu.Unlock()
+		if err != nil {
+			c.addDeadLetter(string(packet), err)
+			return true, err
+		}
+		s.conn = conn
+	}
+
+	if isStream(c.network) {
+		packet = append(packet, '\n')
+	}
+	c.setWriteDeadline(s.conn)
+	if _, err := s.conn.Write(packet); err != nil {
+		if isStream(c.network) {
+			// part of the packet may have been written, as in writeStream
+			s.conn.Close()
+			trackConn(c.network, -1)
+			s.conn = nil
+		}
+		c.addDeadLetter(string(packet), err)
+		return true, err
+	}
+	return true, nil
+}
+
+// closeSpares close the spare connections, none must be in use
+func (c *clientConn) closeSpares() error {
+	var err error
+	for {
+		select {
+		case s := <-c.spares:
+			if s.conn == nil {
+				continue
+			}
+			if cerr := s.conn.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+			trackConn(c.network, -1)
+		default:
+			return err
+		}
+	}
+}
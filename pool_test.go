@@ -0,0 +1,86 @@
+package statsd
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_WithConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	before := OpenConns()["tcp"]
+	c, err := New(l.Addr().String(), WithNetwork("tcp"), WithConnections(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := OpenConns()["tcp"]; got != before+3 {
+		t.Fatalf("open tcp conns: %d <=> want: %d", got, before+3)
+	}
+
+	// every line read on any connection is whole
+	line := regexp.MustCompile(`^w\.\d+:\d+\|ms$`)
+	lines := make(chan string, 1000)
+	var readers sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := c.WithPrefix("w")
+			for j := 0; j < 100; j++ {
+				if err := w.Timing("0", int64(j)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := OpenConns()["tcp"]; got != before {
+		t.Fatalf("open tcp conns after close: %d <=> want: %d", got, before)
+	}
+	readers.Wait()
+	close(lines)
+
+	n := 0
+	for got := range lines {
+		if !line.MatchString(got) {
+			t.Fatalf("line: %q", got)
+		}
+		n++
+	}
+	if n != 800 {
+		t.Fatalf("lines: %d <=> want: 800", n)
+	}
+
+	if _, err := New(l.Addr().String(), WithConnections(-1)); err != ErrInvalidConnections {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidConnections)
+	}
+}
@@ -0,0 +1,218 @@
+// Package promstats forward the metrics of a prometheus.Gatherer through a
+// statsd.Statter, so that the libraries instrumented for Prometheus share the
+// statsd export path
+package promstats
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// ErrInvalidInterval is returned by Start for an interval <= 0
+var ErrInvalidInterval = errors.New("promstats: interval is less than or equal to 0")
+
+// Bridge periodically gather the Prometheus metrics and send them with their
+// labels as tags:
+//   - counters as statsd counters of their increase since the last gathering,
+//   - gauges and untyped metrics as gauges,
+//   - histograms as <name>.count and <name>.bucket counters of the increase,
+//     the buckets tagged "le:<bound>", and a <name>.sum gauge of the increase,
+//   - summaries as <name>.count, <name>.sum and quantile gauges tagged
+//     "quantile:<q>".
+type Bridge struct {
+	gatherer prometheus.Gatherer
+	client   statsd.Statter
+
+	ticker   *time.Ticker
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// the values already sent of the cumulative series, by series key, and
+	// the keys of the current gathering
+	m        sync.Mutex
+	sent     map[string]float64
+	gathered map[string]bool
+}
+
+// Start forwarding the metrics of g to s every interval. The first gathering
+// is done now and only sets the baseline of the counters, its error is returned.
+// A nil s is statsd.DefaultStatter.
+func Start(g prometheus.Gatherer, s statsd.Statter, interval time.Duration) (*Bridge, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if s == nil {
+		s = statsd.DefaultStatter()
+	}
+
+	b := &Bridge{
+		gatherer: g,
+		client:   s,
+		done:     make(chan struct{}),
+		sent:     make(map[string]float64),
+	}
+	if err := b.gather(false); err != nil {
+		return nil, err
+	}
+
+	b.ticker = time.NewTicker(interval)
+	go b.loop()
+	return b, nil
+}
+
+// Stop forwarding
+func (b *Bridge) Stop() {
+	b.stopOnce.Do(func() {
+		b.ticker.Stop()
+		close(b.done)
+	})
+}
+
+func (b *Bridge) loop() {
+	for {
+		select {
+		case <-b.ticker.C:
+			b.gather(true)
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// gather read the metrics and send them, or only record the counters when
+// send is false. The metrics gathered are sent even on a partial error. The
+// series gone from a complete gathering are forgotten.
+func (b *Bridge) gather(send bool) error {
+	families, err := b.gatherer.Gather()
+
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.gathered = make(map[string]bool, len(b.sent))
+	for _, f := range families {
+		name := bucketName(f.GetName())
+		for _, m := range f.GetMetric() {
+			b.metric(name, f.GetType(), m, send)
+		}
+	}
+	if err == nil {
+		for key := range b.sent {
+			if !b.gathered[key] {
+				delete(b.sent, key)
+			}
+		}
+	}
+	return err
+}
+
+func (b *Bridge) metric(name string, t dto.MetricType, m *dto.Metric, send bool) {
+	tags := labelTags(m.GetLabel())
+
+	switch t {
+	case dto.MetricType_COUNTER:
+		b.counter(name, tags, m.GetCounter().GetValue(), send)
+	case dto.MetricType_GAUGE:
+		if send {
+			b.client.FGauge(name, m.GetGauge().GetValue(), tags...)
+		}
+	case dto.MetricType_UNTYPED:
+		if send {
+			b.client.FGauge(name, m.GetUntyped().GetValue(), tags...)
+		}
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		b.counter(name+".count", tags, float64(h.GetSampleCount()), send)
+		b.sum(name+".sum", tags, h.GetSampleSum(), send)
+		for _, bucket := range h.GetBucket() {
+			le := "le:" + formatFloat(bucket.GetUpperBound())
+			b.counter(name+".bucket", append(tags[:len(tags):len(tags)], le), float64(bucket.GetCumulativeCount()), send)
+		}
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		b.counter(name+".count", tags, float64(s.GetSampleCount()), send)
+		b.sum(name+".sum", tags, s.GetSampleSum(), send)
+		if send {
+			for _, q := range s.GetQuantile() {
+				b.client.FGauge(name, q.GetValue(), append(tags[:len(tags):len(tags)], "quantile:"+formatFloat(q.GetQuantile()))...)
+			}
+		}
+	}
+}
+
+// counter send the whole increase of a cumulative series since the last
+// gathering, the fractional part is carried over to the next one. After a
+// reset, by a restart of the instrumented code, the increase is the value as
+// for the rate() of Prometheus.
+func (b *Bridge) counter(name string, tags []string, value float64, send bool) {
+	key := seriesKey(name, tags)
+	b.gathered[key] = true
+	prev, seen := b.sent[key]
+	if !seen {
+		b.sent[key] = value
+		return
+	}
+	if value < prev {
+		prev = 0 // reset
+	}
+
+	delta := int64(value - prev)
+	if send && delta > 0 {
+		b.client.Incr(name, delta, tags...)
+	}
+	b.sent[key] = prev + float64(delta)
+}
+
+// sum gauge the increase of a cumulative float series since the last
+// gathering, the value after a reset
+func (b *Bridge) sum(name string, tags []string, value float64, send bool) {
+	key := seriesKey(name, tags)
+	b.gathered[key] = true
+	prev, seen := b.sent[key]
+	b.sent[key] = value
+	if value < prev {
+		prev = 0 // reset
+	}
+	if send && seen {
+		b.client.FGauge(name, value-prev, tags...)
+	}
+}
+
+// seriesKey identify a series by its bucket and tags
+func seriesKey(name string, tags []string) string {
+	return name + "|" + strings.Join(tags, ",")
+}
+
+// labelTags turn the labels into sorted "name:value" tags
+func labelTags(labels []*dto.LabelPair) []string {
+	tags := make([]string, 0, len(labels))
+	for _, l := range labels {
+		tags = append(tags, sanitize(l.GetName())+":"+sanitize(l.GetValue()))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// bucketName turn a Prometheus metric name into a bucket, ':' separates the
+// value on the wire
+func bucketName(name string) string {
+	return strings.ReplaceAll(name, ":", "_")
+}
+
+// tagReplacer replace the characters a tag can't hold on the wire
+var tagReplacer = strings.NewReplacer(" ", "_", "|", "_", ",", "_", "#", "_", "\n", "_", ":", "_")
+
+func sanitize(s string) string {
+	return tagReplacer.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
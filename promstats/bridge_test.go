@@ -0,0 +1,129 @@
+package promstats
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// recordStatter record the metrics sent by the bridge
+type recordStatter struct {
+	statsd.Statter
+	lines []string
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%d|c|%s", stat, count, strings.Join(tags, ",")))
+	return nil
+}
+
+func (r *recordStatter) FGauge(stat string, value float64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%g|g|%s", stat, value, strings.Join(tags, ",")))
+	return nil
+}
+
+func Test_Bridge(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total"}, []string{"code", "method"})
+	queue := prometheus.NewGauge(prometheus.GaugeOpts{Name: "queue:depth"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{0.1, 1}})
+	reg.MustRegister(requests, queue, latency)
+
+	requests.WithLabelValues("200", "GET").Add(3)
+	r := &recordStatter{}
+	b, err := Start(reg, r, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+	if len(r.lines) != 0 {
+		t.Fatalf("baseline gathering sent: %v", r.lines)
+	}
+
+	requests.WithLabelValues("200", "GET").Add(2.5)
+	queue.Set(7)
+	latency.Observe(0.0625)
+	latency.Observe(0.5)
+	if err := b.gather(true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"http_requests_total:2|c|code:200,method:GET",
+		"latency_seconds.count:2|c|",
+		"latency_seconds.sum:0.5625|g|",
+		"latency_seconds.bucket:1|c|le:0.1",
+		"latency_seconds.bucket:2|c|le:1",
+		"queue_depth:7|g|",
+	}
+	if got := strings.Join(r.lines, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// the fractional increase is carried over, the series appeared after the
+	// baseline start from the next gathering
+	r.lines = nil
+	requests.WithLabelValues("200", "GET").Add(0.5)
+	requests.WithLabelValues("500", "GET").Inc()
+	latency.Observe(2)
+	b.gather(true)
+	want = []string{
+		"http_requests_total:1|c|code:200,method:GET",
+		"latency_seconds.count:1|c|",
+		"latency_seconds.sum:2|g|",
+		"queue_depth:7|g|",
+	}
+	if got := strings.Join(r.lines, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if _, err := Start(reg, r, 0); err != ErrInvalidInterval {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+}
+
+func Test_BridgeReset(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	jobs := prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total"})
+	reg.MustRegister(jobs)
+	jobs.Add(10.5)
+
+	r := &recordStatter{}
+	b, err := Start(reg, r, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	// a restart of the instrumented code resets the counter, the value is
+	// the increase since the reset
+	reg.Unregister(jobs)
+	jobs = prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total"})
+	reg.MustRegister(jobs)
+	jobs.Add(3.5)
+	if err := b.gather(true); err != nil {
+		t.Fatal(err)
+	}
+	jobs.Add(0.5)
+	if err := b.gather(true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"jobs_total:3|c|", "jobs_total:1|c|"}
+	if got := strings.Join(r.lines, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// the series gone from a gathering are forgotten
+	reg.Unregister(jobs)
+	if err := b.gather(true); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.sent) != 0 {
+		t.Fatalf("sent: %v <=> want: empty", b.sent)
+	}
+}
@@ -0,0 +1,134 @@
+// Package proto implement the statsd line format shared by the client, the
+// servers and the test helpers: parsing the lines into metrics, and the
+// prefix compression understood on stream networks
+package proto
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformedLine is returned by ParseLine for a line out of the statsd format
+var ErrMalformedLine = errors.New("malformed statsd line")
+
+// ErrInvalidCompressedLine is returned by PrefixDecoder for a malformed reference
+var ErrInvalidCompressedLine = errors.New("invalid prefix compressed line")
+
+// Metric is a parsed statsd line
+type Metric struct {
+	Name       string
+	Type       string // "c", "ms" or "g"
+	Value      float64
+	SampleRate float32
+	Tags       []string
+
+	// the extended fields, zero when the line has none
+	Timestamp   int64  // "|T<unix seconds>"
+	ContainerID string // "|c:<id>"
+	Token       string // "|i:<token>", the idempotency token
+}
+
+// HasTags tell if the metric has every given tag
+func (m Metric) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range m.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// String format the metric as a statsd line
+func (m Metric) String() string {
+	line := m.Name + ":" + strconv.FormatFloat(m.Value, 'f', -1, 64) + "|" + m.Type
+	if len(m.Tags) > 0 {
+		line += "|#" + strings.Join(m.Tags, ",")
+	}
+	if m.Timestamp != 0 {
+		line += "|T" + strconv.FormatInt(m.Timestamp, 10)
+	}
+	if m.ContainerID != "" {
+		line += "|c:" + m.ContainerID
+	}
+	if m.Token != "" {
+		line += "|i:" + m.Token
+	}
+	return line
+}
+
+// ParseLine parse a statsd line
+// "<name>:<value>|<type>[|@<rate>][|#<tags>][<extended fields>]", the
+// extended fields being those written by the client extensions. The unknown
+// sections are ignored.
+func ParseLine(line string) (Metric, error) {
+	sections := strings.Split(line, "|")
+	i := strings.LastIndexByte(sections[0], ':')
+	if len(sections) < 2 || i <= 0 || sections[1] == "" {
+		return Metric{}, ErrMalformedLine
+	}
+
+	value, err := strconv.ParseFloat(sections[0][i+1:], 64)
+	if err != nil {
+		return Metric{}, ErrMalformedLine
+	}
+	m := Metric{Name: sections[0][:i], Type: sections[1], Value: value, SampleRate: 1}
+
+	for _, section := range sections[2:] {
+		switch {
+		case strings.HasPrefix(section, "@"):
+			rate, err := strconv.ParseFloat(section[1:], 32)
+			if err != nil {
+				return Metric{}, ErrMalformedLine
+			}
+			m.SampleRate = float32(rate)
+		case strings.HasPrefix(section, "#"):
+			m.Tags = strings.Split(section[1:], ",")
+		case strings.HasPrefix(section, "T"):
+			ts, err := strconv.ParseInt(section[1:], 10, 64)
+			if err != nil {
+				return Metric{}, ErrMalformedLine
+			}
+			m.Timestamp = ts
+		case strings.HasPrefix(section, "c:"):
+			m.ContainerID = section[2:]
+		case strings.HasPrefix(section, "i:"):
+			m.Token = section[2:]
+		}
+	}
+	return m, nil
+}
+
+// PrefixDecoder expand the lines of a stream written with prefix compression,
+// one decoder per connection. Lines without reference are returned unchanged.
+type PrefixDecoder struct {
+	prev string
+}
+
+// Decode return the expanded line
+func (d *PrefixDecoder) Decode(line string) (string, error) {
+	if !strings.HasPrefix(line, "^") {
+		d.prev = line
+		return line, nil
+	}
+
+	end := strings.IndexByte(line[1:], '^')
+	if end < 0 {
+		return "", ErrInvalidCompressedLine
+	}
+	n, err := strconv.Atoi(line[1 : 1+end])
+	if err != nil || n < 0 || n > len(d.prev) {
+		return "", ErrInvalidCompressedLine
+	}
+
+	line = d.prev[:n] + line[2+end:]
+	d.prev = line
+	return line, nil
+}
@@ -0,0 +1,65 @@
+package proto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Metric
+		err  error
+	}{
+		{"a.b:1|c", Metric{Name: "a.b", Type: "c", Value: 1, SampleRate: 1}, nil},
+		{"a:-1.5|g|@0.500000|#env:prod,route:/", Metric{Name: "a", Type: "g", Value: -1.5, SampleRate: 0.5, Tags: []string{"env:prod", "route:/"}}, nil},
+		{"a:2|ms|@1.000000|c:abc", Metric{Name: "a", Type: "ms", Value: 2, SampleRate: 1, ContainerID: "abc"}, nil},
+		{"a:1|c|#env:prod|T1700000000|i:7-42", Metric{Name: "a", Type: "c", Value: 1, SampleRate: 1, Tags: []string{"env:prod"}, Timestamp: 1700000000, Token: "7-42"}, nil},
+		{"a:1|c|x:unknown", Metric{Name: "a", Type: "c", Value: 1, SampleRate: 1}, nil},
+		{"a", Metric{}, ErrMalformedLine},
+		{"a:x|c", Metric{}, ErrMalformedLine},
+		{":1|c", Metric{}, ErrMalformedLine},
+		{"a:1|c|@x", Metric{}, ErrMalformedLine},
+		{"a:1|c|Tx", Metric{}, ErrMalformedLine},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLine(tt.line)
+		if err != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v (%v) <=> want: %v (%v)", tt.line, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func Test_MetricString(t *testing.T) {
+	m := Metric{Name: "a.b", Type: "g", Value: 1.5, SampleRate: 1, Tags: []string{"env:prod", "shop:a"}}
+	if got := m.String(); got != "a.b:1.5|g|#env:prod,shop:a" {
+		t.Fatalf("got: %s", got)
+	}
+	m.Timestamp, m.ContainerID, m.Token = 1700000000, "abc", "7-42"
+	if got := m.String(); got != "a.b:1.5|g|#env:prod,shop:a|T1700000000|c:abc|i:7-42" {
+		t.Fatalf("got: %s", got)
+	}
+	if !m.HasTags("shop:a") || m.HasTags("shop:b") {
+		t.Fatalf("HasTags: %v", m.Tags)
+	}
+}
+
+func Test_PrefixDecoder(t *testing.T) {
+	var d PrefixDecoder
+	for _, tt := range []struct{ line, want string }{
+		{"api.checkout.pay:1|c", "api.checkout.pay:1|c"},
+		{"^13^cart:2|c", "api.checkout.cart:2|c"},
+		{"^17^:3|c", "api.checkout.cart:3|c"},
+	} {
+		if got, err := d.Decode(tt.line); err != nil || got != tt.want {
+			t.Fatalf("%s: %s (%v) <=> want: %s", tt.line, got, err, tt.want)
+		}
+	}
+
+	for _, line := range []string{"^3", "^x^a", "^99^a"} {
+		if _, err := d.Decode(line); err != ErrInvalidCompressedLine {
+			t.Fatalf("[%s] err: %v <=> want: %v", line, err, ErrInvalidCompressedLine)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package statsd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Protocol is the dialect of the lines, chiefly how the tags are encoded
+type Protocol int
+
+const (
+	// ProtocolDogStatsD append the tags to the line, "name:1|c|#k:v,k2:v2",
+	// the default
+	ProtocolDogStatsD Protocol = iota
+	// ProtocolStatsD write the lines of the original StatsD, without tags
+	ProtocolStatsD
+	// ProtocolInfluxDB put the tags in the name as the statsd input of
+	// Telegraf reads them, "name,k=v,k2=v2:1|c"
+	ProtocolInfluxDB
+	// ProtocolSignalFx put the tags in the name as dimensions, "name[k=v,k2=v2]:1|c"
+	ProtocolSignalFx
+	// ProtocolGraphite write Graphite plaintext, "name;k=v;k2=v2 1 1700000000",
+	// for a carbon server over TCP without a statsd daemon. The lines carry no
+	// type: buffer the timers with WithAggregation for carbon to keep more
+	// than the last value of an interval, the gauge deltas are written as
+	// values.
+	ProtocolGraphite
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolDogStatsD:
+		return "dogstatsd"
+	case ProtocolStatsD:
+		return "statsd"
+	case ProtocolInfluxDB:
+		return "influxdb"
+	case ProtocolSignalFx:
+		return "signalfx"
+	case ProtocolGraphite:
+		return "graphite"
+	}
+	return fmt.Sprintf("Protocol(%d)", int(p))
+}
+
+// valid tell if p is one of the dialects above
+func (p Protocol) valid() bool {
+	return p >= ProtocolDogStatsD && p <= ProtocolGraphite
+}
+
+// WithProtocol write the lines in the dialect p instead of DogStatsD. With
+// InfluxDB, SignalFx and Graphite the tags "key:value" are written
+// "key=value" and the tags without a value are left out, with StatsD all of
+// them are.
+func WithProtocol(p Protocol) Option {
+	return func(c *Client) {
+		c.protocol = p
+	}
+}
+
+// appendNameTags append the client tags, the call tags and the call tags
+// already joined to the name of a line, in the dialects taking them there
+func (c *clientConn) appendNameTags(dst []byte, tags []string, joined string) []byte {
+	var open, sep, end string
+	switch c.protocol {
+	case ProtocolInfluxDB:
+		open, sep = ",", ","
+	case ProtocolSignalFx:
+		open, sep, end = "[", ",", "]"
+	case ProtocolGraphite:
+		open, sep = ";", ";"
+	default:
+		return dst
+	}
+
+	n := 0
+	add := func(tag string) {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok || k == "" {
+			return
+		}
+		if n == 0 {
+			dst = append(dst, open...)
+		} else {
+			dst = append(dst, sep...)
+		}
+		dst = append(dst, k...)
+		dst = append(dst, '=')
+		dst = append(dst, v...)
+		n++
+	}
+	for _, tag := range c.tags {
+		add(tag)
+	}
+	for _, tag := range tags {
+		add(tag)
+	}
+	for joined != "" {
+		var tag string
+		tag, joined, _ = strings.Cut(joined, ",")
+		add(tag)
+	}
+	if n > 0 {
+		dst = append(dst, end...)
+	}
+	return dst
+}
@@ -0,0 +1,47 @@
+package statsd
+
+import "testing"
+
+func Test_WithProtocol(t *testing.T) {
+	tests := []struct {
+		protocol Protocol
+		timing   string // of a call
+		count    string // of a buffered counter
+	}{
+		{ProtocolDogStatsD, "api.db:3|ms|#env:prod,table:users,cached", "api.hits:1|c|#env:prod,route:/"},
+		{ProtocolStatsD, "api.db:3|ms", "api.hits:1|c"},
+		{ProtocolInfluxDB, "api.db,env=prod,table=users:3|ms", "api.hits,env=prod,route=/:1|c"},
+		{ProtocolSignalFx, "api.db[env=prod,table=users]:3|ms", "api.hits[env=prod,route=/]:1|c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.protocol.String(), func(t *testing.T) {
+			_, l := newTestClient(t, "")
+			c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithTags("env:prod"), WithProtocol(tt.protocol))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			if err := c.Timing("db", 3, "table:users", "cached"); err != nil {
+				t.Fatal(err)
+			}
+			if got := readPacket(t, l); got != tt.timing {
+				t.Fatalf("timing: %q <=> want: %q", got, tt.timing)
+			}
+			if err := c.Incr("hits", 1, "route:/"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := readPacket(t, l); got != tt.count {
+				t.Fatalf("count: %q <=> want: %q", got, tt.count)
+			}
+		})
+	}
+
+	if _, err := New("127.0.0.1:8125", WithProtocol(9)); err != ErrInvalidProtocol {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidProtocol)
+	}
+}
@@ -0,0 +1,85 @@
+package statsd
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned for the packets dropped by WithRateLimit
+var ErrRateLimited = errors.New("metrics dropped, rate limit exceeded")
+
+// RateLimit bound what a client writes per second, see WithRateLimit
+type RateLimit struct {
+	Metrics float64 // lines per second, unlimited if 0
+	Bytes   float64 // bytes per second, unlimited if 0
+}
+
+// WithRateLimit put a token bucket of a burst of one second of l ahead of the
+// socket, protecting the network and the aggregator from a runaway
+// instrumentation loop: the packets over the limit are dropped whole, the
+// write returns ErrRateLimited and the metrics are counted in
+// Telemetry.RateLimited, sent as statsd.client.rate_limited by
+// WithTelemetry. A packet larger than the burst passes once the bucket is
+// full, the next ones paying for it.
+func WithRateLimit(l RateLimit) Option {
+	return func(c *Client) {
+		c.rateLimit = l
+	}
+}
+
+// rateLimiter is the token buckets of WithRateLimit
+type rateLimiter struct {
+	m       sync.Mutex
+	metrics tokenBucket
+	bytes   tokenBucket
+}
+
+func newRateLimiter(l RateLimit, now time.Time) *rateLimiter {
+	if l.Metrics == 0 && l.Bytes == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		metrics: tokenBucket{rate: l.Metrics, tokens: l.Metrics, last: now},
+		bytes:   tokenBucket{rate: l.Bytes, tokens: l.Bytes, last: now},
+	}
+}
+
+// take tell if a packet of n metrics and size bytes may be written, and
+// take its tokens if so
+func (l *rateLimiter) take(now time.Time, n, size int) bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.metrics.refill(now)
+	l.bytes.refill(now)
+	if !l.metrics.has(float64(n)) || !l.bytes.has(float64(size)) {
+		return false
+	}
+	l.metrics.tokens -= float64(n)
+	l.bytes.tokens -= float64(size)
+	return true
+}
+
+// tokenBucket is a bucket of rate tokens per second, unlimited if rate is 0,
+// its capacity is the rate
+type tokenBucket struct {
+	rate   float64
+	tokens float64 // negative while paying for an oversized packet
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.rate == 0 {
+		return
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.rate, b.rate)
+	}
+	b.last = now
+}
+
+// has tell if n tokens may be taken, all of them once the bucket is full
+func (b *tokenBucket) has(n float64) bool {
+	return b.rate == 0 || b.tokens >= n || b.tokens >= b.rate
+}
@@ -0,0 +1,64 @@
+package statsd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_WithRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	c, err := New("", WithSink(WriterSink(&buf)), WithClock(clock), WithRateLimit(RateLimit{Metrics: 3, Bytes: 1000}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := c.Timing("db", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the burst is spent
+	if err := c.Timing("db", 1); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrRateLimited)
+	}
+	if err := c.sendLines([]string{"a:1|g", "b:2|g"}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrRateLimited)
+	}
+
+	// refilled with the time
+	clock.Tick(time.Second / 2)
+	if err := c.Timing("db", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Telemetry(); got.MetricsSent != 4 || got.RateLimited != 3 {
+		t.Fatalf("telemetry: %+v", got)
+	}
+	if got := c.statsTelemetryLines(); got[len(got)-1] != "statsd.client.rate_limited:3|c" {
+		t.Fatalf("telemetry lines: %q", got)
+	}
+
+	// a packet over the burst passes once the bucket is full
+	b, err := New("", WithSink(WriterSink(&buf)), WithClock(clock), WithRateLimit(RateLimit{Bytes: 10}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if err := b.Timing("requests.latency", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Timing("db", 1); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrRateLimited)
+	}
+	clock.Tick(2 * time.Second)
+	if err := b.Timing("db", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New("", WithSink(WriterSink(&buf)), WithRateLimit(RateLimit{Metrics: -1})); err != ErrInvalidRateLimit {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidRateLimit)
+	}
+}
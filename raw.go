@@ -0,0 +1,27 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRawLine is returned by WriteRaw for an empty line, a line with a
+// line break or larger than the max packet size
+var ErrInvalidRawLine = errors.New("invalid raw line")
+
+// WriteRaw write line as it is, without the prefix nor the tags of the
+// client, e.g. a metric type or an extension the package doesn't model yet
+// ("users.online:42|s"). It goes out with the next batch of WithMaxBatchDelay
+// if set, at once otherwise.
+func (c *Client) WriteRaw(line string) error {
+	switch {
+	case line == "":
+		return fmt.Errorf("%w: empty", ErrInvalidRawLine)
+	case strings.ContainsAny(line, "\r\n"):
+		return fmt.Errorf("%w: line break in %q", ErrInvalidRawLine, line)
+	case len(line) > c.maxPacketSize:
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrInvalidRawLine, len(line), c.maxPacketSize)
+	}
+	return c.sendLine(line)
+}
@@ -0,0 +1,34 @@
+package statsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_WriteRaw(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithTags("env:prod"), WithMaxPacketSize(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.WriteRaw("users.online:42|s"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPacket(t, l), "users.online:42|s"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+
+	for _, line := range []string{"", "a:1|c\nb:2|c", "a:1|c\r", strings.Repeat("a", 33)} {
+		if err := c.WriteRaw(line); !errors.Is(err, ErrInvalidRawLine) {
+			t.Errorf("WriteRaw(%q): %v <=> want: %v", line, err, ErrInvalidRawLine)
+		}
+	}
+
+	c.Close()
+	if err := c.WriteRaw("x:1|c"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,159 @@
+package statsd
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithRealTime make the metric calls return in a bounded time, within a few
+// microseconds: past the validation and the sampling, a call only queues the
+// metric without blocking in a queue of queueSize metrics allocated upfront.
+// The name rules, the filters, the budgets, the buffering, formatting and
+// writing, which may lock or fail, happen in a goroutine of the client,
+// their errors go to the error handler. A call finding the queue full waits
+// for room at most bound, 0 to not wait, then drops the metric and returns
+// ErrQueueFull, the drops are counted by statsd.client.realtime.dropped at
+// each flush.
+func WithRealTime(queueSize int, bound time.Duration) Option {
+	return func(c *Client) {
+		c.rt = &realTime{size: queueSize, bound: bound}
+	}
+}
+
+// realTime is the queue of WithRealTime
+type realTime struct {
+	size    int
+	bound   time.Duration
+	queue   chan rtItem
+	dropped atomic.Int64
+	done    chan struct{} // closed to stop the goroutine
+	stopped chan struct{} // closed once the queue is drained
+
+	// stopping is set by stop with m held for writing, the calls queue with
+	// m held for reading, so that no metric is queued after the last drain
+	m        sync.RWMutex
+	stopping bool
+}
+
+// rtItem is a metric queued by a call, value holds the integer values and
+// fvalue the float gauges
+type rtItem struct {
+	c          *Client // the client called, for its prefix
+	t          metricType
+	stat       string
+	value      int64
+	fvalue     float64
+	sampleRate float32
+	tags       []string
+}
+
+func (r *realTime) init() {
+	r.queue = make(chan rtItem, r.size)
+	r.done = make(chan struct{})
+	r.stopped = make(chan struct{})
+}
+
+// enqueue queue a metric, waiting for room at most the bound
+func (c *Client) enqueue(item rtItem) error {
+	r := c.rt
+	r.m.RLock()
+	defer r.m.RUnlock()
+	if r.stopping {
+		return ErrClosed
+	}
+
+	select {
+	case r.queue <- item:
+		return nil
+	default:
+	}
+	if r.bound > 0 {
+		// spin rather than arm a timer, too coarse for microseconds
+		for deadline := time.Now().Add(r.bound); time.Now().Before(deadline); {
+			runtime.Gosched()
+			select {
+			case r.queue <- item:
+				return nil
+			default:
+			}
+		}
+	}
+
+	r.dropped.Add(1)
+	c.stats.dropped.Add(1)
+	c.log(slog.LevelWarn, "statsd: metric dropped", "stat", item.stat, "err", ErrQueueFull)
+	return ErrQueueFull
+}
+
+// run send the queued metrics until stop, then the remaining ones
+func (r *realTime) run() {
+	defer close(r.stopped)
+	for {
+		select {
+		case item := <-r.queue:
+			item.send()
+		case <-r.done:
+			for {
+				select {
+				case item := <-r.queue:
+					item.send()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// stop the goroutine once the queued metrics are sent, the calls then
+// return ErrClosed
+func (r *realTime) stop() {
+	r.m.Lock()
+	r.stopping = true
+	r.m.Unlock()
+	close(r.done)
+	<-r.stopped
+}
+
+// send a queued metric the way the call would have without WithRealTime
+func (item *rtItem) send() {
+	c := item.c
+	stat, ok, err := c.admit(item.stat, item.tags)
+	if !ok {
+		if err != nil {
+			c.handleError(fmt.Errorf("statsd: %s: %w", item.stat, err))
+		}
+		return
+	}
+	item.stat = stat
+
+	switch item.t {
+	case metricTypeCount:
+		err = c.addToBuffer(item.stat, float64(item.value), item.sampleRate, item.tags)
+	case metricTypeFCount:
+		err = c.addToBuffer(item.stat, item.fvalue, item.sampleRate, item.tags)
+	case metricTypeTimer:
+		err = c.timing(item.stat, item.value, item.sampleRate, item.tags)
+	case metricTypeDuration:
+		err = c.send(item.stat, c.durationValue(time.Duration(item.value)), "ms", item.sampleRate, item.tags...)
+	case metricTypeGauge:
+		err = c.gauge(item.stat, item.value, item.value < 0, item.sampleRate, item.tags)
+	case metricTypeFGauge:
+		err = c.gauge(item.stat, item.fvalue, item.fvalue < 0, item.sampleRate, item.tags)
+	case metricTypeGaugeDelta:
+		err = c.gaugeDelta(item.stat, item.value, item.tags)
+	}
+	c.handleError(err)
+}
+
+// realTimeLines append the count of the metrics dropped since the last flush
+func (c *Client) realTimeLines(lines []string) []string {
+	if dropped := c.rt.dropped.Swap(0); dropped > 0 {
+		lines = append(lines, c.format("statsd.client.realtime.dropped", dropped, "c", 1))
+	}
+	return lines
+}
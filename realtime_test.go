@@ -0,0 +1,256 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockSink block the writes until released, recording the packets
+type blockSink struct {
+	release chan struct{}
+	m       sync.Mutex
+	packets []string
+}
+
+func newBlockSink() *blockSink {
+	return &blockSink{release: make(chan struct{})}
+}
+
+func (s *blockSink) Write(packet []byte) error {
+	<-s.release
+	s.m.Lock()
+	s.packets = append(s.packets, string(packet))
+	s.m.Unlock()
+	return nil
+}
+
+func (s *blockSink) Close() error { return nil }
+
+func (s *blockSink) lines() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return strings.Join(s.packets, "\n")
+}
+
+func Test_WithRealTime(t *testing.T) {
+	s := newBlockSink()
+	close(s.release)
+	c, err := New("", WithSink(s), WithRealTime(16, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := c.WithPrefix("api")
+	child.Incr("hits", 1)
+	c.Decr("slots", 2)
+	c.Timing("db", 3)
+	c.Gauge("queue", -1)
+	c.FGauge("temp", 1.5)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Incr("hits", 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrClosed)
+	}
+
+	want := []string{
+		"db:3|ms",
+		"queue:0|g",
+		"queue:-1|g",
+		"temp:1.5|g",
+		"api.hits:1|c",
+		"slots:-2|c",
+	}
+	if got := s.lines(); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if _, err := New("", WithSink(s), WithRealTime(0, 0)); err != ErrInvalidQueueSize {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidQueueSize)
+	}
+}
+
+func Test_WithRealTimeQueueFull(t *testing.T) {
+	s := newBlockSink()
+	c, err := New("", WithSink(s), WithRealTime(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the goroutine blocks on the first timer, the second fills the queue
+	c.Timing("db", 1)
+	for i := 0; i < 100 && len(c.rt.queue) > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	c.Timing("db", 2)
+	if err := c.Timing("db", 3); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrQueueFull)
+	}
+
+	close(s.release)
+	c.Close()
+	if got := s.lines(); !strings.HasSuffix(got, "statsd.client.realtime.dropped:1|c") {
+		t.Fatalf("got:\n%s\nwant: statsd.client.realtime.dropped:1", got)
+	}
+}
+
+func Test_WithRealTimeBound(t *testing.T) {
+	s := newBlockSink()
+	c, err := New("", WithSink(s), WithRealTime(1, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the goroutine blocks on the first timer, the second fills the queue
+	c.Timing("db", 1)
+	for i := 0; i < 100 && len(c.rt.queue) > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	c.Timing("db", 2)
+
+	// the third waits for room, made once the sink is released
+	time.AfterFunc(10*time.Millisecond, func() { close(s.release) })
+	if err := c.Timing("db", 3); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if got := s.lines(); got != "db:1|ms\ndb:2|ms\ndb:3|ms" {
+		t.Fatalf("got:\n%s\nwant: the 3 timers", got)
+	}
+
+	if _, err := New("", WithSink(s), WithRealTime(1, -1)); err != ErrInvalidTimeout {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidTimeout)
+	}
+}
+
+func Test_WithRealTimeChecks(t *testing.T) {
+	s := newBlockSink()
+	close(s.release)
+	var m sync.Mutex
+	var errs []error
+	c, err := New("", WithSink(s), WithRealTime(16, 0),
+		WithNameRules(NameRules{Allowed: SafeNameChar, MaxLength: 8, Truncate: true}),
+		WithErrorHandler(func(err error) {
+			m.Lock()
+			errs = append(errs, err)
+			m.Unlock()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the name rules and the budget are applied off the caller path, their
+	// errors go to the handler
+	b := c.WithBudget("team", Budget{Metrics: 1, Interval: time.Hour})
+	for _, err := range []error{
+		c.Gauge("a b", 1),
+		c.Gauge("too.long.name", 1),
+		b.Gauge("ok", 1),
+		b.Gauge("ok", 2),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Close()
+
+	m.Lock()
+	defer m.Unlock()
+	want := []error{ErrNameChar, ErrNameTooLong, ErrBudgetExceeded}
+	if len(errs) != len(want) {
+		t.Fatalf("errors: %v <=> want: %v", errs, want)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, want[i]) {
+			t.Fatalf("errors[%d]: %v <=> want: %v", i, err, want[i])
+		}
+	}
+	if got := s.lines(); got != "too.long:1|g\nok:1|g" {
+		t.Fatalf("got:\n%s\nwant: too.long:1|g and ok:1|g", got)
+	}
+}
+
+func Test_WithRealTimeClose(t *testing.T) {
+	s := newBlockSink()
+	close(s.release)
+	c, err := New("", WithSink(s), WithRealTime(1024, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every call returning nil is sent, even racing with Close
+	var sent atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				switch err := c.Incr("hits", 1); {
+				case err == nil:
+					sent.Add(1)
+				case errors.Is(err, ErrClosed):
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	wg.Wait()
+
+	want := fmt.Sprintf("hits:%d|c", sent.Load())
+	if got := s.lines(); !strings.Contains(got, want) {
+		t.Fatalf("got:\n%s\nwant: %s", got, want)
+	}
+}
+
+// Test_RealTimeLatency check the latency of the calls while the sink is stalled
+func Test_RealTimeLatency(t *testing.T) {
+	const calls = 10000
+	const bound = 100 * time.Microsecond // loose, for the race detector and loaded machines
+
+	s := newBlockSink()
+	c, err := New("", WithSink(s), WithRealTime(calls, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	defer close(s.release)
+
+	latencies := make([]time.Duration, calls)
+	for i := range latencies {
+		start := time.Now()
+		if err := c.Timing("db", int64(i), "op:select"); err != nil {
+			t.Fatal(err)
+		}
+		latencies[i] = time.Since(start)
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	if p99 := latencies[calls*99/100]; p99 > bound {
+		t.Fatalf("p99 latency: %s <=> want: at most %s", p99, bound)
+	}
+}
+
+func Benchmark_RealTime(b *testing.B) {
+	s := newBlockSink()
+	close(s.release)
+	c, err := New("", WithSink(s), WithRealTime(b.N+1, 0))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Timing("db", int64(i), "op:select")
+	}
+}
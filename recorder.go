@@ -0,0 +1,131 @@
+package statsd
+
+import "time"
+
+// RequestRecorder accumulates the counters and timers of a single request
+// (HTTP, gRPC, ...) locally and merges them into the client once, when Flush
+// is called at the end of the request. It is not safe for concurrent use,
+// a recorder belongs to one request.
+type RequestRecorder struct {
+	client  *Client
+	counts  []countBuffer
+	timings []timingSample
+}
+
+type timingSample struct {
+	name  string
+	delta int64
+}
+
+// NewRequestRecorder return a recorder merging into the default client on Flush
+func NewRequestRecorder() *RequestRecorder {
+	return &RequestRecorder{}
+}
+
+// NewRequestRecorder return a recorder merging into c on Flush
+func (c *Client) NewRequestRecorder() *RequestRecorder {
+	return &RequestRecorder{client: c}
+}
+
+// Incr increment a counter of the request
+func (r *RequestRecorder) Incr(stat string, count int64) {
+	if stat == "" || count <= 0 {
+		return
+	}
+
+	for i := range r.counts {
+		if r.counts[i].name == stat {
+			r.counts[i].count += float64(count)
+			return
+		}
+	}
+	r.counts = append(r.counts, countBuffer{name: stat, count: float64(count), rate: 1})
+}
+
+// Timing track a duration of the request, the delta must be given in milliseconds
+func (r *RequestRecorder) Timing(stat string, delta int64) {
+	if stat == "" {
+		return
+	}
+
+	r.timings = append(r.timings, timingSample{stat, delta})
+}
+
+// TimingByValue track a duration of the request
+func (r *RequestRecorder) TimingByValue(stat string, d time.Duration) {
+	r.Timing(stat, int64(d/time.Millisecond))
+}
+
+// Flush merge everything recorded so far into the client and reset the recorder.
+// Counters join the client buffer under a single lock, timers are written
+// together in as few packets as possible.
+func (r *RequestRecorder) Flush() error {
+	counts, timings := r.counts, r.timings
+	r.counts, r.timings = nil, nil
+
+	if len(counts) == 0 && len(timings) == 0 {
+		return nil
+	}
+
+	client := r.client
+	if client == nil {
+		// the recorder belongs to the default client
+		if !Enabled() {
+			return nil
+		}
+		if client = getClient(); client == nil {
+			return ErrNotConnected
+		}
+	}
+
+	return client.merge(counts, timings)
+}
+
+// merge add the given counters to the buffer and send the given timers,
+// checked, filtered and budgeted as the metrics of c: those left out don't
+// prevent the others and the first error is returned
+func (c *Client) merge(counts []countBuffer, timings []timingSample) error {
+	var first error
+	admit := func(stat string, value interface{}) (string, bool) {
+		stat, ok, err := c.admit(stat, nil)
+		if err != nil && first == nil {
+			first = newSendError(err, "RequestRecorder.Flush", stat, value)
+		}
+		return stat, ok
+	}
+
+	kept := counts[:0]
+	for _, cnt := range counts {
+		var ok bool
+		if cnt.name, ok = admit(cnt.name, cnt.count); ok {
+			kept = append(kept, cnt)
+		}
+	}
+	counts = kept
+
+	if len(counts) > 0 {
+		c.m.Lock()
+		if c.closed.Load() {
+			c.m.Unlock()
+			return ErrClosed
+		}
+		for _, cnt := range counts {
+			cnt.name, _ = c.hashName(cnt.name, nil)
+			c.addCount(c.bucket(cnt.name), cnt.tags, cnt.count, cnt.rate)
+		}
+		c.m.Unlock()
+	}
+
+	lines := make([]string, 0, len(timings))
+	for _, t := range timings {
+		if name, ok := admit(t.name, t.delta); ok {
+			lines = append(lines, c.format(name, t.delta, "ms", 1))
+		}
+	}
+	if len(lines) > 0 {
+		if err := c.sendLines(lines); err != nil {
+			return err
+		}
+	}
+	return first
+}
@@ -0,0 +1,151 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient return a client writing to a local UDP listener
+func newTestClient(t *testing.T, prefix string) (*Client, net.PacketConn) {
+	t.Helper()
+
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newClient(l.LocalAddr().String(), prefix)
+	if err != nil {
+		l.Close()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		c.Close()
+		l.Close()
+	})
+	return c, l
+}
+
+// setupTestDefault point the package helpers at a default client connected
+// from cfg to a local UDP listener, cfg.Host and cfg.Port are filled in
+func setupTestDefault(t *testing.T, cfg *Config) net.PacketConn {
+	t.Helper()
+
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	restoreDefault(t)
+	cfg.Host = "127.0.0.1"
+	cfg.Port = l.LocalAddr().(*net.UDPAddr).Port
+	Setup(cfg)
+	return l
+}
+
+// restoreDefault close the default client created by the test and restore
+// the config of the package helpers at the end of the test
+func restoreDefault(t *testing.T) {
+	defaultMu.Lock()
+	prevConfig, prevAddr := config.Load(), addr
+	defaultMu.Unlock()
+	resetDefaultClient()
+
+	t.Cleanup(func() {
+		if c := defaultClient.Load(); c != nil {
+			c.Close()
+		}
+		resetDefaultClient()
+		defaultMu.Lock()
+		config.Store(prevConfig)
+		addr = prevAddr
+		defaultMu.Unlock()
+	})
+}
+
+// readPacket return the next packet received by l
+func readPacket(t *testing.T, l net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 64*1024)
+	l.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := l.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func Test_RequestRecorder(t *testing.T) {
+	c, l := newTestClient(t, "proj")
+
+	r := c.NewRequestRecorder()
+	r.Incr("req.count", 1)
+	r.Incr("req.count", 2)
+	r.Incr("req.err", 1)
+	r.Timing("req.db", 12)
+	r.TimingByValue("req.total", 30*time.Millisecond)
+
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []countBuffer{{name: "proj.req.count", count: 3, rate: 1}, {name: "proj.req.err", count: 1, rate: 1}}
+	c.m.Lock()
+	got := c.buffer
+	c.m.Unlock()
+	if len(got) != len(want) {
+		t.Fatalf("buffer: %v <=> want: %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("buffer: %v <=> want: %v", got, want)
+		}
+	}
+
+	lines := strings.Split(readPacket(t, l), "\n")
+	wantLines := []string{
+		"proj.req.db:12|ms",
+		"proj.req.total:30|ms",
+	}
+	if strings.Join(lines, ",") != strings.Join(wantLines, ",") {
+		t.Fatalf("packet: %v <=> want: %v", lines, wantLines)
+	}
+
+	// the recorder is reset after a flush
+	if len(r.counts) != 0 || len(r.timings) != 0 {
+		t.Fatalf("recorder not reset after flush")
+	}
+}
+
+func Test_RequestRecorderFiltered(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithDeny("debug.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	r := c.NewRequestRecorder()
+	r.Incr("debug.hits", 1)
+	r.Incr("hits", 1)
+	r.Timing("debug.db", 1)
+	r.Timing("db", 2)
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.m.Lock()
+	got := c.buffer
+	c.m.Unlock()
+	if len(got) != 1 || got[0].name != "hits" {
+		t.Fatalf("buffer: %v <=> want: hits only", got)
+	}
+	if got, want := readPacket(t, l), "db:2|ms"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+}
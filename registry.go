@@ -0,0 +1,226 @@
+package statsd
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry hold counters, gauges and timers in memory, to report them all
+// together to a StatSender: once with Report, or every interval with
+// StartReporter. It is a StatSender itself, safe for concurrent use, so that
+// the code instrumented doesn't tell a registry from a client.
+type Registry struct {
+	m        sync.Mutex
+	counters map[registryKey]int64
+	gauges   map[registryKey]float64
+	timers   map[registryKey][]int64
+}
+
+var _ StatSender = (*Registry)(nil)
+
+// registryKey is a metric name with its joined tags
+type registryKey struct {
+	name string
+	tags string
+}
+
+func newRegistryKey(stat string, tags []string) registryKey {
+	return registryKey{stat, joinTags(tags)}
+}
+
+func (k registryKey) splitTags() []string {
+	if k.tags == "" {
+		return nil
+	}
+	return strings.Split(k.tags, ",")
+}
+
+// NewRegistry return an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[registryKey]int64),
+		gauges:   make(map[registryKey]float64),
+		timers:   make(map[registryKey][]int64),
+	}
+}
+
+// Incr add count to a counter
+func (r *Registry) Incr(stat string, count int64, tags ...string) error {
+	if err := checkCount(count); err != nil {
+		return err
+	}
+
+	r.m.Lock()
+	r.counters[newRegistryKey(stat, tags)] += count
+	r.m.Unlock()
+	return nil
+}
+
+// Decr subtract count from a counter
+func (r *Registry) Decr(stat string, count int64, tags ...string) error {
+	if err := checkCount(count); err != nil {
+		return err
+	}
+
+	r.m.Lock()
+	r.counters[newRegistryKey(stat, tags)] -= count
+	r.m.Unlock()
+	return nil
+}
+
+// Timing add a sample to a timer, the delta must be given in milliseconds
+func (r *Registry) Timing(stat string, delta int64, tags ...string) error {
+	k := newRegistryKey(stat, tags)
+	r.m.Lock()
+	r.timers[k] = append(r.timers[k], delta)
+	r.m.Unlock()
+	return nil
+}
+
+// TimingDuration add a duration to a timer
+func (r *Registry) TimingDuration(stat string, d time.Duration, tags ...string) error {
+	return r.Timing(stat, int64(d/time.Millisecond), tags...)
+}
+
+// Gauge set a gauge
+func (r *Registry) Gauge(stat string, value int64, tags ...string) error {
+	return r.FGauge(stat, float64(value), tags...)
+}
+
+// FGauge set a gauge to a floating point value
+func (r *Registry) FGauge(stat string, value float64, tags ...string) error {
+	r.m.Lock()
+	r.gauges[newRegistryKey(stat, tags)] = value
+	r.m.Unlock()
+	return nil
+}
+
+// Snapshot return the metrics held, sorted by name, without reporting them
+func (r *Registry) Snapshot() Snapshot {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	var s Snapshot
+	for k, count := range r.counters {
+		s.Counters = append(s.Counters, SnapshotValue{k.name, k.splitTags(), float64(count)})
+	}
+	for k, value := range r.gauges {
+		s.Gauges = append(s.Gauges, SnapshotValue{k.name, k.splitTags(), value})
+	}
+	for k, samples := range r.timers {
+		s.Timers = append(s.Timers, SnapshotTimer{k.name, k.splitTags(), append([]int64(nil), samples...)})
+	}
+	sortSnapshot(&s)
+	return s
+}
+
+// sortSnapshot sort the metrics of s by name then tags
+func sortSnapshot(s *Snapshot) {
+	values := func(v []SnapshotValue) func(i, j int) bool {
+		return func(i, j int) bool { return snapshotLess(v[i].Name, v[i].Tags, v[j].Name, v[j].Tags) }
+	}
+	sort.Slice(s.Counters, values(s.Counters))
+	sort.Slice(s.Gauges, values(s.Gauges))
+	sort.Slice(s.Timers, func(i, j int) bool {
+		return snapshotLess(s.Timers[i].Name, s.Timers[i].Tags, s.Timers[j].Name, s.Timers[j].Tags)
+	})
+}
+
+func snapshotLess(name1 string, tags1 []string, name2 string, tags2 []string) bool {
+	if name1 != name2 {
+		return name1 < name2
+	}
+	return joinTags(tags1) < joinTags(tags2)
+}
+
+// Report send the metrics held to s: the counters and the timer samples since
+// the last report, which are reset, and the last value of every gauge
+func (r *Registry) Report(s StatSender) error {
+	r.m.Lock()
+	counters, timers := r.counters, r.timers
+	r.counters, r.timers = make(map[registryKey]int64), make(map[registryKey][]int64)
+	gauges := make(map[registryKey]float64, len(r.gauges))
+	for k, v := range r.gauges {
+		gauges[k] = v
+	}
+	r.m.Unlock()
+
+	var errs []error
+	for k, count := range counters {
+		switch {
+		case count > 0:
+			errs = append(errs, s.Incr(k.name, count, k.splitTags()...))
+		case count < 0:
+			errs = append(errs, s.Decr(k.name, -count, k.splitTags()...))
+		}
+	}
+	for k, value := range gauges {
+		errs = append(errs, s.FGauge(k.name, value, k.splitTags()...))
+	}
+	for k, samples := range timers {
+		for _, delta := range samples {
+			errs = append(errs, s.Timing(k.name, delta, k.splitTags()...))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reporter report a registry every interval, see Registry.StartReporter
+type Reporter struct {
+	registry *Registry
+	sender   StatSender
+	onError  func(err error)
+	ticker   *time.Ticker
+
+	done     chan struct{}
+	loopDone chan struct{}
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// StartReporter report the registry to s every interval until Stop. The
+// errors of the reports go to onError, if not nil.
+func (r *Registry) StartReporter(s StatSender, interval time.Duration, onError func(err error)) (*Reporter, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	rep := &Reporter{
+		registry: r,
+		sender:   s,
+		onError:  onError,
+		ticker:   time.NewTicker(interval),
+		done:     make(chan struct{}),
+		loopDone: make(chan struct{}),
+	}
+	go rep.loop()
+	return rep, nil
+}
+
+// Stop reporting, after a last report returned
+func (rep *Reporter) Stop() error {
+	rep.stopOnce.Do(func() {
+		rep.ticker.Stop()
+		close(rep.done)
+		<-rep.loopDone
+		rep.stopErr = rep.registry.Report(rep.sender)
+	})
+	return rep.stopErr
+}
+
+func (rep *Reporter) loop() {
+	defer close(rep.loopDone)
+	for {
+		select {
+		case <-rep.ticker.C:
+			if err := rep.registry.Report(rep.sender); err != nil && rep.onError != nil {
+				rep.onError(err)
+			}
+		case <-rep.done:
+			return
+		}
+	}
+}
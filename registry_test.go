@@ -0,0 +1,69 @@
+package statsd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Registry(t *testing.T) {
+	r := NewRegistry()
+	r.Incr("orders", 2, "shop:a")
+	r.Incr("orders", 1, "shop:a")
+	r.Decr("stock", 1)
+	r.FGauge("queue", 1.5)
+	r.Gauge("queue", 2)
+	r.Timing("db", 5)
+	r.TimingDuration("db", 3*time.Millisecond)
+	if err := r.Incr("orders", 0); err != ErrInvalidCount {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidCount)
+	}
+
+	want := Snapshot{
+		Counters: []SnapshotValue{{"orders", []string{"shop:a"}, 3}, {"stock", nil, -1}},
+		Gauges:   []SnapshotValue{{"queue", nil, 2}},
+		Timers:   []SnapshotTimer{{"db", nil, []int64{5, 3}}},
+	}
+	if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("snapshot: %+v <=> want: %+v", got, want)
+	}
+
+	// reported to another registry, the way it reaches a client
+	dst := NewRegistry()
+	if err := r.Report(dst); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("reported: %+v <=> want: %+v", got, want)
+	}
+
+	// the gauges are kept, the counters and timers start over
+	want = Snapshot{Gauges: []SnapshotValue{{"queue", nil, 2}}}
+	if got := r.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after report: %+v <=> want: %+v", got, want)
+	}
+}
+
+func Test_RegistryReporter(t *testing.T) {
+	c, l := newTestClient(t, "api")
+
+	r := NewRegistry()
+	rep, err := r.StartReporter(c, time.Millisecond, func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Timing("db", 4, "op:select")
+	if got := readPacket(t, l); got != "api.db:4|ms|#op:select" {
+		t.Fatalf("got: %s <=> want: api.db:4|ms|#op:select", got)
+	}
+	if err := rep.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.StartReporter(c, 0, nil); err != ErrInvalidInterval {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+}
@@ -0,0 +1,48 @@
+package statsd
+
+import (
+	"fmt"
+	"time"
+)
+
+// reloadGrace is how long the client replaced by Reload stays open, for the
+// calls which got it just before the swap
+var reloadGrace = time.Second
+
+// userDefault is the client given to SetDefault, owned by the caller, guarded by defaultMu
+var userDefault *Client
+
+// Reload apply cfg to the package level helpers without restart, e.g. to
+// rotate the endpoint during an agent upgrade: a client built from cfg (host,
+// prefix, sample rate, tags, ...) atomically replaces the default client,
+// which is closed shortly after, flushing its buffered counters, so that no
+// metric in flight is dropped. On error, e.g. cfg can't connect, nothing
+// changes. A client given to SetDefault is replaced but not closed.
+func Reload(cfg *Config) error {
+	var client *Client
+	if cfg.Enable {
+		var err error
+		c := *cfg
+		if c.SampleRate == 0 {
+			c.SampleRate = defaultSampleRate
+		}
+		if client, err = New(fmt.Sprintf("%s:%d", c.Host, c.Port), c.options()...); err != nil {
+			return err
+		}
+	}
+
+	defaultMu.Lock()
+	setup(cfg)
+	defaultTried = client != nil // disabled, connected lazily once enabled again
+	old := defaultClient.Swap(client)
+	owned := old != userDefault
+	userDefault = nil
+	defaultMu.Unlock()
+
+	if old != nil && owned {
+		time.AfterFunc(reloadGrace, func() {
+			reportError(cfg, old.Close())
+		})
+	}
+	return nil
+}
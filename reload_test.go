@@ -0,0 +1,100 @@
+package statsd
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Reload(t *testing.T) {
+	defer func(d time.Duration) { reloadGrace = d }(reloadGrace)
+	reloadGrace = 0
+
+	l1 := setupTestDefault(t, &Config{Project: "v1", Enable: true})
+	Gauge("queue", 1)
+	if got := readPacket(t, l1); got != "v1.queue:1|g" {
+		t.Fatalf("got: %s <=> want: v1.queue:1|g", got)
+	}
+	IncrByVal("hits", 1)
+
+	l2, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l2.Close()
+	err = Reload(&Config{
+		Project: "v2",
+		Host:    "127.0.0.1",
+		Port:    l2.LocalAddr().(*net.UDPAddr).Port,
+		Enable:  true,
+		Tags:    []string{"env:test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the counter buffered by the replaced client is not lost
+	if got := readPacket(t, l1); got != "v1.hits:1|c" {
+		t.Fatalf("got: %s <=> want: v1.hits:1|c", got)
+	}
+	Gauge("queue", 2)
+	if got := readPacket(t, l2); got != "v2.queue:2|g|#env:test" {
+		t.Fatalf("got: %s <=> want: v2.queue:2|g|#env:test", got)
+	}
+
+	// a config which can't connect changes nothing
+	if err := Reload(&Config{Host: "256.0.0.1", Port: 1, Enable: true}); err == nil {
+		t.Fatal("reloaded an invalid address")
+	}
+	Gauge("queue", 3)
+	if got := readPacket(t, l2); got != "v2.queue:3|g|#env:test" {
+		t.Fatalf("got: %s <=> want: v2.queue:3|g|#env:test", got)
+	}
+}
+
+func Test_ReloadConcurrent(t *testing.T) {
+	defer func(d time.Duration) { reloadGrace = d }(reloadGrace)
+	reloadGrace = 0
+
+	l := setupTestDefault(t, &Config{Project: "v1", Enable: true})
+	port := l.LocalAddr().(*net.UDPAddr).Port
+
+	// run with -race: the helpers read the config while Reload replaces it
+	stop := make(chan struct{})
+	var wg, started sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				Incr("hits")
+				Gauge("queue", 1)
+				FIncr("bytes", 0.5)
+			}
+		}()
+	}
+	started.Wait()
+	for i := 0; i < 20; i++ {
+		err := Reload(&Config{
+			Project:     "v2",
+			Host:        "127.0.0.1",
+			Port:        port,
+			Enable:      true,
+			SampleRate:  0.5,
+			SampleRates: map[string]float32{"queue": 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
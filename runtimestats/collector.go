@@ -0,0 +1,182 @@
+package runtimestats
+
+import (
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// Collector periodically sample a configured list of runtime/metrics and
+// forward them, each under <stat>.<name> where name is the metric name
+// without its unit, e.g. "/gc/heap/allocs:bytes" under "<stat>.gc.heap.allocs":
+//   - cumulative integers are sent as counters of their increase,
+//   - other integers and floats as gauges, the increase for cumulative floats,
+//   - histograms as .p50, .p90, .p99 and .max gauges of the interval,
+//     in milliseconds for the seconds histograms.
+type Collector struct {
+	client statsd.Statter
+	stat   string
+
+	ticker   *time.Ticker
+	done     chan struct{}
+	stopOnce sync.Once
+
+	samples    []metrics.Sample
+	cumulative []bool
+	prev       []sampleValue
+}
+
+// sampleValue is a copy of a sampled value, metrics.Read reuses the histograms
+type sampleValue struct {
+	u      uint64
+	f      float64
+	counts []uint64
+}
+
+// StartCollector start sampling the runtime metrics of the given names every
+// interval, sending to c or to statsd.DefaultStatter if nil
+func StartCollector(c statsd.Statter, stat string, names []string, interval time.Duration) (*Collector, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if c == nil {
+		c = statsd.DefaultStatter()
+	}
+
+	all := make(map[string]metrics.Description)
+	for _, d := range metrics.All() {
+		all[d.Name] = d
+	}
+
+	col := &Collector{
+		client: c,
+		stat:   stat,
+		done:   make(chan struct{}),
+		prev:   make([]sampleValue, len(names)),
+	}
+	for _, name := range names {
+		d, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("runtimestats: unknown runtime metric %q", name)
+		}
+		col.samples = append(col.samples, metrics.Sample{Name: name})
+		col.cumulative = append(col.cumulative, d.Cumulative)
+	}
+
+	// the first interval starts now
+	metrics.Read(col.samples)
+	for i := range col.samples {
+		col.prev[i] = copyValue(col.samples[i].Value)
+	}
+
+	col.ticker = time.NewTicker(interval)
+	go col.loop()
+	return col, nil
+}
+
+// Stop sampling
+func (col *Collector) Stop() {
+	col.stopOnce.Do(func() {
+		col.ticker.Stop()
+		close(col.done)
+	})
+}
+
+func (col *Collector) loop() {
+	for {
+		select {
+		case <-col.ticker.C:
+			col.collect()
+		case <-col.done:
+			return
+		}
+	}
+}
+
+func (col *Collector) collect() {
+	metrics.Read(col.samples)
+
+	for i, s := range col.samples {
+		cur := copyValue(s.Value)
+		col.send(col.stat+"."+bucketName(s.Name), s, col.cumulative[i], col.prev[i], cur)
+		col.prev[i] = cur
+	}
+}
+
+func (col *Collector) send(name string, s metrics.Sample, cumulative bool, prev, cur sampleValue) {
+	switch s.Value.Kind() {
+	case metrics.KindUint64:
+		switch {
+		case !cumulative:
+			col.client.Gauge(name, clamp(cur.u))
+		case cur.u > prev.u:
+			col.client.Incr(name, clamp(cur.u-prev.u))
+		}
+	case metrics.KindFloat64:
+		if cumulative {
+			col.client.FGauge(name, cur.f-prev.f)
+		} else {
+			col.client.FGauge(name, cur.f)
+		}
+	case metrics.KindFloat64Histogram:
+		counts := deltaCounts(prev.counts, cur.counts)
+		scale := 1.0
+		if strings.HasSuffix(s.Name, ":seconds") {
+			scale = 1000
+		}
+
+		buckets := s.Value.Float64Histogram().Buckets
+		for _, p := range histogramPercentiles {
+			v := percentile(buckets, counts, p.q)
+			if math.IsNaN(v) {
+				return // empty interval
+			}
+			col.client.FGauge(name+"."+p.name, v*scale)
+		}
+	}
+}
+
+func copyValue(v metrics.Value) sampleValue {
+	switch v.Kind() {
+	case metrics.KindUint64:
+		return sampleValue{u: v.Uint64()}
+	case metrics.KindFloat64:
+		return sampleValue{f: v.Float64()}
+	case metrics.KindFloat64Histogram:
+		return sampleValue{counts: append([]uint64(nil), v.Float64Histogram().Counts...)}
+	}
+	return sampleValue{}
+}
+
+// deltaCounts return the counts of cur added since prev
+func deltaCounts(prev, cur []uint64) []uint64 {
+	delta := make([]uint64, len(cur))
+	for i, n := range cur {
+		delta[i] = n
+		if i < len(prev) {
+			delta[i] -= prev[i]
+		}
+	}
+	return delta
+}
+
+// bucketName turn a runtime metric name into a bucket: "/gc/heap/allocs:bytes" => "gc.heap.allocs"
+func bucketName(name string) string {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ReplaceAll(strings.Trim(name, "/"), "/", ".")
+}
+
+// clamp convert n to int64, saturating at math.MaxInt64
+func clamp(n uint64) int64 {
+	if n > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(n)
+}
@@ -0,0 +1,139 @@
+package runtimestats
+
+import (
+	"fmt"
+	"net"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+func Test_bucketName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/gc/heap/allocs:bytes", "gc.heap.allocs"},
+		{"/sched/latencies:seconds", "sched.latencies"},
+		{"/memory/classes/heap/free:bytes", "memory.classes.heap.free"},
+	}
+
+	for _, tt := range tests {
+		if got := bucketName(tt.name); got != tt.want {
+			t.Fatalf("[%s] got: %s <=> want: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_StartCollector(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := statsd.New(l.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := StartCollector(c, "runtime", []string{"/no/such:metric"}, time.Second); err == nil {
+		t.Fatal("no error for an unknown metric")
+	}
+
+	col, err := StartCollector(c, "runtime", []string{"/sched/goroutines:goroutines"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer col.Stop()
+
+	col.collect()
+	buf := make([]byte, 1024)
+	l.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := l.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "runtime.sched.goroutines:") || !strings.HasSuffix(got, "|g") {
+		t.Fatalf("unexpected gauge: %s", got)
+	}
+}
+
+// recordStatter record the metrics sent by a collector
+type recordStatter struct {
+	statsd.Statter
+	lines []string
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%d|c", stat, count))
+	return nil
+}
+
+func (r *recordStatter) FGauge(stat string, value float64, tags ...string) error {
+	r.lines = append(r.lines, fmt.Sprintf("%s:%g|g", stat, value))
+	return nil
+}
+
+// readSample return a sample of the runtime metric name
+func readSample(t *testing.T, name string) metrics.Sample {
+	t.Helper()
+
+	s := []metrics.Sample{{Name: name}}
+	metrics.Read(s)
+	if s[0].Value.Kind() == metrics.KindBad {
+		t.Skipf("%s not supported by this runtime", name)
+	}
+	return s[0]
+}
+
+func Test_CollectorSend(t *testing.T) {
+	r := &recordStatter{}
+	col := &Collector{client: r}
+
+	// cumulative integers are sent as counters of their increase, none if unchanged
+	cycles := readSample(t, "/gc/cycles/total:gc-cycles")
+	col.send("gc.cycles", cycles, true, sampleValue{u: 10}, sampleValue{u: 15})
+	col.send("gc.cycles", cycles, true, sampleValue{u: 15}, sampleValue{u: 15})
+
+	// histograms are sent as the percentiles of the interval, in ms for seconds
+	latencies := readSample(t, "/sched/latencies:seconds")
+	buckets := latencies.Value.Float64Histogram().Buckets
+	i := sort.SearchFloat64s(buckets, 0.002) // a bucket starting around 2ms
+	prev := make([]uint64, len(buckets)-1)
+	cur := make([]uint64, len(buckets)-1)
+	prev[i], cur[i] = 5, 15
+	col.send("sched.latencies", latencies, true, sampleValue{counts: prev}, sampleValue{counts: cur})
+
+	// an empty interval sends nothing
+	col.send("sched.latencies", latencies, true, sampleValue{counts: cur}, sampleValue{counts: cur})
+
+	if len(r.lines) != 1+len(histogramPercentiles) || r.lines[0] != "gc.cycles:5|c" {
+		t.Fatalf("lines: %v", r.lines)
+	}
+	want := buckets[i] * 1000
+	for j, p := range histogramPercentiles {
+		line := r.lines[1+j]
+		var v float64
+		if _, err := fmt.Sscanf(line, "sched.latencies."+p.name+":%g|g", &v); err != nil {
+			t.Fatalf("line: %s (%v)", line, err)
+		}
+		if v < want || v > buckets[i+1]*1000 {
+			t.Fatalf("[%s] got: %g <=> want in [%g, %g]", p.name, v, want, buckets[i+1]*1000)
+		}
+	}
+}
+
+func Test_StartInvalidInterval(t *testing.T) {
+	if _, err := StartCollector(&recordStatter{}, "runtime", nil, 0); err != ErrInvalidInterval {
+		t.Fatalf("StartCollector err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+	if _, err := StartSchedLatency(&recordStatter{}, "sched", -time.Second); err != ErrInvalidInterval {
+		t.Fatalf("StartSchedLatency err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+}
@@ -0,0 +1,148 @@
+// Package runtimestats collect Go runtime statistics and send them through a statsd client
+package runtimestats
+
+import (
+	"errors"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+const schedLatencyMetric = "/sched/latencies:seconds"
+
+// ErrInvalidInterval is returned by the Start functions for an interval <= 0
+var ErrInvalidInterval = errors.New("runtimestats: interval is less than or equal to 0")
+
+// percentiles sent for the histograms each interval
+var histogramPercentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+	{"max", 1},
+}
+
+// SchedLatency periodically send the percentiles of the time goroutines spent
+// runnable before running, the leading indicator of CPU saturation. Each
+// interval the delta of the runtime histogram is turned into <stat>.p50,
+// <stat>.p90, <stat>.p99 and <stat>.max gauges in milliseconds, fractional
+// so that the microsecond latencies aren't rounded down to 0.
+type SchedLatency struct {
+	client statsd.Statter
+	stat   string
+
+	ticker   *time.Ticker
+	done     chan struct{}
+	stopOnce sync.Once
+
+	sample []metrics.Sample
+	prev   []uint64
+}
+
+// StartSchedLatency start collecting the scheduling latency every interval,
+// sending to c or to statsd.DefaultStatter if nil
+func StartSchedLatency(c statsd.Statter, stat string, interval time.Duration) (*SchedLatency, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if c == nil {
+		c = statsd.DefaultStatter()
+	}
+
+	s := &SchedLatency{
+		client: c,
+		stat:   stat,
+		ticker: time.NewTicker(interval),
+		done:   make(chan struct{}),
+		sample: []metrics.Sample{{Name: schedLatencyMetric}},
+	}
+
+	s.read() // the first window starts now
+	go s.loop()
+	return s, nil
+}
+
+// Stop collecting
+func (s *SchedLatency) Stop() {
+	s.stopOnce.Do(func() {
+		s.ticker.Stop()
+		close(s.done)
+	})
+}
+
+func (s *SchedLatency) loop() {
+	for {
+		select {
+		case <-s.ticker.C:
+			s.collect()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *SchedLatency) collect() {
+	h, counts := s.read()
+	if h == nil || len(counts) == 0 {
+		return
+	}
+
+	for _, p := range histogramPercentiles {
+		v := percentile(h.Buckets, counts, p.q)
+		if math.IsNaN(v) {
+			return // no goroutine scheduled in the window
+		}
+		s.client.FGauge(s.stat+"."+p.name, v*1000)
+	}
+}
+
+// read the runtime histogram and return it with the counts since the previous read
+func (s *SchedLatency) read() (*metrics.Float64Histogram, []uint64) {
+	metrics.Read(s.sample)
+	if s.sample[0].Value.Kind() != metrics.KindFloat64Histogram {
+		return nil, nil // not supported by this runtime
+	}
+
+	h := s.sample[0].Value.Float64Histogram()
+	delta := deltaCounts(s.prev, h.Counts)
+	s.prev = append(s.prev[:0], h.Counts...)
+
+	return h, delta
+}
+
+// percentile return the q quantile of a histogram, buckets holding the
+// len(counts)+1 boundaries. The upper boundary of the matching bucket is
+// returned, or its lower one for the last unbounded bucket. NaN is returned
+// for an empty histogram.
+func percentile(buckets []float64, counts []uint64, q float64) float64 {
+	var total uint64
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, n := range counts {
+		seen += n
+		if seen < rank {
+			continue
+		}
+		if upper := buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		}
+		return buckets[i]
+	}
+	return buckets[len(buckets)-1]
+}
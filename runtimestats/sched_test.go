@@ -0,0 +1,54 @@
+package runtimestats
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_percentile(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 0.001, 0.002, 0.004, math.Inf(1)}
+
+	tests := []struct {
+		name   string
+		counts []uint64
+		q      float64
+		want   float64
+	}{
+		{
+			name:   "p50",
+			counts: []uint64{0, 6, 3, 1},
+			q:      0.5,
+			want:   0.002,
+		},
+		{
+			name:   "p90",
+			counts: []uint64{0, 6, 3, 1},
+			q:      0.9,
+			want:   0.004,
+		},
+		{
+			name:   "max-unbounded",
+			counts: []uint64{0, 6, 3, 1},
+			q:      1,
+			want:   0.004,
+		},
+		{
+			name:   "zero-quantile",
+			counts: []uint64{0, 0, 5, 0},
+			q:      0,
+			want:   0.004,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(buckets, tt.counts, tt.q); got != tt.want {
+				t.Fatalf("[%s] got: %v <=> want: %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	if got := percentile(buckets, []uint64{0, 0, 0, 0}, 0.5); !math.IsNaN(got) {
+		t.Fatalf("empty histogram got: %v <=> want: NaN", got)
+	}
+}
@@ -0,0 +1,123 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithSampleRates override the sample rate of the methods without explicit
+// sampling by metric name, e.g. 0.01 for a noisy "cache.hit" while the
+// critical counters stay at 1. A key is a name or a glob of path.Match, as
+// "cache.*" or "*.hit", matched against the name given to the call and
+// against the name with the prefix of the client. An exact name wins over
+// the globs, and the longest matching glob over the others. The overrides
+// take precedence over WithSampleRate and WithSampleSchedule.
+func WithSampleRates(rates map[string]float32) Option {
+	return func(c *Client) {
+		c.sampleRates = rates
+	}
+}
+
+// sampleRates is the lookup table of WithSampleRates
+type sampleRates struct {
+	exact map[string]float32
+	globs []rateGlob // longest first
+}
+
+type rateGlob struct {
+	pattern string
+	rate    float32
+}
+
+// newSampleRates build the table of rates, the invalid entries are left out
+// and returned in err
+func newSampleRates(rates map[string]float32) (*sampleRates, error) {
+	if len(rates) == 0 {
+		return nil, nil
+	}
+
+	t := &sampleRates{exact: make(map[string]float32, len(rates))}
+	var errs []error
+	for key, rate := range rates {
+		if err := checkSampleRate(rate); err != nil {
+			errs = append(errs, fmt.Errorf("statsd: SampleRates[%q] %g: %w", key, rate, err))
+			continue
+		}
+		if !strings.ContainsAny(key, `*?[\`) {
+			t.exact[key] = rate
+			continue
+		}
+		if _, err := path.Match(key, ""); err != nil {
+			errs = append(errs, fmt.Errorf("statsd: SampleRates[%q]: %w", key, err))
+			continue
+		}
+		t.globs = append(t.globs, rateGlob{key, rate})
+	}
+	sort.Slice(t.globs, func(i, j int) bool {
+		if len(t.globs[i].pattern) != len(t.globs[j].pattern) {
+			return len(t.globs[i].pattern) > len(t.globs[j].pattern)
+		}
+		return t.globs[i].pattern < t.globs[j].pattern
+	})
+	return t, errors.Join(errs...)
+}
+
+// lookup return the rate of the first name matching, ok is false if none
+func (t *sampleRates) lookup(names ...string) (rate float32, ok bool) {
+	if t == nil {
+		return 0, false
+	}
+	for _, name := range names {
+		if rate, ok := t.exact[name]; ok {
+			return rate, true
+		}
+	}
+	for _, g := range t.globs {
+		for _, name := range names {
+			if ok, _ := path.Match(g.pattern, name); ok {
+				return g.rate, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// rate return the sample rate of stat for the methods without explicit
+// sampling
+func (c *Client) rate(stat string) float32 {
+	if c.rates != nil {
+		names := []string{stat}
+		if c.prefix != "" {
+			names = append(names, c.prefix+"."+stat)
+		}
+		if rate, ok := c.rates.lookup(names...); ok {
+			return rate
+		}
+	}
+	if c.schedule == nil {
+		return c.sampleRate // without reading the clock, for the hot paths
+	}
+	return c.schedule.Rate(c.clock.Now(), c.sampleRate)
+}
+
+// sampleRate return the sample rate of stat for the package level helpers at
+// the current time, cfg is the snapshot of Setup
+func (cfg *Config) sampleRate(stat string) float32 {
+	if rate, ok := cfg.rates.lookup(stat); ok {
+		return rate
+	}
+	return cfg.SampleSchedule.Rate(time.Now(), cfg.SampleRate)
+}
+
+// incrRate return the sample rate of Incr for stat: 1, as Incr always sent,
+// unless Config.SampleRates sets one for stat
+func (cfg *Config) incrRate(stat string) float32 {
+	if rate, ok := cfg.rates.lookup(stat); ok {
+		return rate
+	}
+	return 1
+}
@@ -0,0 +1,85 @@
+package statsd
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_WithSampleRates(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("app"), WithSampleRate(0.5), WithSampleRates(map[string]float32{
+		"cache.hit":     0.01,
+		"cache.*":       0.1,
+		"cache.miss.*":  0.2,
+		"*.errors":      1,
+		"app.db.*":      0.3,
+		"app.db.orders": 0.4,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		name string
+		c    *Client
+		stat string
+		want float32
+	}{
+		{"exact", c, "cache.hit", 0.01},
+		{"glob", c, "cache.size", 0.1},
+		{"longest glob", c, "cache.miss.disk", 0.2},
+		{"suffix glob", c, "api.errors", 1},
+		{"prefixed glob", c, "db.users", 0.3},
+		{"prefixed exact", c, "db.orders", 0.4},
+		{"child prefix", c.WithPrefix("db"), "users", 0.3},
+		{"default", c, "api.latency", 0.5},
+	}
+	for _, tt := range tests {
+		if got := tt.c.rate(tt.stat); got != tt.want {
+			t.Errorf("%s: rate(%q): %g <=> want: %g", tt.name, tt.stat, got, tt.want)
+		}
+	}
+
+	// the overrides apply to the calls without explicit sampling only
+	c2, err := New(l.LocalAddr().String(), WithSampleRates(map[string]float32{"cache.*": 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	if err := c2.Timing("cache.get", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.TimingWithSampling("cache.get", 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPacket(t, l); got != "cache.get:2|ms" {
+		t.Fatalf("packet: %q <=> want: %q", got, "cache.get:2|ms")
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithSampleRates(map[string]float32{"cache.*": 2})); !errors.Is(err, ErrInvalidSampleRate) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidSampleRate)
+	}
+}
+
+func Test_ConfigSampleRates(t *testing.T) {
+	l := setupTestDefault(t, &Config{Enable: true, SampleRates: map[string]float32{"cache.*": 0}})
+
+	Gauge("cache.size", 1)
+	Gauge("db.size", 2)
+	if got := readPacket(t, l); got != "db.size:2|g" {
+		t.Fatalf("packet: %q <=> want: %q", got, "db.size:2|g")
+	}
+}
+
+func Test_IncrSampleRate(t *testing.T) {
+	// Config.SampleRate doesn't sample Incr, Config.SampleRates does
+	l := setupTestDefault(t, &Config{Enable: true, SampleRate: 0.0001, SampleRates: map[string]float32{"cache.*": 0}})
+
+	Incr("hits")
+	Incr("cache.hits")
+	Default().flush()
+	if got := readPacket(t, l); got != "hits:1|c" {
+		t.Fatalf("packet: %q <=> want: %q", got, "hits:1|c")
+	}
+}
@@ -0,0 +1,110 @@
+package statsd
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// WithHashSampling make the sampling deterministic: whether a metric is kept
+// is decided by a hash of its name and of the current interval instead of at
+// random, so that either all or none of the events of a metric fire in an
+// interval and the related metrics of a request stay consistent. The
+// interval is the flush interval if 0.
+func WithHashSampling(interval time.Duration) Option {
+	return func(c *Client) {
+		c.hashSampling = true
+		c.hashInterval = interval
+	}
+}
+
+// WithRand make the random sampling of the client draw from src instead of
+// the source of the package, e.g. a seeded rand.NewPCG for the tests to get
+// the same sampling at each run. src is used under a lock of the client.
+func WithRand(src rand.Source) Option {
+	return func(c *Client) {
+		c.rng = &lockedRand{r: rand.New(src)}
+	}
+}
+
+// lockedRand is a rand.Rand safe for concurrent use
+type lockedRand struct {
+	m sync.Mutex
+	r *rand.Rand
+}
+
+func (l *lockedRand) Float32() float32 {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.r.Float32()
+}
+
+// WithSampleKey return a child client, with the prefix of c, whose sampled
+// metrics are kept or dropped together by a hash of key, e.g. a request or a
+// trace ID: for a given rate, all the metrics of the key fire or none does.
+func (c *Client) WithSampleKey(key string) *Client {
+	child := c.WithPrefix("")
+	child.sampleKey = key
+	child.hasSampleKey = true
+	return child
+}
+
+// fire tell if a metric sampled at sampleRate is kept, by the sample key of
+// c, by WithHashSampling or at random, from the source of WithRand if set
+func (c *Client) fire(stat string, sampleRate float32) bool {
+	if sampleRate == 1 {
+		return true
+	}
+
+	switch {
+	case c.hasSampleKey:
+		return hashFire(fnvString(fnvOffset, c.sampleKey), sampleRate)
+	case c.hashSampling:
+		interval := c.hashInterval
+		if interval <= 0 {
+			interval = c.flushInterval
+		}
+		h := fnvString(fnvOffset, c.prefix)
+		h = fnvString(fnvByte(h, '.'), stat)
+		return hashFire(fnvUint64(h, uint64(c.clock.Now().UnixNano()/int64(interval))), sampleRate)
+	}
+	if c.rng != nil {
+		return c.rng.Float32() <= sampleRate
+	}
+	return shouldFire(sampleRate)
+}
+
+// hashFire map the hash h to [0, 1) and keep it under sampleRate, once its
+// bits mixed: the high bits of FNV vary little for the names of a family
+func hashFire(h uint64, sampleRate float32) bool {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return float64(h>>11)/(1<<53) < float64(sampleRate)
+}
+
+// the FNV-1a 64 bits hash, inlined for the sampling to not allocate
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+func fnvByte(h uint64, b byte) uint64 {
+	return (h ^ uint64(b)) * fnvPrime
+}
+
+func fnvString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = fnvByte(h, s[i])
+	}
+	return h
+}
+
+func fnvUint64(h uint64, v uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h = fnvByte(h, byte(v>>(8*i)))
+	}
+	return h
+}
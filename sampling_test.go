@@ -0,0 +1,87 @@
+package statsd
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_WithHashSampling(t *testing.T) {
+	c := &Client{clientConn: &clientConn{flushInterval: time.Hour, clock: systemClock{}}}
+	WithHashSampling(0)(c)
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		stat := "metric." + strconv.Itoa(i)
+		fire := c.fire(stat, 0.25)
+		// the decision holds for all the events of the metric in the interval
+		for j := 0; j < 10; j++ {
+			if got := c.fire(stat, 0.25); got != fire {
+				t.Fatalf("%s: fire: %t <=> want: %t", stat, got, fire)
+			}
+		}
+		if fire {
+			kept++
+		}
+	}
+	if kept < 150 || kept > 350 {
+		t.Fatalf("kept: %d/1000 <=> want: about 250", kept)
+	}
+
+	if !c.fire("metric.0", 1) {
+		t.Fatal("metric sampled at 1 dropped")
+	}
+	if c.fire("metric.0", 0) {
+		t.Fatal("metric sampled at 0 kept")
+	}
+}
+
+func Test_WithSampleKey(t *testing.T) {
+	c := &Client{clientConn: &clientConn{flushInterval: time.Hour, clock: systemClock{}}}
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		r := c.WithPrefix("api").WithSampleKey("request-" + strconv.Itoa(i))
+		fire := r.fire("latency", 0.5)
+		// all the metrics of the key fire or none does, in the children too
+		for _, stat := range []string{"latency", "hits", "errors"} {
+			if got := r.WithPrefix("db").fire(stat, 0.5); got != fire {
+				t.Fatalf("request-%d %s: fire: %t <=> want: %t", i, stat, got, fire)
+			}
+		}
+		if fire {
+			kept++
+		}
+	}
+	if kept < 400 || kept > 600 {
+		t.Fatalf("kept: %d/1000 <=> want: about 500", kept)
+	}
+}
+
+func Test_WithRand(t *testing.T) {
+	draw := func() []bool {
+		c := &Client{clientConn: &clientConn{}}
+		WithRand(rand.NewPCG(1, 2))(c)
+		fired := make([]bool, 100)
+		for i := range fired {
+			fired[i] = c.fire("hits", 0.5)
+		}
+		return fired
+	}
+
+	// the same source samples the same way
+	a, b := draw(), draw()
+	kept := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("draw %d: %t <=> %t", i, a[i], b[i])
+		}
+		if a[i] {
+			kept++
+		}
+	}
+	if kept == 0 || kept == len(a) {
+		t.Fatalf("kept: %d/%d", kept, len(a))
+	}
+}
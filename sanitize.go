@@ -0,0 +1,62 @@
+package statsd
+
+import "strings"
+
+// unsafeChars break the wire format when found in a bucket or a tag value
+const unsafeChars = " :|,\n\r\t"
+
+// WithSanitizer pass the stats and the tag values of the client through fn
+// before they are sent, e.g. Sanitize, for the names built from dynamic
+// input not to corrupt the lines. fn is given the stat without the prefix
+// of the client, and the tags "key:value" their value only.
+func WithSanitizer(fn func(string) string) Option {
+	return func(c *Client) {
+		c.sanitizer = fn
+	}
+}
+
+// Sanitize replace by '_' the characters corrupting a line when found in a
+// bucket or a tag value: the spaces, colons, pipes, commas and line breaks
+func Sanitize(s string) string {
+	if !strings.ContainsAny(s, unsafeChars) {
+		return s // without allocating, the usual case
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(unsafeChars, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// sanitize apply the sanitizer of the client to stat and to the tag values,
+// tags is left untouched
+func (c *clientConn) sanitize(stat string, tags []string) (string, []string) {
+	if c.sanitizer == nil {
+		return stat, tags
+	}
+
+	stat = c.sanitizer(stat)
+	var sanitized []string
+	for i, tag := range tags {
+		s := tag
+		if k, v, ok := strings.Cut(tag, ":"); ok {
+			if sv := c.sanitizer(v); sv != v {
+				s = k + ":" + sv
+			}
+		} else {
+			s = c.sanitizer(tag)
+		}
+		if s == tag {
+			continue
+		}
+		if sanitized == nil {
+			sanitized = append([]string(nil), tags...)
+		}
+		sanitized[i] = s
+	}
+	if sanitized == nil {
+		return stat, tags
+	}
+	return stat, sanitized
+}
@@ -0,0 +1,67 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Sanitize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"orders.placed", "orders.placed"},
+		{"user name", "user_name"},
+		{"a:b|c,d", "a_b_c_d"},
+		{"line\nbreak\r\ttab", "line_break__tab"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q): %q <=> want: %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func Test_WithSanitizer(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithSanitizer(Sanitize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tags := []string{"route:/a|b", "user:x y", "bare tag"}
+	c.Timing("search page:load", 3, tags...)
+	want := "api.search_page_load:3|ms|#route:/a_b,user:x_y,bare_tag"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+	if tags[0] != "route:/a|b" {
+		t.Fatalf("call tags modified: %v", tags)
+	}
+
+	// the buffered counters and the instruments too
+	c.Incr("hits\n", 1)
+	c.NewCounter("clicks|c", "button:ok,cancel").Inc()
+	if err := c.flush(); err != nil {
+		t.Fatal(err)
+	}
+	got := readPacket(t, l)
+	for _, want := range []string{"api.hits_:1|c", "api.clicks_c:1|c|#button:ok_cancel"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("flush:\n%s\nwant: %s", got, want)
+		}
+	}
+
+	// a custom sanitizer
+	c2, err := New(l.LocalAddr().String(), WithSanitizer(strings.ToLower))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	c2.Gauge("Queue.Size", 2, "Env:Prod")
+	if got, want := readPacket(t, l), "queue.size:2|g|#Env:prod"; got != want {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package statsd
+
+import (
+	"fmt"
+	"time"
+)
+
+// SampleWindow is a time of day range with its own sample rate. Start and End
+// are wall-clock times of day (e.g. 9*time.Hour for 09:00, also on DST days),
+// End is excluded. A window with Start after End wraps around midnight.
+type SampleWindow struct {
+	Start time.Duration
+	End   time.Duration
+	Rate  float32
+}
+
+// SampleSchedule select the global sample rate by time of day, e.g. full rate
+// during business hours and 10% overnight
+type SampleSchedule struct {
+	// Location is the timezone the windows are expressed in, time.Local if nil
+	Location *time.Location
+	// Windows are checked in order, the first one containing the time wins
+	Windows []SampleWindow
+}
+
+// Validate check the rates of the windows
+func (s *SampleSchedule) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for i, w := range s.Windows {
+		if err := checkSampleRate(w.Rate); err != nil {
+			return fmt.Errorf("sample window %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Rate return the sample rate at t, or fallback when no window contains t.
+// The windows with an invalid rate are ignored, see Validate.
+func (s *SampleSchedule) Rate(t time.Time, fallback float32) float32 {
+	if s == nil || len(s.Windows) == 0 {
+		return fallback
+	}
+
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	// the time of day on the clock, not the time elapsed since midnight
+	// which differs by an hour on DST days
+	t = t.In(loc)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+
+	for _, w := range s.Windows {
+		if checkSampleRate(w.Rate) == nil && w.contains(offset) {
+			return w.Rate
+		}
+	}
+	return fallback
+}
+
+func (w SampleWindow) contains(offset time.Duration) bool {
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+
+	// wraps around midnight
+	return offset >= w.Start || offset < w.End
+}
+
+// WithSampleSchedule override the sample rate of the methods without explicit
+// sampling by time of day
+func WithSampleSchedule(s *SampleSchedule) Option {
+	return func(c *Client) {
+		c.schedule = s
+	}
+}
@@ -0,0 +1,107 @@
+package statsd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_SampleSchedule(t *testing.T) {
+	ams, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	schedule := &SampleSchedule{
+		Location: ams,
+		Windows: []SampleWindow{
+			{Start: 9 * time.Hour, End: 18 * time.Hour, Rate: 1},
+			{Start: 22 * time.Hour, End: 6 * time.Hour, Rate: 0.1},
+		},
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want float32
+	}{
+		{
+			name: "business-hours",
+			t:    time.Date(2024, 3, 1, 10, 30, 0, 0, ams),
+			want: 1,
+		},
+		{
+			name: "business-hours-other-timezone",
+			t:    time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC), // 10:30 in Amsterdam
+			want: 1,
+		},
+		{
+			name: "end-excluded",
+			t:    time.Date(2024, 3, 1, 18, 0, 0, 0, ams),
+			want: 0.5,
+		},
+		{
+			name: "overnight-before-midnight",
+			t:    time.Date(2024, 3, 1, 23, 0, 0, 0, ams),
+			want: 0.1,
+		},
+		{
+			name: "overnight-after-midnight",
+			t:    time.Date(2024, 3, 2, 3, 0, 0, 0, ams),
+			want: 0.1,
+		},
+		{
+			name: "dst-start-day",
+			t:    time.Date(2024, 3, 31, 9, 30, 0, 0, ams),
+			want: 1,
+		},
+		{
+			name: "dst-end-day",
+			t:    time.Date(2024, 10, 27, 17, 30, 0, 0, ams),
+			want: 1,
+		},
+		{
+			name: "no-window",
+			t:    time.Date(2024, 3, 2, 7, 0, 0, 0, ams),
+			want: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.Rate(tt.t, 0.5); got != tt.want {
+				t.Fatalf("[%s] got: %v <=> want: %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	var none *SampleSchedule
+	if got := none.Rate(time.Now(), 0.5); got != 0.5 {
+		t.Fatalf("nil schedule got: %v <=> want: 0.5", got)
+	}
+}
+
+func Test_SampleScheduleValidate(t *testing.T) {
+	schedule := &SampleSchedule{
+		Location: time.UTC,
+		Windows: []SampleWindow{
+			{Start: 0, End: 12 * time.Hour, Rate: 2},
+			{Start: 0, End: 24 * time.Hour, Rate: 0.2},
+		},
+	}
+
+	if err := schedule.Validate(); !errors.Is(err, ErrInvalidSampleRate) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidSampleRate)
+	}
+	// the invalid window is skipped
+	if got := schedule.Rate(time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), 0.5); got != 0.2 {
+		t.Fatalf("got: %v <=> want: 0.2", got)
+	}
+}
+
+func Test_WithSampleSchedule(t *testing.T) {
+	invalid := &SampleSchedule{Windows: []SampleWindow{{Start: 0, End: time.Hour, Rate: -1}}}
+	if _, err := New("127.0.0.1:8125", WithSampleSchedule(invalid)); !errors.Is(err, ErrInvalidSampleRate) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidSampleRate)
+	}
+}
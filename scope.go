@@ -0,0 +1,137 @@
+package statsd
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Scope is a view of a client in the style of uber-go/tally: a prefix made
+// of the sub-scopes and tags applied to the instruments it returns, e.g.
+//
+//	scope := client.Scope().Tagged(map[string]string{"env": "prod"})
+//	scope.SubScope("db").Counter("errors").Inc()
+//
+// The sub-scopes, the tagged scopes and the instruments are created once per
+// prefix and tags and cached by the root scope, so that getting them in the
+// hot paths is cheap and registers nothing new. A Scope is safe for
+// concurrent use.
+type Scope struct {
+	c      *Client
+	tags   map[string]string
+	sorted []string // tags as "name:value" sorted by name
+	reg    *scopeRegistry
+}
+
+// scopeRegistry is the cache shared by a root scope and its descendants
+type scopeRegistry struct {
+	m        sync.RWMutex
+	scopes   map[string]*Scope // by prefix and tags, see scopeKey
+	counters map[string]*Counter
+	gauges   map[string]*GaugeInstrument
+	timers   map[string]*Timer // the instruments by bucket and tags
+}
+
+// Scope return a root scope of c, with the prefix of c and no tag. Each call
+// returns a new root with its own cache, keep it for the life of c.
+func (c *Client) Scope() *Scope {
+	reg := &scopeRegistry{
+		scopes:   make(map[string]*Scope),
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*GaugeInstrument),
+		timers:   make(map[string]*Timer),
+	}
+	s := &Scope{c: c, reg: reg}
+	reg.scopes[scopeKey(c.prefix, nil)] = s
+	return s
+}
+
+// scopeKey is the key of the scope of prefix and the sorted tags
+func scopeKey(prefix string, sorted []string) string {
+	return prefix + "|" + strings.Join(sorted, ",")
+}
+
+// cached return the value of key in m, created by create under the write
+// lock of the registry if missing
+func cached[T any](reg *scopeRegistry, m map[string]T, key string, create func() T) T {
+	reg.m.RLock()
+	v, ok := m[key]
+	reg.m.RUnlock()
+	if ok {
+		return v
+	}
+
+	reg.m.Lock()
+	defer reg.m.Unlock()
+	if v, ok := m[key]; ok {
+		return v // created meanwhile
+	}
+	v = create()
+	m[key] = v
+	return v
+}
+
+// SubScope return the scope whose names are prefixed with name
+func (s *Scope) SubScope(name string) *Scope {
+	key := scopeKey(joinPrefix(s.c.prefix, strings.Trim(name, ".")), s.sorted)
+	return cached(s.reg, s.reg.scopes, key, func() *Scope {
+		return &Scope{c: s.c.WithPrefix(name), tags: s.tags, sorted: s.sorted, reg: s.reg}
+	})
+}
+
+// Tagged return the scope with the given tags on top of the tags of s, the
+// values given replacing the values of the same tag names
+func (s *Scope) Tagged(tags map[string]string) *Scope {
+	merged := make(map[string]string, len(s.tags)+len(tags))
+	for k, v := range s.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	sorted := sortTags(merged)
+	return cached(s.reg, s.reg.scopes, scopeKey(s.c.prefix, sorted), func() *Scope {
+		return &Scope{c: s.c, tags: merged, sorted: sorted, reg: s.reg}
+	})
+}
+
+// sortTags return the tags as "name:value" sorted by name
+func sortTags(tags map[string]string) []string {
+	sorted := make([]string, 0, len(tags))
+	for k, v := range tags {
+		sorted = append(sorted, k+":"+v)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Tags return the tags of the scope, as "name:value" sorted by name
+func (s *Scope) Tags() []string {
+	return append([]string{}, s.sorted...)
+}
+
+// Prefix return the prefix of the names of the scope
+func (s *Scope) Prefix() string {
+	return strings.TrimSuffix(s.c.bucket(""), ".")
+}
+
+// Counter return the counter name of the scope
+func (s *Scope) Counter(name string) *Counter {
+	return cached(s.reg, s.reg.counters, scopeKey(s.c.bucket(name), s.sorted), func() *Counter {
+		return s.c.NewCounter(name, s.sorted...)
+	})
+}
+
+// Gauge return the gauge name of the scope
+func (s *Scope) Gauge(name string) *GaugeInstrument {
+	return cached(s.reg, s.reg.gauges, scopeKey(s.c.bucket(name), s.sorted), func() *GaugeInstrument {
+		return s.c.NewGauge(name, s.sorted...)
+	})
+}
+
+// Timer return the timer name of the scope
+func (s *Scope) Timer(name string) *Timer {
+	return cached(s.reg, s.reg.timers, scopeKey(s.c.bucket(name), s.sorted), func() *Timer {
+		return s.c.NewTimer(name, s.sorted...)
+	})
+}
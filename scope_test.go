@@ -0,0 +1,69 @@
+package statsd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Scope(t *testing.T) {
+	c, l := newTestClient(t, "api")
+
+	root := c.Scope().Tagged(map[string]string{"env": "prod", "region": "eu"})
+	db := root.SubScope("db").Tagged(map[string]string{"region": "us"})
+	if got, want := db.Tags(), []string{"env:prod", "region:us"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tags: %v <=> want: %v", got, want)
+	}
+	if got := db.Prefix(); got != "api.db" {
+		t.Fatalf("prefix: %s <=> want: api.db", got)
+	}
+	if db.Counter("errors") != db.Counter("errors") {
+		t.Fatal("the counter is not cached")
+	}
+
+	db.Counter("errors").Inc()
+	c.flush()
+	if got := readPacket(t, l); got != "api.db.errors:1|c|#env:prod,region:us" {
+		t.Fatalf("got: %s", got)
+	}
+
+	root.Gauge("queue").Set(3)
+	if got := readPacket(t, l); got != "api.queue:3|g|#env:prod,region:eu" {
+		t.Fatalf("got: %s", got)
+	}
+	root.SubScope("http").Timer("latency").Observe(5 * time.Millisecond)
+	if got := readPacket(t, l); got != "api.http.latency:5|ms|#env:prod,region:eu" {
+		t.Fatalf("got: %s", got)
+	}
+}
+
+func Test_ScopeCache(t *testing.T) {
+	c, _ := newTestClient(t, "api")
+	root := c.Scope()
+
+	// the hot path usage registers the counter once
+	for i := 0; i < 100; i++ {
+		root.SubScope("db").Counter("errors").Inc()
+		root.Tagged(map[string]string{"env": "prod"}).Timer("latency")
+	}
+	c.m.Lock()
+	n := len(c.counters)
+	c.m.Unlock()
+	if n != 1 {
+		t.Fatalf("counters: %d <=> want: 1", n)
+	}
+
+	if root.SubScope("db") != root.SubScope("db") {
+		t.Fatal("the sub-scope is not cached")
+	}
+	env := root.Tagged(map[string]string{"env": "prod"})
+	if env != root.Tagged(map[string]string{"env": "prod"}) || env != env.Tagged(nil) {
+		t.Fatal("the tagged scope is not cached")
+	}
+	if root.SubScope("db").Counter("errors") != root.Counter("db.errors") {
+		t.Fatal("the instruments of a bucket are not shared")
+	}
+	if env.Counter("errors") == root.Counter("errors") {
+		t.Fatal("the instruments of different tags are shared")
+	}
+}
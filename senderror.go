@@ -0,0 +1,32 @@
+package statsd
+
+import "fmt"
+
+// SendError is the error of a metric call, naming the metric which failed:
+// the method called, the metric name and value of the call. Cause is one of
+// the Err* of the package (errors.Is(err, ErrClosed) still holds) or the
+// error of the write.
+type SendError struct {
+	Op    string // the method, e.g. "Incr" for Incr and IncrWithSampling
+	Stat  string
+	Value interface{}
+	Cause error
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("statsd: %s %s %v: %v", e.Op, e.Stat, e.Value, e.Cause)
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Cause
+}
+
+// newSendError wrap err, unless already wrapped by the method a metric call went
+// through first. It is only called on a failure, so that the value isn't
+// boxed on the success path.
+func newSendError(err error, op string, stat string, value interface{}) error {
+	if _, ok := err.(*SendError); ok {
+		return err
+	}
+	return &SendError{Op: op, Stat: stat, Value: value, Cause: err}
+}
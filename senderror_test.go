@@ -0,0 +1,33 @@
+package statsd
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_SendError(t *testing.T) {
+	c, _ := newTestClient(t, "api")
+
+	err := c.IncrWithSampling("hits", -1, 1)
+	var se *SendError
+	if !errors.As(err, &se) {
+		t.Fatalf("err: %#v <=> want a *SendError", err)
+	}
+	want := SendError{Op: "Incr", Stat: "hits", Value: int64(-1), Cause: ErrInvalidCount}
+	if *se != want {
+		t.Fatalf("err: %+v <=> want: %+v", *se, want)
+	}
+	if !errors.Is(err, ErrInvalidCount) {
+		t.Fatal("cause not unwrapped")
+	}
+	if got := err.Error(); got != "statsd: Incr hits -1: count is less than 0" {
+		t.Fatalf("message: %s", got)
+	}
+
+	// wrapped once, by Timing which TimingDuration calls
+	c.floatTimings = false
+	err = c.TimingDurationWithSampling("db", 0, 2)
+	if !errors.As(err, &se) || se.Op != "Timing" || se.Cause != ErrInvalidSampleRate {
+		t.Fatalf("err: %+v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package server receive statsd metrics, from datagrams with Serve or from
+// stream connections with ServeStream, the building block of the statsdtest
+// fake server and of the relays and aggregators built on this module
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sunlit-coder/statsd/proto"
+)
+
+// maxPacketSize is the largest datagram read
+const maxPacketSize = 64 * 1024
+
+// Server read the statsd packets of a connection, or the lines of stream
+// connections, and hand each line to its handlers, see Serve and ServeStream
+type Server struct {
+	// Handler is called for every well formed line, in order
+	Handler func(packet []proto.Metric)
+	// Malformed is called with the lines ParseLine refused, if set
+	Malformed func(lines []string)
+
+	m sync.Mutex // serialize the handlers of the stream connections
+}
+
+// Serve read the packets of conn until it is closed, then return nil. The
+// handlers are called once per packet, from the calling goroutine.
+func (s *Server) Serve(conn net.PacketConn) error {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		metrics, malformed := ParsePacket(string(buf[:n]))
+		if s.Handler != nil && len(metrics) > 0 {
+			s.Handler(metrics)
+		}
+		if s.Malformed != nil && len(malformed) > 0 {
+			s.Malformed(malformed)
+		}
+	}
+}
+
+// ServeStream accept the connections of a stream network ("tcp", "unix")
+// until l is closed, then close them and return nil. The lines end with a
+// newline and may be prefix compressed, see proto.PrefixDecoder. The
+// handlers are called once per line, one call at a time whatever the number
+// of connections.
+func (s *Server) ServeStream(l net.Listener) error {
+	var (
+		wg    sync.WaitGroup
+		m     sync.Mutex
+		conns = make(map[net.Conn]bool)
+	)
+	defer func() {
+		m.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		m.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		m.Lock()
+		conns[conn] = true
+		m.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(conn)
+			m.Lock()
+			delete(conns, conn)
+			m.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// serveConn read the lines of a stream connection until it is closed
+func (s *Server) serveConn(conn net.Conn) {
+	var d proto.PrefixDecoder
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxPacketSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		var (
+			metrics   []proto.Metric
+			malformed []string
+		)
+		if expanded, err := d.Decode(line); err != nil {
+			malformed = []string{line}
+		} else {
+			metrics, malformed = ParsePacket(expanded)
+		}
+
+		s.m.Lock()
+		if s.Handler != nil && len(metrics) > 0 {
+			s.Handler(metrics)
+		}
+		if s.Malformed != nil && len(malformed) > 0 {
+			s.Malformed(malformed)
+		}
+		s.m.Unlock()
+	}
+}
+
+// ParsePacket split a packet into its lines and parse them, returning the
+// lines refused apart
+func ParsePacket(packet string) (metrics []proto.Metric, malformed []string) {
+	for _, line := range strings.Split(packet, "\n") {
+		if m, err := proto.ParseLine(line); err == nil {
+			metrics = append(metrics, m)
+		} else {
+			malformed = append(malformed, line)
+		}
+	}
+	return metrics, malformed
+}
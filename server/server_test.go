@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/sunlit-coder/statsd/proto"
+)
+
+func Test_ParsePacket(t *testing.T) {
+	metrics, malformed := ParsePacket("a:1|c\nbad\nb:2|ms|#env:prod")
+	want := []proto.Metric{
+		{Name: "a", Type: "c", Value: 1, SampleRate: 1},
+		{Name: "b", Type: "ms", Value: 2, SampleRate: 1, Tags: []string{"env:prod"}},
+	}
+	if !reflect.DeepEqual(metrics, want) {
+		t.Fatalf("metrics: %v <=> want: %v", metrics, want)
+	}
+	if !reflect.DeepEqual(malformed, []string{"bad"}) {
+		t.Fatalf("malformed: %q <=> want: [bad]", malformed)
+	}
+}
+
+func Test_Serve(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan []proto.Metric, 1)
+	done := make(chan error)
+	s := Server{Handler: func(metrics []proto.Metric) { got <- metrics }}
+	go func() { done <- s.Serve(conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Write([]byte("a:1|c"))
+
+	if metrics := <-got; len(metrics) != 1 || metrics[0].Name != "a" {
+		t.Fatalf("metrics: %v", metrics)
+	}
+	conn.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("err: %v <=> want: nil", err)
+	}
+}
+
+func Test_ServeStream(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan []proto.Metric, 4)
+	malformed := make(chan []string, 1)
+	done := make(chan error)
+	s := Server{
+		Handler:   func(metrics []proto.Metric) { got <- metrics },
+		Malformed: func(lines []string) { malformed <- lines },
+	}
+	go func() { done <- s.ServeStream(l) }()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Write([]byte("api.checkout.pay:1|c|#env:prod|T1700000000\n^13^cart:2|c\n^99^x\n"))
+
+	want := []proto.Metric{
+		{Name: "api.checkout.pay", Type: "c", Value: 1, SampleRate: 1, Tags: []string{"env:prod"}, Timestamp: 1700000000},
+		{Name: "api.checkout.cart", Type: "c", Value: 2, SampleRate: 1},
+	}
+	for _, w := range want {
+		if metrics := <-got; !reflect.DeepEqual(metrics, []proto.Metric{w}) {
+			t.Fatalf("metrics: %v <=> want: %v", metrics, w)
+		}
+	}
+	if lines := <-malformed; !reflect.DeepEqual(lines, []string{"^99^x"}) {
+		t.Fatalf("malformed: %q <=> want: [^99^x]", lines)
+	}
+
+	// closing the listener closes the connections still open
+	l.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("err: %v <=> want: nil", err)
+	}
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Fatal("connection still open")
+	}
+}
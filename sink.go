@@ -0,0 +1,60 @@
+package statsd
+
+import (
+	"io"
+	"sync"
+)
+
+// Sink is the wire layer of a client: it receives the packets, several lines
+// separated by a newline without a trailing one. Write is called concurrently
+// and must not retain the packet once it returns.
+type Sink interface {
+	Write(packet []byte) error
+	Close() error
+}
+
+// WithSink write the packets to s in place of a connection to addr, which is
+// then ignored with the network, e.g. to a log file, a Kafka producer or a
+// custom transport. Closing the client closes s.
+func WithSink(s Sink) Option {
+	return func(c *Client) {
+		c.sink = s
+	}
+}
+
+// WriterSink return a Sink writing each packet followed by a newline to w,
+// one packet at a time. Closing it closes w if it is an io.Closer.
+func WriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+type writerSink struct {
+	m   sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+func (s *writerSink) Write(packet []byte) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.buf = append(append(s.buf[:0], packet...), '\n')
+	_, err := s.w.Write(s.buf)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// writeSink write a packet to the sink of the client
+func (c *clientConn) writeSink(packet []byte) error {
+	err := c.sink.Write(packet)
+	if err != nil {
+		c.addDeadLetter(string(packet), err)
+	}
+	return err
+}
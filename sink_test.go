@@ -0,0 +1,66 @@
+package statsd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// closeBuffer is a bytes.Buffer knowing if it was closed
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+// failSink fail every write
+type failSink struct{}
+
+func (failSink) Write([]byte) error { return errFailSink }
+func (failSink) Close() error       { return nil }
+
+var errFailSink = errors.New("sink down")
+
+func Test_WithSink(t *testing.T) {
+	buf := &closeBuffer{}
+	c, err := New("", WithPrefix("shop"), WithSink(WriterSink(buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Timing("db", 3)
+	c.sendLines([]string{c.format("a", 1, "g", 1), c.format("b", 2, "g", 1)})
+	c.Incr("orders", 1)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "shop.db:3|ms\n" +
+		"shop.a:1|g\nshop.b:2|g\n" +
+		"shop.orders:1|c\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if !buf.closed {
+		t.Fatal("writer not closed with the client")
+	}
+}
+
+func Test_WithSinkError(t *testing.T) {
+	c, err := New("", WithSink(failSink{}), WithDeadLetters(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Timing("db", 3); !errors.Is(err, errFailSink) {
+		t.Fatalf("err: %v <=> want: %v", err, errFailSink)
+	}
+	if letters := c.DeadLetters(); len(letters) != 1 || letters[0].Line != "db:3|ms" {
+		t.Fatalf("dead letters: %v", letters)
+	}
+}
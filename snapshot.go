@@ -0,0 +1,175 @@
+package statsd
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Snapshot is the state of the buffers of a client, what its next flush would
+// send: the counters since the last flush and, with WithAggregation, the
+// gauges and timer samples of the window. The tags include the client tags.
+type Snapshot struct {
+	Counters []SnapshotValue
+	Gauges   []SnapshotValue
+	Timers   []SnapshotTimer
+}
+
+// SnapshotValue is a counter or a gauge of a Snapshot
+type SnapshotValue struct {
+	Name  string // prefixed bucket
+	Tags  []string
+	Value float64
+}
+
+// SnapshotTimer is the samples of a timer of a Snapshot, in milliseconds
+type SnapshotTimer struct {
+	Name    string
+	Tags    []string
+	Samples []int64
+}
+
+// Snapshot return the current state of the buffers without flushing them
+func (c *Client) Snapshot() Snapshot {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.drainCounters()
+	var s Snapshot
+	for _, b := range c.buffer {
+		s.Counters = append(s.Counters, SnapshotValue{b.name, c.snapshotTags(b.tags), b.count})
+	}
+	for _, g := range c.gauges {
+		s.Gauges = append(s.Gauges, SnapshotValue{g.name, c.snapshotTags(g.tags), g.float()})
+	}
+	for _, t := range c.timers {
+		s.Timers = append(s.Timers, SnapshotTimer{t.name, c.snapshotTags(t.tags), append([]int64(nil), t.samples...)})
+	}
+	return s
+}
+
+// snapshotTags return the client tags followed by the joined call tags
+func (c *clientConn) snapshotTags(joined string) []string {
+	tags := append([]string(nil), c.tags...)
+	if joined != "" {
+		tags = append(tags, strings.Split(joined, ",")...)
+	}
+	return tags
+}
+
+// snapshotQuantiles are the quantiles of the timer summaries
+var snapshotQuantiles = []float64{0.5, 0.95, 0.99}
+
+// WriteOpenMetrics write the snapshot in the OpenMetrics text format, for
+// the scrapers or the humans debugging the client: the counters as counters
+// reset at each flush, the gauges as gauges, the timers as summaries in
+// milliseconds. The names and the label names of the "key:value" tags are
+// sanitized, a tag without value becomes a label with an empty value.
+func (s Snapshot) WriteOpenMetrics(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	counters := sortedValues(s.Counters)
+	for i, v := range counters {
+		name := openMetricsName(v.Name)
+		if i == 0 || openMetricsName(counters[i-1].Name) != name {
+			bw.WriteString("# TYPE " + name + " counter\n")
+		}
+		writeSample(bw, name+"_total", v.Tags, "", v.Value)
+	}
+
+	gauges := sortedValues(s.Gauges)
+	for i, v := range gauges {
+		name := openMetricsName(v.Name)
+		if i == 0 || openMetricsName(gauges[i-1].Name) != name {
+			bw.WriteString("# TYPE " + name + " gauge\n")
+		}
+		writeSample(bw, name, v.Tags, "", v.Value)
+	}
+
+	timers := append([]SnapshotTimer(nil), s.Timers...)
+	sort.SliceStable(timers, func(i, j int) bool { return timers[i].Name < timers[j].Name })
+	for i, t := range timers {
+		name := openMetricsName(t.Name)
+		if i == 0 || openMetricsName(timers[i-1].Name) != name {
+			bw.WriteString("# TYPE " + name + " summary\n# UNIT " + name + " milliseconds\n")
+		}
+
+		samples := append([]int64(nil), t.Samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		var sum int64
+		for _, sample := range samples {
+			sum += sample
+		}
+		if len(samples) > 0 {
+			for _, q := range snapshotQuantiles {
+				writeSample(bw, name, t.Tags, strconv.FormatFloat(q, 'f', -1, 64), float64(percentile(samples, q)))
+			}
+		}
+		writeSample(bw, name+"_count", t.Tags, "", float64(len(samples)))
+		writeSample(bw, name+"_sum", t.Tags, "", float64(sum))
+	}
+
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+func sortedValues(values []SnapshotValue) []SnapshotValue {
+	values = append([]SnapshotValue(nil), values...)
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+	return values
+}
+
+// writeSample write a line "<name>{<labels>} <value>", quantile is added to
+// the labels unless empty
+func writeSample(w *bufio.Writer, name string, tags []string, quantile string, value float64) {
+	w.WriteString(name)
+
+	labels := make([]string, 0, len(tags)+1)
+	for _, tag := range tags {
+		key, val, _ := strings.Cut(tag, ":")
+		labels = append(labels, openMetricsLabel(key)+`="`+escapeLabel(val)+`"`)
+	}
+	if quantile != "" {
+		labels = append(labels, `quantile="`+quantile+`"`)
+	}
+	if len(labels) > 0 {
+		w.WriteString("{" + strings.Join(labels, ",") + "}")
+	}
+
+	w.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+// openMetricsName replace the characters out of [a-zA-Z0-9_:] by '_'
+func openMetricsName(name string) string {
+	return sanitizeOpenMetrics(name, true)
+}
+
+// openMetricsLabel replace the characters out of [a-zA-Z0-9_] by '_'
+func openMetricsLabel(name string) string {
+	return sanitizeOpenMetrics(name, false)
+}
+
+func sanitizeOpenMetrics(name string, colon bool) string {
+	b := []byte(name)
+	for i, ch := range b {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch == '_':
+		case ch >= '0' && ch <= '9' && i > 0:
+		case ch == ':' && colon:
+		default:
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
@@ -0,0 +1,71 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_SnapshotWriteOpenMetrics(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithTags("env:prod"), WithAggregation(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Incr("http.hits", 1, "route:/a")
+	c.Incr("http.hits", 1, "route:/b")
+	c.Gauge("queue", 3)
+	c.FGauge("temp", 21.5, "room:\"a\"")
+	for i := int64(1); i <= 4; i++ {
+		c.Timing("db", i)
+	}
+
+	var b strings.Builder
+	if err := c.Snapshot().WriteOpenMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# TYPE api_http_hits counter
+api_http_hits_total{env="prod",route="/a"} 1
+api_http_hits_total{env="prod",route="/b"} 1
+# TYPE api_queue gauge
+api_queue{env="prod"} 3
+# TYPE api_temp gauge
+api_temp{env="prod",room="\"a\""} 21.5
+# TYPE api_db summary
+# UNIT api_db milliseconds
+api_db{env="prod",quantile="0.5"} 2
+api_db{env="prod",quantile="0.95"} 4
+api_db{env="prod",quantile="0.99"} 4
+api_db_count{env="prod"} 4
+api_db_sum{env="prod"} 10
+# EOF
+`
+	if got := b.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// the snapshot doesn't flush
+	if s := c.Snapshot(); len(s.Counters) != 2 || len(s.Timers[0].Samples) != 4 {
+		t.Fatalf("buffers flushed by the snapshot: %+v", s)
+	}
+}
+
+func Test_openMetricsName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"api.http-hits", "api_http_hits"},
+		{"ns:sub", "ns:sub"},
+		{"9lives", "_lives"},
+		{"", "_"},
+	}
+
+	for _, tt := range tests {
+		if got := openMetricsName(tt.name); got != tt.want {
+			t.Errorf("%q: %q <=> want: %q", tt.name, got, tt.want)
+		}
+	}
+}
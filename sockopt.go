@@ -0,0 +1,70 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// errUnsupportedSocket is returned by SendBufferSize for the connections
+// without a socket option
+var errUnsupportedSocket = fmt.Errorf("statsd: socket options: %w", errors.ErrUnsupported)
+
+// SocketOptions configure the sockets of the connections of a client, see
+// WithSocketOptions
+type SocketOptions struct {
+	// SendBuffer is the size of the send buffer (SO_SNDBUF) in bytes, the
+	// system default if 0. Raise it when the kernel drops packets at high
+	// rates, see Client.SendBufferSize for the size in effect.
+	SendBuffer int
+
+	// Control is called with the raw socket of each connection before it
+	// connects, as net.Dialer.Control, to set the other options. It is
+	// ignored with WithDialer.
+	Control func(network, address string, c syscall.RawConn) error
+}
+
+// WithSocketOptions apply o to each connection of the client, it is ignored
+// with WithSink
+func WithSocketOptions(o SocketOptions) Option {
+	return func(c *Client) {
+		c.socketOptions = o
+	}
+}
+
+// setSendBuffer apply SocketOptions.SendBuffer to a new connection
+func (c *clientConn) setSendBuffer(conn net.Conn) error {
+	if c.socketOptions.SendBuffer == 0 {
+		return nil
+	}
+	if b, ok := conn.(interface{ SetWriteBuffer(bytes int) error }); ok {
+		return b.SetWriteBuffer(c.socketOptions.SendBuffer)
+	}
+	return nil
+}
+
+// SendBufferSize return the size in effect of the send buffer of the
+// connection of c, e.g. twice SocketOptions.SendBuffer on Linux which
+// doubles it for its bookkeeping. It returns ErrNotConnected while
+// disconnected, and errors.ErrUnsupported with WithSink or on the systems
+// without SO_SNDBUF.
+func (c *Client) SendBufferSize() (int, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.sink != nil {
+		return 0, errUnsupportedSocket
+	}
+	if c.conn == nil {
+		return 0, ErrNotConnected
+	}
+	sc, ok := c.conn.(syscall.Conn)
+	if !ok {
+		return 0, errUnsupportedSocket // e.g. a TLS connection
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	return sendBufferSize(raw)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package statsd
+
+import "syscall"
+
+func sendBufferSize(raw syscall.RawConn) (int, error) {
+	return 0, errUnsupportedSocket
+}
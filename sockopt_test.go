@@ -0,0 +1,43 @@
+package statsd
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+func Test_WithSocketOptions(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var controlled bool
+	c, err := New(l.LocalAddr().String(), WithSocketOptions(SocketOptions{
+		SendBuffer: 64 << 10,
+		Control: func(network, address string, raw syscall.RawConn) error {
+			controlled = true
+			return nil
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if !controlled {
+		t.Fatal("Control not called")
+	}
+
+	size, err := c.SendBufferSize()
+	if err != nil {
+		t.Skip(err) // e.g. no SO_SNDBUF
+	}
+	if size < 64<<10 {
+		t.Fatalf("send buffer: %d <=> want: >= %d", size, 64<<10)
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithSocketOptions(SocketOptions{SendBuffer: -1})); err != ErrInvalidSendBuffer {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidSendBuffer)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package statsd
+
+import "syscall"
+
+// sendBufferSize read SO_SNDBUF of a socket
+func sendBufferSize(raw syscall.RawConn) (int, error) {
+	var size int
+	var serr error
+	err := raw.Control(func(fd uintptr) {
+		size, serr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_SNDBUF)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, serr
+}
@@ -0,0 +1,353 @@
+// Package sqlstats instrument database/sql drivers and pools with statsd metrics
+package sqlstats
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// ErrNamedParameters is returned for named arguments to a statement of a driver
+// without context support, which can't receive their names
+var ErrNamedParameters = errors.New("sqlstats: driver does not support the use of Named Parameters")
+
+// the errors of BeginTx for the options a driver without driver.ConnBeginTx
+// can't apply, as database/sql returns them
+var (
+	ErrIsolationLevel = errors.New("sqlstats: driver does not support non-default isolation level")
+	ErrReadOnly       = errors.New("sqlstats: driver does not support read-only transactions")
+)
+
+// buckets of the driver metrics, tagged with "op:<operation>"
+// (prepare, exec, query, begin, commit, rollback) and the tags given to Wrap
+const (
+	statLatency = "sql.latency" // timer
+	statErrors  = "sql.errors"  // counter
+)
+
+// Wrap return a driver.Driver timing the operations of d and counting their
+// errors, register it with sql.Register to use it:
+//
+//	sql.Register("postgres-stats", sqlstats.Wrap(&pq.Driver{}, client, "db:orders"))
+//
+// A nil client is statsd.DefaultStatter.
+func Wrap(d driver.Driver, client statsd.Statter, tags ...string) driver.Driver {
+	return &wrapDriver{parent: d, rec: newRecorder(client, tags)}
+}
+
+// WrapConnector return a driver.Connector timing the operations of the
+// connections of c as Wrap does, for sql.OpenDB:
+//
+//	db := sql.OpenDB(sqlstats.WrapConnector(connector, client, "db:orders"))
+func WrapConnector(c driver.Connector, client statsd.Statter, tags ...string) driver.Connector {
+	rec := newRecorder(client, tags)
+	return &wrapConnector{parent: c, driver: &wrapDriver{parent: c.Driver(), rec: rec}}
+}
+
+type recorder struct {
+	client statsd.Statter
+	tags   []string
+}
+
+// newRecorder return a recorder sending to client, statsd.DefaultStatter if nil
+func newRecorder(client statsd.Statter, tags []string) *recorder {
+	if client == nil {
+		client = statsd.DefaultStatter()
+	}
+	return &recorder{client: client, tags: tags}
+}
+
+// record time an operation started at start, and count it if it failed
+func (r *recorder) record(op string, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return // not an operation, database/sql falls back to another one
+	}
+
+	tags := append([]string{"op:" + op}, r.tags...)
+	r.client.Timing(statLatency, int64(time.Since(start)/time.Millisecond), tags...)
+	if err != nil && err != driver.ErrBadConn {
+		r.client.Incr(statErrors, 1, tags...)
+	}
+}
+
+type wrapDriver struct {
+	parent driver.Driver
+	rec    *recorder
+}
+
+func (d *wrapDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapConn{parent: conn, rec: d.rec}, nil
+}
+
+// OpenConnector forward the connector of a driver.DriverContext, database/sql
+// uses it in place of Open
+func (d *wrapDriver) OpenConnector(name string) (driver.Connector, error) {
+	dc, ok := d.parent.(driver.DriverContext)
+	if !ok {
+		return &dsnConnector{name: name, driver: d}, nil
+	}
+	c, err := dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapConnector{parent: c, driver: d}, nil
+}
+
+// dsnConnector is the connector of a driver without driver.DriverContext
+type dsnConnector struct {
+	name   string
+	driver *wrapDriver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type wrapConnector struct {
+	parent driver.Connector
+	driver *wrapDriver
+}
+
+func (c *wrapConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapConn{parent: conn, rec: c.driver.rec}, nil
+}
+
+func (c *wrapConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close forward the closing of the connector, called by sql.DB.Close
+func (c *wrapConnector) Close() error {
+	if cl, ok := c.parent.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+type wrapConn struct {
+	parent driver.Conn
+	rec    *recorder
+}
+
+func (c *wrapConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *wrapConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	start := time.Now()
+	defer func() { c.rec.record("prepare", start, err) }()
+
+	if p, ok := c.parent.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.parent.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wrapStmt{parent: stmt, conn: c.parent, rec: c.rec}, nil
+}
+
+func (c *wrapConn) Close() error {
+	return c.parent.Close()
+}
+
+func (c *wrapConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *wrapConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	start := time.Now()
+	defer func() { c.rec.record("begin", start, err) }()
+
+	if b, ok := c.parent.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		switch {
+		case opts.Isolation != driver.IsolationLevel(0):
+			return nil, ErrIsolationLevel
+		case opts.ReadOnly:
+			return nil, ErrReadOnly
+		}
+		tx, err = c.parent.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wrapTx{parent: tx, rec: c.rec}, nil
+}
+
+func (c *wrapConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (res driver.Result, err error) {
+	e, ok := c.parent.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	defer func() { c.rec.record("exec", start, err) }()
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *wrapConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	q, ok := c.parent.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	defer func() { c.rec.record("query", start, err) }()
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *wrapConn) Ping(ctx context.Context) error {
+	if p, ok := c.parent.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *wrapConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.parent.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *wrapConn) IsValid() bool {
+	if v, ok := c.parent.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue forward the argument conversion of the driver,
+// database/sql uses its default conversion on driver.ErrSkip
+func (c *wrapConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.parent.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type wrapStmt struct {
+	parent driver.Stmt
+	conn   driver.Conn // parent connection, for its argument conversion
+	rec    *recorder
+}
+
+// CheckNamedValue forward the argument conversion of the statement or else of
+// its connection. On driver.ErrSkip database/sql falls back to ColumnConverter.
+func (s *wrapStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.parent.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	if n, ok := s.conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ColumnConverter forward the converters of the statement, the default
+// conversion is used if it has none
+func (s *wrapStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if c, ok := s.parent.(driver.ColumnConverter); ok {
+		return c.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func (s *wrapStmt) Close() error {
+	return s.parent.Close()
+}
+
+func (s *wrapStmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+func (s *wrapStmt) Exec(args []driver.Value) (res driver.Result, err error) {
+	start := time.Now()
+	defer func() { s.rec.record("exec", start, err) }()
+	return s.parent.Exec(args)
+}
+
+func (s *wrapStmt) Query(args []driver.Value) (rows driver.Rows, err error) {
+	start := time.Now()
+	defer func() { s.rec.record("query", start, err) }()
+	return s.parent.Query(args)
+}
+
+func (s *wrapStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res driver.Result, err error) {
+	e, ok := s.parent.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+
+	start := time.Now()
+	defer func() { s.rec.record("exec", start, err) }()
+	return e.ExecContext(ctx, args)
+}
+
+func (s *wrapStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	q, ok := s.parent.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+
+	start := time.Now()
+	defer func() { s.rec.record("query", start, err) }()
+	return q.QueryContext(ctx, args)
+}
+
+// namedValues convert the arguments for a statement without context support,
+// which doesn't know about names
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, ErrNamedParameters
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+type wrapTx struct {
+	parent driver.Tx
+	rec    *recorder
+}
+
+func (t *wrapTx) Commit() (err error) {
+	start := time.Now()
+	defer func() { t.rec.record("commit", start, err) }()
+	return t.parent.Commit()
+}
+
+func (t *wrapTx) Rollback() (err error) {
+	start := time.Now()
+	defer func() { t.rec.record("rollback", start, err) }()
+	return t.parent.Rollback()
+}
@@ -0,0 +1,290 @@
+package sqlstats
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// recordStatter record the bucket and tags of the metrics
+type recordStatter struct {
+	statsd.Statter
+	m     sync.Mutex
+	lines []string
+}
+
+func (r *recordStatter) add(line string) {
+	r.m.Lock()
+	r.lines = append(r.lines, line)
+	r.m.Unlock()
+}
+
+func (r *recordStatter) Incr(stat string, count int64, tags ...string) error {
+	r.add(stat + "|c|" + strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) Timing(stat string, delta int64, tags ...string) error {
+	r.add(stat + "|ms|" + strings.Join(tags, ","))
+	return nil
+}
+
+func (r *recordStatter) Gauge(stat string, value int64, tags ...string) error {
+	r.add(stat + "|g|" + strings.Join(tags, ","))
+	return nil
+}
+
+var errBroken = errors.New("broken query")
+
+// fakeDriver implement only the mandatory driver interfaces
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt(query), nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeStmt string
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s == "broken" {
+		return nil, errBroken
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return nil }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// connector open the connections of a driver, for sql.OpenDB
+type connector struct {
+	d driver.Driver
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+// checkerDriver accept []int arguments, database/sql refuses them by default
+type checkerDriver struct{}
+
+func (checkerDriver) Open(string) (driver.Conn, error) { return checkerConn{}, nil }
+
+type checkerConn struct{ fakeConn }
+
+func (checkerConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ints, ok := nv.Value.([]int); ok {
+		nv.Value = int64(len(ints))
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// converterStmt convert its arguments to strings
+type converterStmt struct{ fakeStmt }
+
+func (converterStmt) ColumnConverter(int) driver.ValueConverter { return stringConverter{} }
+
+type stringConverter struct{}
+
+func (stringConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	return fmt.Sprint(v), nil
+}
+
+func Test_Wrap(t *testing.T) {
+	r := &recordStatter{}
+	db := sql.OpenDB(connector{Wrap(fakeDriver{}, r, "db:test")})
+	defer db.Close()
+
+	if _, err := db.Exec("ok", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("broken"); !errors.Is(err, errBroken) {
+		t.Fatalf("err: %v <=> want: %v", err, errBroken)
+	}
+	rows, err := db.Query("select")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+
+	want := []string{
+		"sql.latency|ms|op:prepare,db:test",
+		"sql.latency|ms|op:exec,db:test",
+		"sql.latency|ms|op:prepare,db:test",
+		"sql.latency|ms|op:exec,db:test",
+		"sql.errors|c|op:exec,db:test",
+		"sql.latency|ms|op:prepare,db:test",
+		"sql.latency|ms|op:query,db:test",
+		"sql.latency|ms|op:begin,db:test",
+		"sql.latency|ms|op:commit,db:test",
+	}
+	if got := strings.Join(r.lines, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	r.lines = nil
+	p := &PoolStats{db: db, client: r, tags: []string{"db:test"}}
+	p.collect()
+	if len(r.lines) != 4 || r.lines[0] != "sql.pool.open|g|db:test" {
+		t.Fatalf("pool gauges: %v", r.lines)
+	}
+}
+
+func Test_StartPoolStatsInvalidInterval(t *testing.T) {
+	if _, err := StartPoolStats(nil, nil, 0); err != ErrInvalidInterval {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidInterval)
+	}
+}
+
+func Test_WrapArguments(t *testing.T) {
+	r := &recordStatter{}
+
+	// the named arguments fail with a real error, not driver.ErrSkip
+	db := sql.OpenDB(connector{Wrap(fakeDriver{}, r)})
+	defer db.Close()
+	if _, err := db.Exec("ok", sql.Named("id", 1)); !errors.Is(err, ErrNamedParameters) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrNamedParameters)
+	}
+
+	// the argument checker of the connection is kept
+	db = sql.OpenDB(connector{Wrap(checkerDriver{}, r)})
+	defer db.Close()
+	if _, err := db.Exec("ok", []int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// and so are the column converters of the statement
+	stmt := &wrapStmt{parent: converterStmt{}, conn: fakeConn{}, rec: &recorder{client: r}}
+	nv := &driver.NamedValue{Ordinal: 1, Value: 42}
+	if err := stmt.CheckNamedValue(nv); err != driver.ErrSkip {
+		t.Fatalf("err: %v <=> want: %v", err, driver.ErrSkip)
+	}
+	if v, err := stmt.ColumnConverter(0).ConvertValue(42); err != nil || v != "42" {
+		t.Fatalf("converted: %v (%v) <=> want: 42", v, err)
+	}
+}
+
+func Test_WrapBeginTxOptions(t *testing.T) {
+	db := sql.OpenDB(connector{Wrap(fakeDriver{}, &recordStatter{})})
+	defer db.Close()
+
+	// the driver can only Begin, the options it can't apply are refused
+	ctx := context.Background()
+	if _, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}); !errors.Is(err, ErrIsolationLevel) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrIsolationLevel)
+	}
+	if _, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrReadOnly)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+}
+
+// contextDriver is a driver.DriverContext counting the connections of its
+// connector
+type contextDriver struct {
+	fakeDriver
+	connects *int
+	closed   *bool
+}
+
+func (d contextDriver) OpenConnector(string) (driver.Connector, error) {
+	return closingConnector{connector{d}, d}, nil
+}
+
+type closingConnector struct {
+	connector
+	d contextDriver
+}
+
+func (c closingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	*c.d.connects++
+	return c.connector.Connect(ctx)
+}
+
+func (c closingConnector) Close() error {
+	*c.d.closed = true
+	return nil
+}
+
+func Test_WrapConnector(t *testing.T) {
+	r := &recordStatter{}
+	d := contextDriver{connects: new(int), closed: new(bool)}
+
+	// the connector of a driver.DriverContext is used, and closed with the db
+	sql.Register("sqlstats-context", Wrap(d, r))
+	db, err := sql.Open("sqlstats-context", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("ok"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if *d.connects != 1 || !*d.closed {
+		t.Fatalf("connects: %d, closed: %t <=> want: 1, true", *d.connects, *d.closed)
+	}
+
+	// a connector given to sql.OpenDB is wrapped too
+	db = sql.OpenDB(WrapConnector(connector{fakeDriver{}}, r, "db:test"))
+	defer db.Close()
+	r.lines = nil
+	if _, err := db.Exec("ok"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(r.lines, "\n"), "sql.latency|ms|op:prepare,db:test\nsql.latency|ms|op:exec,db:test"; got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func Test_WrapDefaultClient(t *testing.T) {
+	// a nil client is the default one, the operations don't panic
+	db := sql.OpenDB(connector{Wrap(fakeDriver{}, nil)})
+	defer db.Close()
+	if _, err := db.Exec("ok"); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := StartPoolStats(db, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+	if p.client == nil {
+		t.Fatal("nil client kept")
+	}
+}
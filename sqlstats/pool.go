@@ -0,0 +1,81 @@
+package sqlstats
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// buckets of the pool gauges, tagged with the tags given to StartPoolStats
+const (
+	statPoolOpen  = "sql.pool.open"
+	statPoolIdle  = "sql.pool.idle"
+	statPoolInUse = "sql.pool.in_use"
+	statPoolWait  = "sql.pool.wait_count"
+)
+
+// ErrInvalidInterval is returned by StartPoolStats for an interval <= 0
+var ErrInvalidInterval = errors.New("sqlstats: interval is less than or equal to 0")
+
+// PoolStats periodically gauge the connections of a *sql.DB pool
+type PoolStats struct {
+	db     *sql.DB
+	client statsd.Statter
+	tags   []string
+
+	ticker   *time.Ticker
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartPoolStats start gauging the pool of db every interval, to client or to
+// statsd.DefaultStatter if nil
+func StartPoolStats(db *sql.DB, client statsd.Statter, interval time.Duration, tags ...string) (*PoolStats, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if client == nil {
+		client = statsd.DefaultStatter()
+	}
+
+	p := &PoolStats{
+		db:     db,
+		client: client,
+		tags:   tags,
+		ticker: time.NewTicker(interval),
+		done:   make(chan struct{}),
+	}
+
+	go p.loop()
+	return p, nil
+}
+
+// Stop gauging
+func (p *PoolStats) Stop() {
+	p.stopOnce.Do(func() {
+		p.ticker.Stop()
+		close(p.done)
+	})
+}
+
+func (p *PoolStats) loop() {
+	for {
+		select {
+		case <-p.ticker.C:
+			p.collect()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *PoolStats) collect() {
+	s := p.db.Stats()
+	p.client.Gauge(statPoolOpen, int64(s.OpenConnections), p.tags...)
+	p.client.Gauge(statPoolIdle, int64(s.Idle), p.tags...)
+	p.client.Gauge(statPoolInUse, int64(s.InUse), p.tags...)
+	p.client.Gauge(statPoolWait, s.WaitCount, p.tags...)
+}
@@ -2,7 +2,9 @@ package statsd
 
 import (
 	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,28 +15,176 @@ type Config struct {
 	Project    string
 	Enable     bool    // flag used to indicate whether stats is enabled
 	SampleRate float32 // global statsd sample rate
+
+	// SampleSchedule overrides SampleRate by time of day, if set
+	SampleSchedule *SampleSchedule
+
+	// SampleRates override SampleRate and SampleSchedule by metric name or
+	// glob (e.g. "cache.*"), see WithSampleRates
+	SampleRates map[string]float32
+
+	// Tags (e.g. "env:prod", "dc:ams1") appended to every metric of the default client
+	Tags []string
+
+	// Hosts ("host:port") shard the metrics over a cluster by bucket, in
+	// place of Host and Port, see WithHosts
+	Hosts []string
+
+	// Backup ("host:port") receive the metrics while Host fails, see WithFailover
+	Backup string
+
+	// Mirrors ("host:port") receive every metric too, see WithMirrors
+	Mirrors []string
+
+	// Protocol is the dialect of the lines, DogStatsD by default, see
+	// WithProtocol. With ProtocolGraphite the default client connects over TCP.
+	Protocol Protocol
+
+	// ConnectTimeout bound the dials of the default client, 5s if 0, see
+	// WithConnectTimeout
+	ConnectTimeout time.Duration
+
+	// WriteTimeout bound its writes, none if 0, see WithWriteTimeout
+	WriteTimeout time.Duration
+
+	// SendBuffer is the size of the socket send buffer of the default client,
+	// the system default if 0, see SocketOptions
+	SendBuffer int
+
+	// Extensions declare the extended fields the server supports, see ParseExtensions
+	Extensions      Extension
+	ExtensionSchema int    // schema version the server understands, ExtensionSchema if 0
+	ContainerID     string // sent with ExtContainerID
+
+	// AggregationWindow buffer the gauges and timers too, see WithAggregation
+	AggregationWindow time.Duration
+
+	// QueueSize is the size of the send queue of the package level helpers,
+	// 1024 if 0. It is read once, by the first helper call.
+	QueueSize int
+
+	// OverflowPolicy is what the helpers do once the send queue is full,
+	// OverflowDropNewest by default
+	OverflowPolicy OverflowPolicy
+
+	// OverflowTimeout is how long OverflowBlock waits for room, 10ms if 0
+	OverflowTimeout time.Duration
+
+	// SendWorkers is the number of goroutines draining the send queue, 1 if
+	// 0, the default client then opens as many connections, see
+	// WithConnections. It is read once, by the first helper call.
+	SendWorkers int
+
+	// MaxBatchDelay hold the metrics at most this long to batch them, see WithMaxBatchDelay
+	MaxBatchDelay time.Duration
+
+	// GapThreshold send statsd.client.gap after failing to write this long, see WithGapDetection
+	GapThreshold time.Duration
+
+	// GaugeAllowNegative send the negative gauges as they are, see WithGaugeAllowNegative
+	GaugeAllowNegative bool
+
+	// Sanitizer clean the stats and the tag values of the default client, e.g.
+	// Sanitize, see WithSanitizer
+	Sanitizer func(string) string
+
+	// NameRules bound the length and the characters of the stats of the
+	// default client, see WithNameRules
+	NameRules NameRules
+
+	// TagValueLimit bound the distinct values of each tag of a metric of the
+	// default client, the further ones sent as "other", see WithTagLimit
+	TagValueLimit int
+
+	// OnTagLimit is called the first time a tag passes TagValueLimit
+	OnTagLimit func(stat, key, value string)
+
+	// Allow send only the metrics of the default client matching one of
+	// these globs, e.g. "http.*", see WithAllow
+	Allow []string
+
+	// Deny drop the metrics matching one of these globs, e.g. "debug.*",
+	// see WithDeny
+	Deny []string
+
+	// RateLimit bound the metrics and bytes per second the default client
+	// writes, see WithRateLimit
+	RateLimit RateLimit
+
+	// StartupBanner send a counter tagged with the config fingerprint on connect
+	StartupBanner bool
+
+	// Logger log the connections and the losses of the default client, see WithLogger
+	Logger *slog.Logger
+
+	// ErrorHandler is called with the errors the package level helpers
+	// can't return, e.g. a failed connection or a failed send
+	ErrorHandler func(err error)
+
+	rates *sampleRates // of SampleRates, built by Setup
 }
 
 const (
 	defaultSampleRate = 1.0
 )
 
-var config *Config
-var addr string
+// config is the snapshot of the config given to Setup, nil before. It is
+// never modified once published, each helper loads it once.
+var config atomic.Pointer[Config]
+
+var addr string // of config, guarded by defaultMu
 
-// Setup set the config
+// Setup set the config, the default client connects from it on the first
+// helper call unless one is connected already
 func Setup(cfg *Config) {
-	config = cfg
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	setup(cfg)
+}
+
+// setup is Setup with defaultMu held
+func setup(cfg *Config) {
+	stopReconnect()
+	defaultTried = false
+	snapshot := *cfg
 
 	// if sample rate is equal to 0, it indicates that the statsd never called
 	// so we set a default value
-	if config.SampleRate == 0 {
-		config.SampleRate = defaultSampleRate
+	if snapshot.SampleRate == 0 {
+		snapshot.SampleRate = defaultSampleRate
 	}
 
-	addr = fmt.Sprintf("%s:%d", config.Host, config.Port)
+	addr = fmt.Sprintf("%s:%d", snapshot.Host, snapshot.Port)
+
+	// the invalid windows are ignored, the rest of the schedule still applies
+	reportError(&snapshot, snapshot.SampleSchedule.Validate())
+
+	// the same for the invalid sample rates
+	var err error
+	snapshot.rates, err = newSampleRates(snapshot.SampleRates)
+	reportError(&snapshot, err)
+
+	config.Store(&snapshot)
+	enabled.Store(snapshot.Enable)
 }
 
+// enabled is Config.Enable of the config given to Setup, changed by SetEnabled
+var enabled atomic.Bool
+
+// SetEnabled turn the package level helpers on or off at runtime, e.g. from
+// an admin endpoint, overriding Config.Enable until the next Setup. It is
+// safe to call concurrently with the helpers.
+func SetEnabled(b bool) {
+	enabled.Store(b)
+}
+
+// Enabled tell if the package level helpers send metrics
+func Enabled() bool {
+	return config.Load() != nil && enabled.Load()
+}
+
+// The tags given to the helpers below (e.g. "route:home") are appended to Config.Tags.
+
 type metricType int16
 
 const (
@@ -42,140 +192,235 @@ const (
 	metricTypeGauge
 	metricTypeFGauge
 	metricTypeTimer
+	metricTypeGaugeDelta
+	metricTypeFCount
+	metricTypeDuration // a time.Duration, see Client.TimingDuration
 )
 
-// Incr increment a particular event
-func Incr(stat string) {
-	getClient().Incr(stat, 1)
+// wireType return the statsd type of t
+func (t metricType) wireType() string {
+	switch t {
+	case metricTypeCount, metricTypeFCount:
+		return "c"
+	case metricTypeTimer, metricTypeDuration:
+		return "ms"
+	}
+	return "g"
+}
+
+// Incr increment a particular event, unsampled unless Config.SampleRates
+// sets a rate for stat: Config.SampleRate and SampleSchedule don't apply
+func Incr(stat string, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil || !enabled.Load() {
+		return
+	}
+	if cli := getClient(); cli != nil {
+		cli.IncrWithSampling(stat, 1, cfg.incrRate(stat), tags...)
+	}
 }
 
 // IncrByVal increment a particular event with value
-func IncrByVal(stat string, val int64) {
+func IncrByVal(stat string, val int64, tags ...string) {
 	// check whether is initialized
-	if config == nil {
+	cfg := config.Load()
+	if cfg == nil || !enabled.Load() {
 		return
 	}
-	getClient().IncrWithSampling(stat, val, config.SampleRate)
+	if cli := getClient(); cli != nil {
+		cli.IncrWithSampling(stat, val, cfg.sampleRate(stat), tags...)
+	}
 }
 
 // IncrWithSampling increment a particular event with value and sampling
-func IncrWithSampling(stat string, val int64, sampleRate float32) {
-	if config == nil {
+func IncrWithSampling(stat string, val int64, sampleRate float32, tags ...string) {
+	if config.Load() == nil {
 		return
 	}
 
-	if !config.Enable {
+	if !enabled.Load() {
 		return
 	}
 	if val == 0 {
 		return // ignore
 	}
-	getClient().IncrWithSampling(stat, val, sampleRate)
+	if cli := getClient(); cli != nil {
+		cli.IncrWithSampling(stat, val, sampleRate, tags...)
+	}
+}
+
+// FIncr increment a particular event with a fractional value
+func FIncr(stat string, val float64, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
+		return
+	}
+
+	FIncrWithSampling(stat, val, cfg.sampleRate(stat), tags...)
+}
+
+// FIncrWithSampling increment a particular event with a fractional value and sampling
+func FIncrWithSampling(stat string, val float64, sampleRate float32, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
+		return
+	}
+
+	if !enabled.Load() {
+		return
+	}
+
+	sendFloat(cfg, stat, val, metricTypeFCount, sampleRate, tags)
 }
 
 // Gauge set a constant value of a particular event
-func Gauge(stat string, val int64) {
-	if config == nil {
+func Gauge(stat string, val int64, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	GaugeWithSampling(stat, val, config.SampleRate)
+	GaugeWithSampling(stat, val, cfg.sampleRate(stat), tags...)
 }
 
 // Gauge2Times call Gauge 2 times
-func Gauge2Times(stat string, val int64) {
-	Gauge(stat, val)
-	Gauge(stat, val)
+func Gauge2Times(stat string, val int64, tags ...string) {
+	Gauge(stat, val, tags...)
+	Gauge(stat, val, tags...)
 }
 
 // GaugeMultiTimes call Gauge multiple times
-func GaugeMultiTimes(stat string, val int64, t int) {
+func GaugeMultiTimes(stat string, val int64, t int, tags ...string) {
 	if t <= 0 {
 		return
 	}
 
 	for t > 0 {
-		Gauge(stat, val)
+		Gauge(stat, val, tags...)
 		t--
 	}
 }
 
+// GaugeBool set a particular event to 1 when b is true, 0 otherwise
+func GaugeBool(stat string, b bool, tags ...string) {
+	var val int64
+	if b {
+		val = 1
+	}
+
+	Gauge(stat, val, tags...)
+}
+
 // GaugeWithSampling set a constant value of a particular event with sampling
-func GaugeWithSampling(stat string, val int64, sampleRate float32) {
-	if config == nil {
+func GaugeWithSampling(stat string, val int64, sampleRate float32, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	if !config.Enable {
+	if !enabled.Load() {
 		return
 	}
 
-	gauge(stat, val, metricTypeGauge, sampleRate)
+	sendInt(cfg, stat, val, metricTypeGauge, sampleRate, tags)
 }
 
 // FGauge set a constant float point value of a particular event
-func FGauge(stat string, val float64) {
-	if config == nil {
+func FGauge(stat string, val float64, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	FGaugeWithSampling(stat, val, config.SampleRate)
+	FGaugeWithSampling(stat, val, cfg.sampleRate(stat), tags...)
 }
 
 // FGaugeWithSampling set a constant float point value of a particular event with sampling
-func FGaugeWithSampling(stat string, val float64, sampleRate float32) {
-	if config == nil {
+func FGaugeWithSampling(stat string, val float64, sampleRate float32, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	if !config.Enable {
+	if !enabled.Load() {
 		return
 	}
 
-	gauge(stat, val, metricTypeFGauge, sampleRate)
+	sendFloat(cfg, stat, val, metricTypeFGauge, sampleRate, tags)
 }
 
-func gauge(stat string, val interface{}, t metricType, sampleRate float32) {
-	send(stat, val, t, sampleRate)
+// SendBatch call fn to collect metrics then write them in a single packet
+// with the default client, see Client.Batch. fn is called even when the stats
+// are disabled or not connected, the batch is then dropped.
+func SendBatch(fn func(b *Batch)) {
+	var cli *Client
+	if config.Load() != nil && enabled.Load() {
+		cli = getClient()
+	}
+	if cli == nil {
+		fn(&Batch{}) // collect nothing
+		return
+	}
+	handleError(cli.Batch(fn))
 }
 
 // TimingByValue track duration of a event
-func TimingByValue(stat string, d time.Duration) {
-	if config == nil {
+func TimingByValue(stat string, d time.Duration, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	TimingByValueWithSampling(stat, d, config.SampleRate)
+	TimingByValueWithSampling(stat, d, cfg.sampleRate(stat), tags...)
 }
 
 // TimingByValueWithSampling track duration of a event with sampling
-func TimingByValueWithSampling(stat string, d time.Duration, sampleRate float32) {
-	if config == nil {
+func TimingByValueWithSampling(stat string, d time.Duration, sampleRate float32, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	if !config.Enable {
+	if !enabled.Load() {
 		return
 	}
 
-	// the delta must be given in milliseconds
-	t := d / time.Millisecond
-
-	send(stat, int64(t), metricTypeTimer, sampleRate)
+	// converted by the client, see WithTimingUnit and WithFloatTimings
+	sendInt(cfg, stat, int64(d), metricTypeDuration, sampleRate, tags)
 }
 
 // Timing track duration of a event
-func Timing(stat string, t1 time.Time, t2 time.Time) {
-	if config == nil {
+func Timing(stat string, t1 time.Time, t2 time.Time, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
 		return
 	}
 
-	TimingWithSampling(stat, t1, t2, config.SampleRate)
+	TimingWithSampling(stat, t1, t2, cfg.sampleRate(stat), tags...)
 }
 
 // TimingWithSampling track duration of a event with sampling
-func TimingWithSampling(stat string, t1 time.Time, t2 time.Time, sampleRate float32) {
-	TimingByValueWithSampling(stat, t2.Sub(t1), sampleRate)
+func TimingWithSampling(stat string, t1 time.Time, t2 time.Time, sampleRate float32, tags ...string) {
+	TimingByValueWithSampling(stat, t2.Sub(t1), sampleRate, tags...)
+}
+
+// TimeFunc track the wall-clock duration of fn, fn is always called
+func TimeFunc(stat string, fn func(), tags ...string) {
+	cfg := config.Load()
+	if cfg == nil {
+		fn()
+		return
+	}
+
+	TimeFuncWithSampling(stat, fn, cfg.sampleRate(stat), tags...)
+}
+
+// TimeFuncWithSampling track the wall-clock duration of fn with sampling,
+// fn is always called
+func TimeFuncWithSampling(stat string, fn func(), sampleRate float32, tags ...string) {
+	t1 := time.Now()
+	fn()
+	TimingByValueWithSampling(stat, time.Since(t1), sampleRate, tags...)
 }
 
 // Now return current system time
@@ -183,75 +428,257 @@ func Now() time.Time {
 	return time.Now()
 }
 
-var once sync.Once
-var defaultClient *Client
+var defaultClient atomic.Pointer[Client]
 
+// defaultMu guards the config, its address and the lazy connection of the
+// default client against the background goroutines
+var defaultMu sync.Mutex
+var defaultTried bool // the lazy connection from the config has been attempted
+var reconnecting *reconnector
+
+// delays between two connection attempts of the default client
+var (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = time.Minute
+)
+
+// getClient return the default client, or nil while it isn't connected yet.
+// The first call after Setup connects, if it fails the error is reported and
+// the connection is retried in the background until it succeeds. The dial is
+// done out of defaultMu, the other calls return nil meanwhile.
 func getClient() *Client {
-	once.Do(func() {
-		client, err := newClient(addr, config.Project)
-		if err != nil {
-			panic(err)
+	if c := defaultClient.Load(); c != nil {
+		return c
+	}
+
+	defaultMu.Lock()
+	cfg, cfgAddr := config.Load(), addr
+	if cfg == nil || defaultTried {
+		defaultMu.Unlock()
+		return defaultClient.Load()
+	}
+	defaultTried = true
+	defaultMu.Unlock()
+
+	err := connectDefault(cfg, cfgAddr)
+	if err != nil {
+		defaultMu.Lock()
+		// unless a Setup, Reload or SetDefault superseded the config meanwhile
+		if config.Load() == cfg && defaultClient.Load() == nil && reconnecting == nil {
+			reconnecting = &reconnector{
+				cfg:      cfg,
+				addr:     cfgAddr,
+				minDelay: reconnectMinDelay,
+				maxDelay: reconnectMaxDelay,
+				stop:     make(chan struct{}),
+			}
+			go reconnecting.run()
 		}
-		defaultClient = client
-	})
-	return defaultClient
+		defaultMu.Unlock()
+	}
+
+	reportError(cfg, err)
+	return defaultClient.Load()
+}
+
+// connectDefault connect a client from cfg and publish it as the default
+// client, unless one has been set meanwhile
+func connectDefault(cfg *Config, addr string) error {
+	client, err := New(addr, cfg.options()...)
+	if err != nil {
+		return err
+	}
+	if !defaultClient.CompareAndSwap(nil, client) {
+		// a default client has been set meanwhile
+		client.Close()
+	}
+	return nil
+}
+
+// Default return the client used by the package level helpers, nil if none
+func Default() *Client {
+	return getClient()
+}
+
+// SetDefault make c the client used by the package level helpers, instead of
+// the one created from the config given to Setup. The caller keeps the
+// ownership of c and of the previous default client. If Setup hasn't been
+// called, the helpers are enabled with the default sample rate.
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if config.Load() == nil {
+		setup(&Config{Enable: true})
+	}
+	stopReconnect()
+	defaultTried = true // no lazy connection from the config any more
+	userDefault = c
+	defaultClient.Store(c)
+}
+
+// reconnector retry the connection of the default client in the background,
+// with the config and address of the failed attempt
+type reconnector struct {
+	cfg      *Config
+	addr     string
+	minDelay time.Duration
+	maxDelay time.Duration
+	stop     chan struct{}
+}
+
+func (r *reconnector) run() {
+	delay := r.minDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-r.stop:
+			return
+		}
+
+		err := connectDefault(r.cfg, r.addr)
+		if err == nil {
+			return
+		}
+		select {
+		case <-r.stop:
+			return // a later Setup or SetDefault superseded the config
+		default:
+		}
+		reportError(r.cfg, err)
+
+		if delay *= 2; delay > r.maxDelay {
+			delay = r.maxDelay
+		}
+	}
+}
+
+// stopReconnect stop the background reconnection, with defaultMu held
+func stopReconnect() {
+	if reconnecting != nil {
+		close(reconnecting.stop)
+		reconnecting = nil
+	}
+}
+
+// handleError pass err to the configured error handler, if any
+func handleError(err error) {
+	reportError(config.Load(), err)
+}
+
+// reportError pass err to the error handler of cfg, if any
+func reportError(cfg *Config, err error) {
+	if err == nil || cfg == nil || cfg.ErrorHandler == nil {
+		return
+	}
+	cfg.ErrorHandler(err)
 }
 
+// sendItem is a metric queued by the helpers, value holds the integer values
+// and the durations, fvalue the float ones, so that nothing is boxed
 type sendItem struct {
 	stat       string
-	val        interface{}
 	t          metricType
+	value      int64
+	fvalue     float64
 	sampleRate float32
+	tags       []string
 }
 
+// sendItemPool recycle the items once sent or dropped
+var sendItemPool = sync.Pool{New: func() interface{} { return new(sendItem) }}
+
 var sendLoopOnce sync.Once
 var sendCh chan *sendItem
 
-func sendAsync(stat string, val interface{}, t metricType, sampleRate float32) {
+func sendInt(cfg *Config, stat string, value int64, t metricType, sampleRate float32, tags []string) {
+	item := sendItemPool.Get().(*sendItem)
+	*item = sendItem{stat: stat, t: t, value: value, sampleRate: sampleRate, tags: tags}
+	sendAsync(cfg, item)
+}
+
+func sendFloat(cfg *Config, stat string, fvalue float64, t metricType, sampleRate float32, tags []string) {
+	item := sendItemPool.Get().(*sendItem)
+	*item = sendItem{stat: stat, t: t, fvalue: fvalue, sampleRate: sampleRate, tags: tags}
+	sendAsync(cfg, item)
+}
+
+func sendAsync(cfg *Config, item *sendItem) {
 	sendLoopOnce.Do(func() {
 		if sendCh == nil {
-			sendCh = make(chan *sendItem, 1024)
+			sendCh = make(chan *sendItem, cfg.queueSize())
+		}
+		for i := 0; i < cfg.sendWorkers(); i++ {
+			go sendLoop()
 		}
-		cli := getClient()
-		go func() {
-			for item := range sendCh {
-				sendEx(cli, item.stat, item.val, item.t, item.sampleRate)
-			}
-		}()
 	})
-	select {
-	case sendCh <- &sendItem{stat, val, t, sampleRate}:
-	default:
+	policy, timeout := cfg.overflow()
+	pushItem(sendCh, item, policy, timeout, dropItem)
+}
+
+// sendLoop send the queued metrics with the default client
+func sendLoop() {
+	for item := range sendCh {
+		if cli := getClient(); cli != nil { // else not connected yet, drop it
+			handleError(sendEx(cli, item))
+		}
+		releaseItem(item)
 	}
 }
 
-func send(stat string, val interface{}, t metricType, sampleRate float32) {
-	sendAsync(stat, val, t, sampleRate)
+// releaseItem put item back in the pool, without retaining the tags
+func releaseItem(item *sendItem) {
+	*item = sendItem{}
+	sendItemPool.Put(item)
 }
 
-func sendEx(client *Client, stat string, val interface{}, t metricType, sampleRate float32) {
-	if stat == "" {
+// dropItem keep a metric dropped by the full send queue as a dead letter
+func dropItem(item *sendItem) {
+	defer releaseItem(item)
+	cli := defaultClient.Load()
+	if cli == nil {
 		return
 	}
+	cli.stats.dropped.Add(1)
+	cli.log(slog.LevelWarn, "statsd: metric dropped", "stat", item.stat, "err", ErrQueueFull)
+
+	var val interface{} = item.value
+	switch item.t {
+	case metricTypeGaugeDelta:
+		val = formatDelta(item.value)
+	case metricTypeFCount:
+		val = formatCount(item.fvalue)
+	case metricTypeFGauge:
+		val = item.fvalue
+	case metricTypeDuration:
+		val = cli.durationValue(time.Duration(item.value))
+	}
+	cli.addDeadLetter(cli.format(item.stat, val, item.t.wireType(), item.sampleRate, item.tags...), ErrQueueFull)
+}
 
-	switch t {
+func sendEx(client *Client, item *sendItem) error {
+	if item.stat == "" {
+		return nil
+	}
+
+	stat, sampleRate, tags := item.stat, item.sampleRate, item.tags
+	switch item.t {
 	case metricTypeCount:
-		if i, ok := val.(int64); ok {
-			client.IncrWithSampling(stat, i, sampleRate)
-		}
+		return client.IncrWithSampling(stat, item.value, sampleRate, tags...)
 	case metricTypeGauge:
-		if i, ok := val.(int64); ok {
-			client.GaugeWithSampling(stat, i, sampleRate)
-		}
+		return client.GaugeWithSampling(stat, item.value, sampleRate, tags...)
 	case metricTypeFGauge:
-		if i, ok := val.(float64); ok {
-			client.FGaugeWithSampling(stat, i, sampleRate)
-		}
+		return client.FGaugeWithSampling(stat, item.fvalue, sampleRate, tags...)
 	case metricTypeTimer:
-		if i, ok := val.(int64); ok {
-			client.TimingWithSampling(stat, i, sampleRate)
-		}
+		return client.TimingWithSampling(stat, item.value, sampleRate, tags...)
+	case metricTypeGaugeDelta:
+		return client.GaugeDelta(stat, item.value, tags...)
+	case metricTypeFCount:
+		return client.FIncrWithSampling(stat, item.fvalue, sampleRate, tags...)
+	case metricTypeDuration:
+		return client.TimingDurationWithSampling(stat, time.Duration(item.value), sampleRate, tags...)
 	default:
 		// temporary do nothing
 	}
+	return nil
 }
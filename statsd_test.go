@@ -1,6 +1,11 @@
 package statsd
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
 	"testing"
 	"time"
 )
@@ -16,7 +21,17 @@ func initConfig() {
 	Setup(config)
 }
 
+// resetDefaultClient make the next helper call connect from the current config
+func resetDefaultClient() {
+	defaultMu.Lock()
+	stopReconnect()
+	defaultTried = false
+	defaultMu.Unlock()
+	defaultClient.Store(nil)
+}
+
 func Test_Gauge(t *testing.T) {
+	restoreDefault(t)
 	initConfig()
 
 	var v int64
@@ -35,6 +50,7 @@ func Test_Gauge(t *testing.T) {
 }
 
 func Test_Incr(t *testing.T) {
+	restoreDefault(t)
 	initConfig()
 
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -50,3 +66,263 @@ func Test_Incr(t *testing.T) {
 		}
 	}
 }
+
+func Test_getClientLazyConnect(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	errCh := make(chan error, 16)
+	defer func(d time.Duration) { reconnectMinDelay = d }(reconnectMinDelay)
+	reconnectMinDelay = 10 * time.Millisecond
+	cfg := &Config{
+		Project:      "stats",
+		Host:         "statsd.invalid",
+		Port:         8125,
+		Enable:       true,
+		ErrorHandler: func(err error) { errCh <- err },
+	}
+	restoreDefault(t)
+	Setup(cfg)
+
+	// must not panic while the host can't be resolved
+	if cli := getClient(); cli != nil {
+		t.Fatalf("getClient() = %v, want nil before connected", cli)
+	}
+	Incr("statsd.lazy")
+
+	select {
+	case <-errCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection error not reported")
+	}
+
+	// SetDefault stops the background retry
+	a, _ := newTestClient(t, "a")
+	SetDefault(a)
+	time.Sleep(50 * time.Millisecond)
+	for len(errCh) > 0 {
+		<-errCh
+	}
+	time.Sleep(100 * time.Millisecond)
+	if len(errCh) > 0 {
+		t.Fatalf("retry still running after SetDefault: %v", <-errCh)
+	}
+
+	// the background retry connects once the address is reachable
+	defaultClient.Store(nil)
+	r := &reconnector{cfg: cfg, addr: l.LocalAddr().String(), minDelay: time.Millisecond, maxDelay: time.Millisecond, stop: make(chan struct{})}
+	go r.run()
+	defer close(r.stop)
+	deadline := time.Now().Add(5 * time.Second)
+	for getClient() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("client not connected by the background retry")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// blockHandler is a slog.Handler blocking the first record until released
+type blockHandler struct {
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (h *blockHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *blockHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *blockHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *blockHandler) Handle(context.Context, slog.Record) error {
+	h.once.Do(func() {
+		close(h.entered)
+		<-h.release
+	})
+	return nil
+}
+
+func Test_getClientDialUnlocked(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	restoreDefault(t)
+	h := &blockHandler{entered: make(chan struct{}), release: make(chan struct{})}
+	var released sync.Once
+	release := func() { released.Do(func() { close(h.release) }) }
+	t.Cleanup(release) // before restoreDefault, even on failure
+	Setup(&Config{Host: "127.0.0.1", Port: l.LocalAddr().(*net.UDPAddr).Port, Enable: true, Logger: slog.New(h)})
+
+	connected := make(chan *Client)
+	go func() { connected <- getClient() }()
+	<-h.entered // blocked in the dial, logging the connection
+
+	// the other helpers don't wait for the dial
+	done := make(chan *Client)
+	go func() { done <- Default() }()
+	select {
+	case c := <-done:
+		if c != nil {
+			t.Fatalf("Default() = %v, want nil while connecting", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Default() blocked by the dial")
+	}
+
+	release()
+	if c := <-connected; c == nil || Default() != c {
+		t.Fatalf("getClient() = %v, Default() = %v, want the connected client", c, Default())
+	}
+}
+
+func Test_getClientBeforeSetup(t *testing.T) {
+	restoreDefault(t)
+	config.Store(nil)
+
+	// a helper called before Setup must not prevent the later connection
+	Incr("statsd.early")
+	if cli := Default(); cli != nil {
+		t.Fatalf("Default() = %v, want nil before Setup", cli)
+	}
+
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	Setup(&Config{Host: "127.0.0.1", Port: l.LocalAddr().(*net.UDPAddr).Port, Enable: true})
+
+	if Default() == nil {
+		t.Fatal("default client not connected after Setup")
+	}
+}
+
+func Test_SetDefault(t *testing.T) {
+	restoreDefault(t)
+	a, _ := newTestClient(t, "a")
+	b, _ := newTestClient(t, "b")
+
+	SetDefault(a)
+	if Default() != a {
+		t.Fatal("default client is not a")
+	}
+	IncrByVal("statsd.multi", 1)
+
+	SetDefault(b)
+	if Default() != b {
+		t.Fatal("default client is not b")
+	}
+	IncrByVal("statsd.multi", 1)
+	IncrByVal("statsd.multi", 1)
+
+	for _, c := range []*Client{a, b} {
+		c.m.Lock()
+		if len(c.buffer) != 1 || c.buffer[0].name != c.prefix+".statsd.multi" {
+			t.Fatalf("[%s] buffer: %v", c.prefix, c.buffer)
+		}
+		c.m.Unlock()
+	}
+}
+
+func Test_ConfigTags(t *testing.T) {
+	l := setupTestDefault(t, &Config{
+		Project: "stats",
+		Enable:  true,
+		Tags:    []string{"env:prod", "dc:ams1"},
+	})
+
+	TimingByValue("statsd.tags", 5*time.Millisecond)
+
+	want := "stats.statsd.tags:5|ms|#env:prod,dc:ams1"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+}
+
+func Test_CallTags(t *testing.T) {
+	l := setupTestDefault(t, &Config{
+		Project: "stats",
+		Enable:  true,
+		Tags:    []string{"env:prod"},
+	})
+
+	TimingByValue("statsd.route", 5*time.Millisecond, "route:home")
+	want := "stats.statsd.route:5|ms|#env:prod,route:home"
+	if got := readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+
+	// counters with different call tags are buffered apart
+	IncrByVal("statsd.hits", 1, "route:home")
+	IncrByVal("statsd.hits", 1, "route:cart")
+	IncrByVal("statsd.hits", 1, "route:home")
+
+	c := getClient()
+	c.m.Lock()
+	defer c.m.Unlock()
+	if len(c.buffer) != 2 || c.buffer[0].tags != "route:home" || c.buffer[1].tags != "route:cart" {
+		t.Fatalf("buffer: %v", c.buffer)
+	}
+}
+
+func Test_TimeFunc(t *testing.T) {
+	l := setupTestDefault(t, &Config{
+		Project: "stats",
+		Enable:  true,
+	})
+
+	called := false
+	TimeFunc("statsd.func", func() {
+		called = true
+		time.Sleep(20 * time.Millisecond)
+	})
+	if !called {
+		t.Fatal("fn not called")
+	}
+
+	var ms int
+	got := readPacket(t, l)
+	if _, err := fmt.Sscanf(got, "stats.statsd.func:%d|ms", &ms); err != nil || ms < 20 {
+		t.Fatalf("unexpected timer: %s", got)
+	}
+}
+
+func Test_SetEnabled(t *testing.T) {
+	l := setupTestDefault(t, &Config{Project: "ops", Enable: true})
+
+	SetEnabled(false)
+	if Enabled() {
+		t.Fatal("enabled after SetEnabled(false)")
+	}
+	Gauge("queue", 1)
+	l.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, _, err := l.ReadFrom(make([]byte, 1024)); err == nil {
+		t.Fatal("sent while disabled")
+	}
+
+	SetEnabled(true)
+	Gauge("queue", 2)
+	if got := readPacket(t, l); got != "ops.queue:2|g" {
+		t.Fatalf("got: %s <=> want: ops.queue:2|g", got)
+	}
+
+	// the next Setup resets it from the config
+	SetEnabled(false)
+	setupTestDefault(t, &Config{Project: "ops", Enable: true})
+	if !Enabled() {
+		t.Fatal("Setup didn't reset the toggle")
+	}
+}
+
+func Benchmark_sendInt(b *testing.B) {
+	cfg := config.Load()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sendInt(cfg, "queue", int64(i), metricTypeGauge, 1, nil)
+	}
+}
@@ -0,0 +1,114 @@
+package statsdtest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sunlit-coder/statsd/proto"
+)
+
+// Metric is a metric captured by a Recorder or a Server
+type Metric = proto.Metric
+
+// store keep the metrics of a Recorder or a Server, and query them
+type store struct {
+	m       sync.Mutex
+	metrics []Metric
+}
+
+// add keep a metric, r.m must be held
+func (r *store) add(m Metric) {
+	r.metrics = append(r.metrics, m)
+}
+
+// Metrics return the metrics received so far, in order
+func (r *store) Metrics() []Metric {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return append([]Metric(nil), r.metrics...)
+}
+
+// Reset forget the metrics received so far
+func (r *store) Reset() {
+	r.m.Lock()
+	r.metrics = nil
+	r.m.Unlock()
+}
+
+// Find return the metrics of the given name and type having every given tag
+func (r *store) Find(stat string, t string, tags ...string) []Metric {
+	var found []Metric
+	for _, m := range r.Metrics() {
+		if m.Name == stat && m.Type == t && m.HasTags(tags...) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// Count return the sum of the counter stat having every given tag, the
+// decrements included
+func (r *store) Count(stat string, tags ...string) int64 {
+	var sum float64
+	for _, m := range r.Find(stat, "c", tags...) {
+		sum += m.Value
+	}
+	return int64(sum)
+}
+
+// AssertIncr fail the test unless the counter stat having every given tag sums to count
+func (r *store) AssertIncr(t testing.TB, stat string, count int64, tags ...string) {
+	t.Helper()
+	if got := r.Count(stat, tags...); got != count {
+		t.Errorf("counter %s%s: %d <=> want: %d\n%s", stat, tagSuffix(tags), got, count, r.dump())
+	}
+}
+
+// AssertGauge fail the test unless the last value of the gauge stat having every given tag is value
+func (r *store) AssertGauge(t testing.TB, stat string, value float64, tags ...string) {
+	t.Helper()
+	found := r.Find(stat, "g", tags...)
+	if len(found) == 0 {
+		t.Errorf("gauge %s%s not set <=> want: %s\n%s", stat, tagSuffix(tags), formatValue(value), r.dump())
+		return
+	}
+	if got := found[len(found)-1].Value; got != value {
+		t.Errorf("gauge %s%s: %s <=> want: %s\n%s", stat, tagSuffix(tags), formatValue(got), formatValue(value), r.dump())
+	}
+}
+
+// AssertTiming fail the test unless the timer stat having every given tag was recorded n times
+func (r *store) AssertTiming(t testing.TB, stat string, n int, tags ...string) {
+	t.Helper()
+	if got := len(r.Find(stat, "ms", tags...)); got != n {
+		t.Errorf("timer %s%s: %d samples <=> want: %d\n%s", stat, tagSuffix(tags), got, n, r.dump())
+	}
+}
+
+// dump format the metrics received for the failure messages
+func (r *store) dump() string {
+	metrics := r.Metrics()
+	if len(metrics) == 0 {
+		return "no metric received"
+	}
+
+	lines := make([]string, 0, len(metrics)+1)
+	lines = append(lines, "received:")
+	for _, m := range metrics {
+		lines = append(lines, "\t"+m.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tagSuffix(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " {" + strings.Join(tags, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,99 @@
+// Package statsdtest help testing the code instrumented with statsd without a
+// statsd server
+package statsdtest
+
+import "github.com/sunlit-coder/statsd"
+
+// Recorder is a statsd.Statter capturing the metrics in memory, without
+// sampling, to inject in the code under test in place of a Client. It
+// validates the arguments like a Client. It is safe for concurrent use.
+type Recorder struct {
+	store
+	closed bool
+}
+
+var _ statsd.Statter = (*Recorder)(nil)
+
+// NewRecorder return an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// record capture a metric
+func (r *Recorder) record(stat string, value float64, t string, sampleRate float32, tags []string) error {
+	if sampleRate < 0 || sampleRate > 1 {
+		return statsd.ErrInvalidSampleRate
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.closed {
+		return statsd.ErrClosed
+	}
+	r.add(Metric{Name: stat, Type: t, Value: value, SampleRate: sampleRate, Tags: append([]string(nil), tags...)})
+	return nil
+}
+
+// Incr record a counter
+func (r *Recorder) Incr(stat string, count int64, tags ...string) error {
+	return r.IncrWithSampling(stat, count, 1, tags...)
+}
+
+// IncrWithSampling record a counter, the sample rate is kept but not applied
+func (r *Recorder) IncrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error {
+	if count <= 0 {
+		return statsd.ErrInvalidCount
+	}
+	return r.record(stat, float64(count), "c", sampleRate, tags)
+}
+
+// Decr record a negative counter
+func (r *Recorder) Decr(stat string, count int64, tags ...string) error {
+	return r.DecrWithSampling(stat, count, 1, tags...)
+}
+
+// DecrWithSampling record a negative counter, the sample rate is kept but not applied
+func (r *Recorder) DecrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error {
+	if count <= 0 {
+		return statsd.ErrInvalidCount
+	}
+	return r.record(stat, float64(-count), "c", sampleRate, tags)
+}
+
+// Timing record a timer
+func (r *Recorder) Timing(stat string, delta int64, tags ...string) error {
+	return r.TimingWithSampling(stat, delta, 1, tags...)
+}
+
+// TimingWithSampling record a timer, the sample rate is kept but not applied
+func (r *Recorder) TimingWithSampling(stat string, delta int64, sampleRate float32, tags ...string) error {
+	return r.record(stat, float64(delta), "ms", sampleRate, tags)
+}
+
+// Gauge record a gauge
+func (r *Recorder) Gauge(stat string, value int64, tags ...string) error {
+	return r.GaugeWithSampling(stat, value, 1, tags...)
+}
+
+// GaugeWithSampling record a gauge, the sample rate is kept but not applied
+func (r *Recorder) GaugeWithSampling(stat string, value int64, sampleRate float32, tags ...string) error {
+	return r.record(stat, float64(value), "g", sampleRate, tags)
+}
+
+// FGauge record a floating point gauge
+func (r *Recorder) FGauge(stat string, value float64, tags ...string) error {
+	return r.FGaugeWithSampling(stat, value, 1, tags...)
+}
+
+// FGaugeWithSampling record a floating point gauge, the sample rate is kept but not applied
+func (r *Recorder) FGaugeWithSampling(stat string, value float64, sampleRate float32, tags ...string) error {
+	return r.record(stat, value, "g", sampleRate, tags)
+}
+
+// Close make the next calls return statsd.ErrClosed
+func (r *Recorder) Close() error {
+	r.m.Lock()
+	r.closed = true
+	r.m.Unlock()
+	return nil
+}
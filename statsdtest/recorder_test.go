@@ -0,0 +1,87 @@
+package statsdtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// fakeT capture the failures of the assertions
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_Recorder(t *testing.T) {
+	r := NewRecorder()
+	var s statsd.Statter = r
+
+	s.Incr("orders.created", 2, "shop:a")
+	s.Incr("orders.created", 1, "shop:b")
+	s.Decr("orders.pending", 1)
+	s.Gauge("queue", 3)
+	s.FGauge("queue", 4.5)
+	s.Timing("db", 12, "op:select")
+	s.TimingWithSampling("db", 8, 0.1, "op:select")
+
+	r.AssertIncr(t, "orders.created", 3)
+	r.AssertIncr(t, "orders.created", 2, "shop:a")
+	r.AssertIncr(t, "orders.pending", -1)
+	r.AssertGauge(t, "queue", 4.5)
+	r.AssertTiming(t, "db", 2, "op:select")
+
+	if err := s.Incr("orders.created", 0); err != statsd.ErrInvalidCount {
+		t.Fatalf("err: %v <=> want: %v", err, statsd.ErrInvalidCount)
+	}
+	if err := s.TimingWithSampling("db", 1, 2); err != statsd.ErrInvalidSampleRate {
+		t.Fatalf("err: %v <=> want: %v", err, statsd.ErrInvalidSampleRate)
+	}
+	if m := r.Find("db", "ms"); len(m) != 2 || m[1].SampleRate != 0.1 {
+		t.Fatalf("timers: %v", m)
+	}
+
+	s.Close()
+	if err := s.Incr("orders.created", 1); err != statsd.ErrClosed {
+		t.Fatalf("err: %v <=> want: %v", err, statsd.ErrClosed)
+	}
+
+	r.Reset()
+	if len(r.Metrics()) != 0 {
+		t.Fatalf("metrics after reset: %v", r.Metrics())
+	}
+}
+
+func Test_RecorderAssertFailures(t *testing.T) {
+	r := NewRecorder()
+	r.Incr("orders.created", 1, "shop:a")
+
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+		want   string
+	}{
+		{"count", func(t testing.TB) { r.AssertIncr(t, "orders.created", 3) }, "counter orders.created: 1 <=> want: 3"},
+		{"tags", func(t testing.TB) { r.AssertIncr(t, "orders.created", 1, "shop:b") }, "counter orders.created {shop:b}: 0 <=> want: 1"},
+		{"gauge", func(t testing.TB) { r.AssertGauge(t, "queue", 1) }, "gauge queue not set <=> want: 1"},
+		{"timer", func(t testing.TB) { r.AssertTiming(t, "db", 1) }, "timer db: 0 samples <=> want: 1"},
+	}
+
+	for _, tt := range tests {
+		ft := &fakeT{}
+		tt.assert(ft)
+		if len(ft.errors) != 1 || !strings.HasPrefix(ft.errors[0], tt.want) {
+			t.Errorf("%s: %q <=> want: %q", tt.name, ft.errors, tt.want)
+		}
+		if len(ft.errors) == 1 && !strings.Contains(ft.errors[0], "orders.created:1|c|#shop:a") {
+			t.Errorf("%s: %q <=> want the recorded metrics", tt.name, ft.errors[0])
+		}
+	}
+}
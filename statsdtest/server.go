@@ -0,0 +1,103 @@
+package statsdtest
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/sunlit-coder/statsd/proto"
+	"github.com/sunlit-coder/statsd/server"
+)
+
+// ErrMalformedLine is returned by ParseLine for a line out of the statsd format.
+//
+// Deprecated: use proto.ErrMalformedLine
+var ErrMalformedLine = proto.ErrMalformedLine
+
+// ErrTimeout is returned by Server.Wait when the metrics don't arrive in time
+var ErrTimeout = errors.New("timeout waiting for the metrics")
+
+// Server is a fake statsd server listening on a loopback UDP port, keeping
+// the metrics it receives to query them, so that the integration tests
+// cover the wire path. The malformed lines are kept apart.
+type Server struct {
+	store
+	conn      net.PacketConn
+	malformed []string // guarded by m
+	done      chan struct{}
+}
+
+// NewServer start a server on a free loopback port, see Addr
+func NewServer() (*Server, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{conn: conn, done: make(chan struct{})}
+	go s.serve()
+	return s, nil
+}
+
+// Addr return the address to give to statsd.New
+func (s *Server) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// Close stop the server, the metrics received stay available
+func (s *Server) Close() error {
+	err := s.conn.Close()
+	<-s.done
+	return err
+}
+
+func (s *Server) serve() {
+	defer close(s.done)
+
+	srv := server.Server{
+		Handler: func(metrics []proto.Metric) {
+			s.m.Lock()
+			for _, m := range metrics {
+				s.add(m)
+			}
+			s.m.Unlock()
+		},
+		Malformed: func(lines []string) {
+			s.m.Lock()
+			s.malformed = append(s.malformed, lines...)
+			s.m.Unlock()
+		},
+	}
+	srv.Serve(s.conn)
+}
+
+// Malformed return the lines received which ParseLine refused
+func (s *Server) Malformed() []string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]string(nil), s.malformed...)
+}
+
+// Wait until at least n metrics are received, at most timeout
+func (s *Server) Wait(n int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		s.m.Lock()
+		received := len(s.metrics)
+		s.m.Unlock()
+		if received >= n {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// ParseLine parse a statsd line "<name>:<value>|<type>[|@<rate>][|#<tags>]".
+//
+// Deprecated: use proto.ParseLine
+func ParseLine(line string) (Metric, error) {
+	return proto.ParseLine(line)
+}
@@ -0,0 +1,73 @@
+package statsdtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+func Test_Server(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	c, err := statsd.New(s.Addr(), statsd.WithPrefix("shop"), statsd.WithTags("env:test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Incr("orders.created", 1, "shop:a")
+	c.Timing("db", 12, "op:select")
+	c.Gauge("queue", -2)
+	c.Close() // flush the counters
+
+	if err := s.Wait(4, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	s.AssertIncr(t, "shop.orders.created", 1, "shop:a", "env:test")
+	s.AssertTiming(t, "shop.db", 1, "op:select")
+	s.AssertGauge(t, "shop.queue", -2)
+
+	// a malformed line is kept apart
+	c2, err := statsd.New(s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2.Incr("", 1)
+	c2.Close()
+	for i := 0; i < 100 && len(s.Malformed()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := s.Malformed(); !reflect.DeepEqual(got, []string{":1|c"}) {
+		t.Fatalf("malformed: %q <=> want: [\":1|c\"]", got)
+	}
+	if err := s.Wait(5, 10*time.Millisecond); err != ErrTimeout {
+		t.Fatalf("err: %v <=> want: %v", err, ErrTimeout)
+	}
+}
+
+func Test_ParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Metric
+		err  error
+	}{
+		{"a.b:1|c", Metric{Name: "a.b", Type: "c", Value: 1, SampleRate: 1}, nil},
+		{"a:-1.5|g|@0.500000|#env:prod,route:/", Metric{Name: "a", Type: "g", Value: -1.5, SampleRate: 0.5, Tags: []string{"env:prod", "route:/"}}, nil},
+		{"a:2|ms|@1.000000|c:abc", Metric{Name: "a", Type: "ms", Value: 2, SampleRate: 1, ContainerID: "abc"}, nil},
+		{"a", Metric{}, ErrMalformedLine},
+		{"a:x|c", Metric{}, ErrMalformedLine},
+		{":1|c", Metric{}, ErrMalformedLine},
+		{"a:1|c|@x", Metric{}, ErrMalformedLine},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLine(tt.line)
+		if err != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v (%v) <=> want: %v (%v)", tt.line, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package statsd
+
+import (
+	"context"
+	"fmt"
+)
+
+// StatSender is the interface sending metrics, for the libraries to accept
+// whatever the caller uses: a Client, a NoopClient, a test recorder...
+type StatSender interface {
+	Incr(stat string, count int64, tags ...string) error
+	Decr(stat string, count int64, tags ...string) error
+	Timing(stat string, delta int64, tags ...string) error
+	Gauge(stat string, value int64, tags ...string) error
+	FGauge(stat string, value float64, tags ...string) error
+}
+
+// Statter is the interface of a Client, to depend on in place of the package
+// level helpers, e.g. when the client is provided by dependency injection
+type Statter interface {
+	StatSender
+	IncrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error
+	DecrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error
+	TimingWithSampling(stat string, delta int64, sampleRate float32, tags ...string) error
+	GaugeWithSampling(stat string, value int64, sampleRate float32, tags ...string) error
+	FGaugeWithSampling(stat string, value float64, sampleRate float32, tags ...string) error
+	Close() error
+}
+
+var (
+	_ Statter = (*Client)(nil)
+	_ Statter = NoopClient{}
+	_ Statter = defaultStatter{}
+)
+
+// NewStatter return a Statter built from cfg without touching the package
+// level state: a connected Client, or a NoopClient if cfg doesn't enable
+// stats. Close it on shutdown.
+func NewStatter(ctx context.Context, cfg *Config) (Statter, error) {
+	if cfg == nil || !cfg.Enable {
+		return NoopClient{}, nil
+	}
+
+	return NewContext(ctx, fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), cfg.options()...)
+}
+
+// options return the client options matching cfg
+func (cfg *Config) options() []Option {
+	opts := []Option{
+		WithPrefix(cfg.Project),
+		WithTags(cfg.Tags...),
+		WithExtensions(cfg.Extensions),
+		WithExtensionSchema(cfg.ExtensionSchema),
+		WithContainerID(cfg.ContainerID),
+		WithErrorHandler(cfg.ErrorHandler),
+	}
+	if cfg.SampleRate != 0 {
+		opts = append(opts, WithSampleRate(cfg.SampleRate))
+	}
+	if cfg.SampleSchedule != nil {
+		opts = append(opts, WithSampleSchedule(cfg.SampleSchedule))
+	}
+	if cfg.Sanitizer != nil {
+		opts = append(opts, WithSanitizer(cfg.Sanitizer))
+	}
+	if cfg.NameRules.MaxLength != 0 || cfg.NameRules.Allowed != nil {
+		opts = append(opts, WithNameRules(cfg.NameRules))
+	}
+	if cfg.TagValueLimit > 0 {
+		opts = append(opts, WithTagLimit(cfg.TagValueLimit, cfg.OnTagLimit))
+	}
+	if len(cfg.Allow) > 0 {
+		opts = append(opts, WithAllow(cfg.Allow...))
+	}
+	if len(cfg.Deny) > 0 {
+		opts = append(opts, WithDeny(cfg.Deny...))
+	}
+	if cfg.RateLimit != (RateLimit{}) {
+		opts = append(opts, WithRateLimit(cfg.RateLimit))
+	}
+	if cfg.Protocol != ProtocolDogStatsD {
+		opts = append(opts, WithProtocol(cfg.Protocol))
+	}
+	if cfg.Protocol == ProtocolGraphite {
+		opts = append(opts, WithNetwork("tcp"))
+	}
+	if cfg.ConnectTimeout > 0 {
+		opts = append(opts, WithConnectTimeout(cfg.ConnectTimeout))
+	}
+	if cfg.WriteTimeout > 0 {
+		opts = append(opts, WithWriteTimeout(cfg.WriteTimeout))
+	}
+	if cfg.SendBuffer > 0 {
+		opts = append(opts, WithSocketOptions(SocketOptions{SendBuffer: cfg.SendBuffer}))
+	}
+	if len(cfg.SampleRates) > 0 {
+		opts = append(opts, WithSampleRates(cfg.SampleRates))
+	}
+	if cfg.AggregationWindow > 0 {
+		opts = append(opts, WithAggregation(cfg.AggregationWindow))
+	}
+	if cfg.MaxBatchDelay > 0 {
+		opts = append(opts, WithMaxBatchDelay(cfg.MaxBatchDelay))
+	}
+	if cfg.GapThreshold > 0 {
+		opts = append(opts, WithGapDetection(cfg.GapThreshold))
+	}
+	if len(cfg.Hosts) > 0 {
+		opts = append(opts, WithHosts(cfg.Hosts...))
+	}
+	if cfg.Backup != "" {
+		opts = append(opts, WithFailover(cfg.Backup, FailoverPolicy{}))
+	}
+	if len(cfg.Mirrors) > 0 {
+		opts = append(opts, WithMirrors(cfg.Mirrors...))
+	}
+	if cfg.Logger != nil {
+		opts = append(opts, WithLogger(cfg.Logger))
+	}
+	if cfg.SendWorkers > 1 {
+		opts = append(opts, WithConnections(cfg.SendWorkers))
+	}
+	if cfg.GaugeAllowNegative {
+		opts = append(opts, WithGaugeAllowNegative())
+	}
+	if cfg.StartupBanner {
+		opts = append(opts, WithStartupBanner())
+	}
+	return opts
+}
+
+// NoopClient is a Statter doing nothing, for the disabled metrics in place
+// of nil checks. The zero value is ready to use.
+type NoopClient struct{}
+
+func (NoopClient) Incr(string, int64, ...string) error                          { return nil }
+func (NoopClient) IncrWithSampling(string, int64, float32, ...string) error     { return nil }
+func (NoopClient) Decr(string, int64, ...string) error                          { return nil }
+func (NoopClient) DecrWithSampling(string, int64, float32, ...string) error     { return nil }
+func (NoopClient) Timing(string, int64, ...string) error                        { return nil }
+func (NoopClient) TimingWithSampling(string, int64, float32, ...string) error   { return nil }
+func (NoopClient) Gauge(string, int64, ...string) error                         { return nil }
+func (NoopClient) GaugeWithSampling(string, int64, float32, ...string) error    { return nil }
+func (NoopClient) FGauge(string, float64, ...string) error                      { return nil }
+func (NoopClient) FGaugeWithSampling(string, float64, float32, ...string) error { return nil }
+func (NoopClient) Close() error                                                 { return nil }
+
+// DefaultStatter return a Statter writing with the package level helpers, to
+// the default client of the time of each call: it can be taken before Setup,
+// and sends nothing while the stats are disabled. The integrations use it in
+// place of a nil Statter. Its sends are asynchronous and return nil, Close
+// does nothing, the default client belongs to the package.
+func DefaultStatter() Statter {
+	return defaultStatter{}
+}
+
+type defaultStatter struct{}
+
+func (defaultStatter) Incr(stat string, count int64, tags ...string) error {
+	IncrByVal(stat, count, tags...)
+	return nil
+}
+
+func (defaultStatter) IncrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error {
+	IncrWithSampling(stat, count, sampleRate, tags...)
+	return nil
+}
+
+func (s defaultStatter) Decr(stat string, count int64, tags ...string) error {
+	if cfg := config.Load(); cfg != nil {
+		s.DecrWithSampling(stat, count, cfg.sampleRate(stat), tags...)
+	}
+	return nil
+}
+
+func (defaultStatter) DecrWithSampling(stat string, count int64, sampleRate float32, tags ...string) error {
+	if config.Load() == nil || !enabled.Load() {
+		return nil
+	}
+	if cli := getClient(); cli != nil {
+		handleError(cli.DecrWithSampling(stat, count, sampleRate, tags...))
+	}
+	return nil
+}
+
+func (s defaultStatter) Timing(stat string, delta int64, tags ...string) error {
+	if cfg := config.Load(); cfg != nil {
+		s.TimingWithSampling(stat, delta, cfg.sampleRate(stat), tags...)
+	}
+	return nil
+}
+
+func (defaultStatter) TimingWithSampling(stat string, delta int64, sampleRate float32, tags ...string) error {
+	cfg := config.Load()
+	if cfg == nil || !enabled.Load() {
+		return nil
+	}
+
+	sendInt(cfg, stat, delta, metricTypeTimer, sampleRate, tags)
+	return nil
+}
+
+func (defaultStatter) Gauge(stat string, value int64, tags ...string) error {
+	Gauge(stat, value, tags...)
+	return nil
+}
+
+func (defaultStatter) GaugeWithSampling(stat string, value int64, sampleRate float32, tags ...string) error {
+	GaugeWithSampling(stat, value, sampleRate, tags...)
+	return nil
+}
+
+func (defaultStatter) FGauge(stat string, value float64, tags ...string) error {
+	FGauge(stat, value, tags...)
+	return nil
+}
+
+func (defaultStatter) FGaugeWithSampling(stat string, value float64, sampleRate float32, tags ...string) error {
+	FGaugeWithSampling(stat, value, sampleRate, tags...)
+	return nil
+}
+
+func (defaultStatter) Close() error { return nil }
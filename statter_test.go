@@ -0,0 +1,109 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_NewStatter(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	s, err := NewStatter(context.Background(), &Config{Enable: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(NoopClient); !ok {
+		t.Fatalf("disabled config gave %T, want NoopClient", s)
+	}
+
+	s, err = NewStatter(context.Background(), &Config{
+		Project: "di",
+		Host:    "127.0.0.1",
+		Port:    l.LocalAddr().(*net.UDPAddr).Port,
+		Enable:  true,
+		Tags:    []string{"env:test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Timing("query", 4); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "di.query:4|ms|#env:test", readPacket(t, l); got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+
+	// the schedule of the config applies to the statter too
+	s, err = NewStatter(context.Background(), &Config{
+		Host:           "127.0.0.1",
+		Port:           l.LocalAddr().(*net.UDPAddr).Port,
+		Enable:         true,
+		SampleSchedule: &SampleSchedule{Windows: []SampleWindow{{Start: 0, End: 24 * time.Hour, Rate: 0.25}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got := s.(*Client).rate(""); got != 0.25 {
+		t.Fatalf("rate: %v <=> want: 0.25", got)
+	}
+
+	// a cancelled context stops the connection
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewStatter(ctx, &Config{Host: "127.0.0.1", Port: 8125, Enable: true}); err == nil {
+		t.Fatal("no error with a cancelled context")
+	}
+}
+
+func Test_NoopClient(t *testing.T) {
+	var s StatSender = NoopClient{}
+	calls := []func() error{
+		func() error { return s.Incr("a", 1) },
+		func() error { return s.Decr("a", 1) },
+		func() error { return s.Timing("a", 1) },
+		func() error { return s.Gauge("a", 1) },
+		func() error { return s.FGauge("a", 1.5) },
+	}
+
+	for i, call := range calls {
+		if err := call(); err != nil {
+			t.Errorf("call %d: %v <=> want: nil", i, err)
+		}
+	}
+}
+
+func Test_DefaultStatter(t *testing.T) {
+	s := DefaultStatter() // taken before Setup
+	l := setupTestDefault(t, &Config{Project: "shop", Enable: true})
+
+	s.Incr("orders", 2)
+	s.Decr("stock", 1)
+	s.Timing("pay", 12)
+	s.Gauge("queue", 3)
+	s.FGauge("ratio", 0.5)
+	Default().flush() // the counters are buffered by the client
+	want := []string{"shop.orders:2|c", "shop.pay:12|ms", "shop.queue:3|g", "shop.ratio:0.5|g", "shop.stock:-1|c"}
+	var got []string
+	for len(got) < len(want) {
+		got = append(got, strings.Split(readPacket(t, l), "\n")...)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got: %q <=> want: %q", got, want)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v <=> want: nil", err)
+	}
+}
@@ -0,0 +1,33 @@
+package statsd
+
+import "time"
+
+// Stopwatch capture a start time to track the duration of a event when it ends:
+//
+//	t := statsd.NewTiming()
+//	defer t.Send("db.query")
+//
+// It can't be named Timing which is already the helper taking two times.
+type Stopwatch struct {
+	start time.Time
+}
+
+// NewTiming return a Stopwatch started now
+func NewTiming() Stopwatch {
+	return Stopwatch{start: time.Now()}
+}
+
+// Duration return the time elapsed since the start
+func (s Stopwatch) Duration() time.Duration {
+	return time.Since(s.start)
+}
+
+// Send track the time elapsed since the start
+func (s Stopwatch) Send(stat string) {
+	TimingByValue(stat, s.Duration())
+}
+
+// SendWithTags track the time elapsed since the start with tags
+func (s Stopwatch) SendWithTags(stat string, tags ...string) {
+	TimingByValue(stat, s.Duration(), tags...)
+}
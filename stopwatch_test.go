@@ -0,0 +1,29 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Stopwatch(t *testing.T) {
+	l := setupTestDefault(t, &Config{
+		Project: "stats",
+		Enable:  true,
+	})
+
+	func() {
+		s := NewTiming()
+		defer s.SendWithTags("statsd.stopwatch", "db:orders")
+		time.Sleep(10 * time.Millisecond)
+
+		if d := s.Duration(); d < 10*time.Millisecond {
+			t.Fatalf("duration: %v, want >= 10ms", d)
+		}
+	}()
+
+	got := readPacket(t, l)
+	if !strings.HasPrefix(got, "stats.statsd.stopwatch:") || !strings.HasSuffix(got, "|#db:orders") {
+		t.Fatalf("unexpected timer: %s", got)
+	}
+}
@@ -0,0 +1,63 @@
+package statsd
+
+import (
+	"strconv"
+
+	"github.com/sunlit-coder/statsd/proto"
+)
+
+// ErrInvalidCompressedLine is returned by PrefixDecoder for a malformed reference.
+//
+// Deprecated: use proto.ErrInvalidCompressedLine
+var ErrInvalidCompressedLine = proto.ErrInvalidCompressedLine
+
+// WithNetwork set the network of the connection: "udp" (default), "unixgram",
+// or the stream networks "tcp" and "unix" on which every line ends with a newline
+func WithNetwork(network string) Option {
+	return func(c *Client) {
+		c.network = network
+	}
+}
+
+// WithPrefixCompression elide, on stream networks, the bucket prefix a line
+// shares with the previous line of the same write: "^<n>^<rest>" stands for
+// the first n bytes of the previous line followed by rest. Only servers
+// decoding it with a PrefixDecoder understand it, it is ignored on UDP.
+func WithPrefixCompression() Option {
+	return func(c *Client) {
+		c.compress = true
+	}
+}
+
+func isStream(network string) bool {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+// minCompressedPrefix is the shortest prefix worth a reference
+const minCompressedPrefix = 4
+
+// appendCompressed append line to packet, referencing the bucket prefix it
+// shares with prev
+func appendCompressed(packet []byte, prev string, line string) []byte {
+	n := 0
+	for n < len(prev) && n < len(line) && prev[n] == line[n] && line[n] != ':' {
+		n++
+	}
+	if n < minCompressedPrefix {
+		return append(packet, line...)
+	}
+
+	packet = append(packet, '^')
+	packet = strconv.AppendInt(packet, int64(n), 10)
+	packet = append(packet, '^')
+	return append(packet, line[n:]...)
+}
+
+// PrefixDecoder expand the lines of a stream written with WithPrefixCompression.
+//
+// Deprecated: use proto.PrefixDecoder
+type PrefixDecoder = proto.PrefixDecoder
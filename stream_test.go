@@ -0,0 +1,112 @@
+package statsd
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_appendCompressed(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		line string
+		want string
+	}{
+		{
+			name: "first-line",
+			prev: "",
+			line: "api.checkout.pay:1|c",
+			want: "api.checkout.pay:1|c",
+		},
+		{
+			name: "shared-prefix",
+			prev: "api.checkout.pay:1|c",
+			line: "api.checkout.cart:2|c",
+			want: "^13^cart:2|c",
+		},
+		{
+			name: "same-bucket-stops-at-colon",
+			prev: "api.checkout.pay:1|c",
+			line: "api.checkout.pay:2|c",
+			want: "^16^:2|c",
+		},
+		{
+			name: "short-prefix",
+			prev: "api.pay:1|c",
+			line: "app.cart:1|c",
+			want: "app.cart:1|c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(appendCompressed(nil, tt.prev, tt.line))
+			if got != tt.want {
+				t.Fatalf("[%s] got: %s <=> want: %s", tt.name, got, tt.want)
+			}
+
+			var d PrefixDecoder
+			d.Decode(tt.prev)
+			if decoded, err := d.Decode(got); err != nil || decoded != tt.line {
+				t.Fatalf("[%s] decoded: %s (%v) <=> want: %s", tt.name, decoded, err, tt.line)
+			}
+		})
+	}
+
+	var d PrefixDecoder
+	for _, line := range []string{"^3", "^x^a", "^5^a"} {
+		if _, err := d.Decode(line); err != ErrInvalidCompressedLine {
+			t.Fatalf("[%s] err: %v <=> want: %v", line, err, ErrInvalidCompressedLine)
+		}
+	}
+}
+
+func Test_StreamCompression(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.Addr().String(), WithNetwork("tcp"), WithPrefix("api.checkout"), WithPrefixCompression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c.Timing("cart.load", 1)
+	c.GaugeGroup(map[string]float64{"cart.items": 3, "cart.total": 10})
+
+	want := []string{
+		"api.checkout.cart.load:1|ms",
+		"api.checkout.cart.items:3|g",
+		"api.checkout.cart.total:10|g",
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	scanner := bufio.NewScanner(conn)
+	var d PrefixDecoder
+	compressed := 0
+	for _, w := range want {
+		if !scanner.Scan() {
+			t.Fatal(scanner.Err())
+		}
+		if strings.HasPrefix(scanner.Text(), "^") {
+			compressed++
+		}
+		if got, err := d.Decode(scanner.Text()); err != nil || got != w {
+			t.Fatalf("got: %s (%v) <=> want: %s", got, err, w)
+		}
+	}
+	if compressed != 1 {
+		t.Fatalf("compressed lines: %d <=> want: 1", compressed)
+	}
+}
@@ -0,0 +1,68 @@
+package statsd
+
+// Strict return the package level helpers returning their errors instead of
+// passing them to Config.ErrorHandler, for the libraries propagating the
+// instrumentation failures to their health checks. The metrics are sent
+// synchronously with the default client and the global sample rate: the
+// calls return ErrNotConnected until it connects, and nil while the helpers
+// are disabled or not set up.
+func Strict() StatSender {
+	return strictHelpers{}
+}
+
+type strictHelpers struct{}
+
+var _ StatSender = strictHelpers{}
+
+// client return the default client and the config snapshot, nil if the
+// helpers send nothing
+func (strictHelpers) client() (*Client, *Config, error) {
+	cfg := config.Load()
+	if cfg == nil || !enabled.Load() {
+		return nil, nil, nil
+	}
+	if cli := getClient(); cli != nil {
+		return cli, cfg, nil
+	}
+	return nil, nil, ErrNotConnected
+}
+
+func (s strictHelpers) Incr(stat string, count int64, tags ...string) error {
+	cli, cfg, err := s.client()
+	if cli == nil {
+		return err
+	}
+	return cli.IncrWithSampling(stat, count, cfg.sampleRate(stat), tags...)
+}
+
+func (s strictHelpers) Decr(stat string, count int64, tags ...string) error {
+	cli, cfg, err := s.client()
+	if cli == nil {
+		return err
+	}
+	return cli.DecrWithSampling(stat, count, cfg.sampleRate(stat), tags...)
+}
+
+func (s strictHelpers) Timing(stat string, delta int64, tags ...string) error {
+	cli, cfg, err := s.client()
+	if cli == nil {
+		return err
+	}
+	return cli.TimingWithSampling(stat, delta, cfg.sampleRate(stat), tags...)
+}
+
+func (s strictHelpers) Gauge(stat string, value int64, tags ...string) error {
+	cli, cfg, err := s.client()
+	if cli == nil {
+		return err
+	}
+	return cli.GaugeWithSampling(stat, value, cfg.sampleRate(stat), tags...)
+}
+
+func (s strictHelpers) FGauge(stat string, value float64, tags ...string) error {
+	cli, cfg, err := s.client()
+	if cli == nil {
+		return err
+	}
+	return cli.FGaugeWithSampling(stat, value, cfg.sampleRate(stat), tags...)
+}
@@ -0,0 +1,37 @@
+package statsd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Strict(t *testing.T) {
+	l := setupTestDefault(t, &Config{Project: "lib", Enable: true})
+	s := Strict()
+
+	if err := s.Gauge("queue", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPacket(t, l); got != "lib.queue:1|g" {
+		t.Fatalf("got: %s <=> want: lib.queue:1|g", got)
+	}
+	if err := s.Incr("hits", 0); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidCount)
+	}
+
+	// nothing to report while disabled
+	SetEnabled(false)
+	if err := s.Timing("db", 1); err != nil {
+		t.Fatalf("err: %v <=> want: nil", err)
+	}
+
+	// the failed connection is returned, not only reported
+	defer func(d time.Duration) { reconnectMinDelay = d }(reconnectMinDelay)
+	reconnectMinDelay = time.Hour
+	restoreDefault(t)
+	Setup(&Config{Host: "256.0.0.1", Port: 1, Enable: true})
+	if err := s.Decr("slots", 1); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrNotConnected)
+	}
+}
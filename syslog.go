@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package statsd
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// NewSyslogFallback return a writer to the local syslog daemon (journald
+// included) for WithFallback, logging at warning level with the given tag
+func NewSyslogFallback(tag string) (io.Writer, error) {
+	return syslog.New(syslog.LOG_WARNING|syslog.LOG_DAEMON, tag)
+}
@@ -0,0 +1,101 @@
+package statsd
+
+import (
+	"strings"
+	"sync"
+)
+
+// tagOther replace the values of a tag past the limit of WithTagLimit
+const tagOther = "other"
+
+// WithTagLimit bound the distinct values of each tag of a metric to limit,
+// the further values being sent as "other", e.g. "user:other", so that an
+// unbounded tag like a user ID can't melt the aggregator. fn, if not nil, is
+// called the first time a tag of a bucket passes the limit, with the value
+// replaced. The values seen are kept for the life of the client.
+func WithTagLimit(limit int, fn func(stat, key, value string)) Option {
+	return func(c *Client) {
+		c.tagLimit = &tagLimiter{limit: limit, onLimit: fn}
+	}
+}
+
+// tagLimiter track the tag values of WithTagLimit
+type tagLimiter struct {
+	limit   int
+	onLimit func(stat, key, value string)
+
+	m      sync.Mutex
+	values map[tagKey]*tagValues
+}
+
+type tagKey struct {
+	prefix string
+	stat   string
+	key    string
+}
+
+type tagValues struct {
+	seen    map[string]struct{}
+	limited bool // onLimit called
+}
+
+// limitTags replace the values past the limit by tagOther for the bucket of
+// prefix and stat, tags is left untouched
+func (l *tagLimiter) limitTags(prefix, stat string, tags []string) []string {
+	if l == nil || len(tags) == 0 {
+		return tags
+	}
+
+	type limited struct{ key, value string }
+	var (
+		replaced []string
+		calls    []limited
+	)
+	l.m.Lock()
+	for i, tag := range tags {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok || v == tagOther {
+			continue
+		}
+		tk := tagKey{prefix, stat, k}
+		vs := l.values[tk]
+		if vs == nil {
+			if l.values == nil {
+				l.values = make(map[tagKey]*tagValues)
+			}
+			vs = &tagValues{seen: make(map[string]struct{})}
+			l.values[tk] = vs
+		}
+		if _, ok := vs.seen[v]; ok {
+			continue
+		}
+		if len(vs.seen) < l.limit {
+			vs.seen[v] = struct{}{}
+			continue
+		}
+
+		if replaced == nil {
+			replaced = append([]string(nil), tags...)
+		}
+		replaced[i] = k + ":" + tagOther
+		if !vs.limited {
+			vs.limited = true
+			calls = append(calls, limited{k, v})
+		}
+	}
+	l.m.Unlock()
+
+	if l.onLimit != nil && len(calls) > 0 {
+		bucket := stat
+		if prefix != "" {
+			bucket = prefix + "." + stat
+		}
+		for _, call := range calls {
+			l.onLimit(bucket, call.key, call.value)
+		}
+	}
+	if replaced == nil {
+		return tags
+	}
+	return replaced
+}
@@ -0,0 +1,71 @@
+package statsd
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func Test_WithTagLimit(t *testing.T) {
+	_, l := newTestClient(t, "")
+	var limited []string
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithTagLimit(2, func(stat, key, value string) {
+		limited = append(limited, stat+" "+key+":"+value)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		c    *Client
+		stat string
+		tags []string
+		want string
+	}{
+		{c, "login", []string{"user:1", "route:/"}, "api.login:1|ms|#user:1,route:/"},
+		{c, "login", []string{"user:2", "route:/"}, "api.login:1|ms|#user:2,route:/"},
+		{c, "login", []string{"user:3", "route:/"}, "api.login:1|ms|#user:other,route:/"},
+		{c, "login", []string{"user:4", "route:/a"}, "api.login:1|ms|#user:other,route:/a"},
+		// the values seen before the limit are kept
+		{c, "login", []string{"user:1", "route:/b"}, "api.login:1|ms|#user:1,route:other"},
+		// each bucket has its own limit
+		{c, "logout", []string{"user:3"}, "api.logout:1|ms|#user:3"},
+		{c.WithPrefix("admin"), "login", []string{"user:3"}, "api.admin.login:1|ms|#user:3"},
+	}
+	for i, tt := range tests {
+		tags := append([]string(nil), tt.tags...)
+		if err := tt.c.Timing(tt.stat, 1, tags...); err != nil {
+			t.Fatal(err)
+		}
+		if got := readPacket(t, l); got != tt.want {
+			t.Errorf("%d: got: %q <=> want: %q", i, got, tt.want)
+		}
+		for j := range tags {
+			if tags[j] != tt.tags[j] {
+				t.Fatalf("%d: call tags modified: %v", i, tags)
+			}
+		}
+	}
+
+	// the callback is called once per tag of a bucket
+	want := []string{"api.login user:3", "api.login route:/b"}
+	if len(limited) != len(want) || limited[0] != want[0] || limited[1] != want[1] {
+		t.Fatalf("limited: %q <=> want: %q", limited, want)
+	}
+
+	// the buffered counters too
+	for i := 0; i < 5; i++ {
+		c.Incr("hits", 1, "user:"+strconv.Itoa(i))
+	}
+	c.m.Lock()
+	other := c.buffer[c.bufferIndex[countKey{name: "api.hits", tags: "user:other", rate: 1}]]
+	c.m.Unlock()
+	if other.count != 3 {
+		t.Fatalf("other: %+v", other)
+	}
+
+	if _, err := New(l.LocalAddr().String(), WithTagLimit(0, nil)); !errors.Is(err, ErrInvalidTagLimit) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidTagLimit)
+	}
+}
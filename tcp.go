@@ -0,0 +1,218 @@
+package statsd
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+)
+
+// connectTimeout is the default bound of the dial and the TLS handshake, see
+// WithConnectTimeout
+var connectTimeout = 5 * time.Second
+
+// reconnectInterval is the shortest time between two reconnections of a
+// stream connection, the writes in between fail with ErrNotConnected
+var reconnectInterval = time.Second
+
+// WithTLS wrap the "tcp" connection in TLS, the server name defaults to the host of addr
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithConnectTimeout bound each dial and TLS handshake to d instead of 5s
+func WithConnectTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.connectTimeout = d
+	}
+}
+
+// WithWriteTimeout bound each write to the connection to d, so that a
+// blocked TCP connection fails the writes instead of stalling the senders.
+// A write timing out on a stream network drops the connection, redialed by
+// the next write. The writes have no deadline if 0, the default.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.writeTimeout = d
+	}
+}
+
+// setWriteDeadline set the deadline of the next write to conn, of WithWriteTimeout
+func (c *clientConn) setWriteDeadline(conn net.Conn) {
+	if c.writeTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+}
+
+// DialFunc connect to addr on network, as net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialer connect with dial instead of a net.Dialer, e.g. through a SOCKS
+// proxy, with socket options or from a source address. The dials are bound
+// by their context, the one of NewContext for the first one, and by the
+// connect timeout. TLS is still done by the client.
+func WithDialer(dial DialFunc) Option {
+	return func(c *Client) {
+		c.dialer = dial
+	}
+}
+
+// connStats are the statistics of the connection sent with WithTelemetry,
+// guarded by connMu
+type connStats struct {
+	reconnects       int64
+	connectTime      []time.Duration // since the last flush
+	handshakeTime    []time.Duration
+	disconnected     time.Duration // time spent disconnected since the last flush
+	disconnectedAt   time.Time     // when the connection was lost, zero while connected
+	lastReconnectTry time.Time
+}
+
+// dial connect to the server, timing the connection and the TLS handshake
+func (c *clientConn) dial(ctx context.Context) (net.Conn, error) {
+	dial := c.dialer
+	if dial == nil {
+		dial = (&net.Dialer{Control: c.socketOptions.Control}).DialContext
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, c.connectTimeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := dial(dialCtx, c.network, c.addr)
+	if err != nil {
+		c.log(slog.LevelWarn, "statsd: connect failed", "err", err)
+		return nil, err
+	}
+	connectTime := time.Since(start)
+	if err := c.setSendBuffer(conn); err != nil {
+		conn.Close()
+		c.log(slog.LevelWarn, "statsd: socket options failed", "err", err)
+		return nil, err
+	}
+
+	var handshakeTime time.Duration
+	if c.tlsConfig != nil {
+		cfg := c.tlsConfig
+		if cfg.ServerName == "" {
+			cfg = cfg.Clone()
+			cfg.ServerName, _, _ = net.SplitHostPort(c.addr)
+		}
+
+		// a stalled peer must not block the writes holding connMu
+		conn.SetDeadline(time.Now().Add(c.connectTimeout))
+		tlsConn := tls.Client(conn, cfg)
+		start = time.Now()
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			c.log(slog.LevelWarn, "statsd: TLS handshake failed", "err", err)
+			return nil, err
+		}
+		handshakeTime = time.Since(start)
+		conn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+	trackConn(c.network, 1)
+	c.log(slog.LevelDebug, "statsd: connected", "connect_time", connectTime)
+
+	if isStream(c.network) {
+		c.connStats.connectTime = append(c.connStats.connectTime, connectTime)
+		if c.tlsConfig != nil {
+			c.connStats.handshakeTime = append(c.connStats.handshakeTime, handshakeTime)
+		}
+	}
+	return conn, nil
+}
+
+// writeStream write a packet on a stream connection, reconnecting it if it was
+// lost. A failed write drops the connection, as part of the packet may have
+// been written the next line would be corrupted.
+func (c *clientConn) writeStream(packet []byte) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn == nil {
+		if err := c.reconnect(); err != nil {
+			c.addDeadLetter(string(packet), err)
+			return err
+		}
+	}
+
+	c.setWriteDeadline(c.conn)
+	if _, err := c.conn.Write(packet); err != nil {
+		c.conn.Close()
+		trackConn(c.network, -1)
+		c.conn = nil
+		c.connStats.disconnectedAt = time.Now()
+		c.log(slog.LevelWarn, "statsd: connection lost", "err", err)
+
+		c.addDeadLetter(string(packet), err)
+		return err
+	}
+	return nil
+}
+
+// reconnect a lost stream connection, at most once per reconnectInterval
+func (c *clientConn) reconnect() error {
+	if time.Since(c.connStats.lastReconnectTry) < reconnectInterval {
+		return ErrNotConnected
+	}
+	c.connStats.lastReconnectTry = time.Now()
+
+	conn, err := c.dial(context.Background())
+	if err != nil {
+		return err
+	}
+
+	c.conn = conn
+	c.log(slog.LevelInfo, "statsd: reconnected")
+	c.connStats.reconnects++
+	c.stats.reconnects.Add(1)
+	if !c.connStats.disconnectedAt.IsZero() {
+		c.connStats.disconnected += time.Since(c.connStats.disconnectedAt)
+		c.connStats.disconnectedAt = time.Time{}
+	}
+	return nil
+}
+
+// connTelemetryLines return the lines of the connection statistics since the
+// last call, tagged with the endpoint, for stream networks only
+func (c *Client) connTelemetryLines() []string {
+	if !isStream(c.network) {
+		return nil
+	}
+
+	c.connMu.Lock()
+	stats := c.connStats
+	c.connStats.reconnects = 0
+	c.connStats.connectTime = nil
+	c.connStats.handshakeTime = nil
+	c.connStats.disconnected = 0
+	if !stats.disconnectedAt.IsZero() {
+		// still disconnected, the time until now belongs to this interval
+		stats.disconnected += time.Since(stats.disconnectedAt)
+		c.connStats.disconnectedAt = time.Now()
+	}
+	c.connMu.Unlock()
+
+	tag := "endpoint:" + c.addr
+	lines := []string{
+		c.format("statsd.client.conn.reconnects", stats.reconnects, "c", 1, tag),
+		c.format("statsd.client.conn.disconnected", milliseconds(stats.disconnected), "ms", 1, tag),
+	}
+	for _, d := range stats.connectTime {
+		lines = append(lines, c.format("statsd.client.conn.connect_time", milliseconds(d), "ms", 1, tag))
+	}
+	for _, d := range stats.handshakeTime {
+		lines = append(lines, c.format("statsd.client.conn.tls_handshake", milliseconds(d), "ms", 1, tag))
+	}
+	return lines
+}
+
+// milliseconds format d in fractional milliseconds, connections are often faster than 1ms
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
@@ -0,0 +1,234 @@
+package statsd
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Reconnect(t *testing.T) {
+	defer func(d time.Duration) { reconnectInterval = d }(reconnectInterval)
+	reconnectInterval = 0
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.Addr().String(), WithNetwork("tcp"), WithTelemetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the server drops the connection, the writes fail until the client notices
+	conn.(*net.TCPConn).SetLinger(0)
+	conn.Close()
+	for i := 0; i < 100 && c.Timing("lost", 1) == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := c.Timing("found", 1); err != nil {
+		t.Fatal(err)
+	}
+	conn, err = l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() || scanner.Text() != "found:1|ms" {
+		t.Fatalf("line: %q (%v) <=> want: found:1|ms", scanner.Text(), scanner.Err())
+	}
+
+	lines := strings.Join(c.connTelemetryLines(), "\n")
+	tag := "|#endpoint:" + l.Addr().String()
+	for _, want := range []string{
+		"statsd.client.conn.reconnects:1|c" + tag,
+		"statsd.client.conn.disconnected:",
+		"statsd.client.conn.connect_time:",
+	} {
+		if !strings.Contains(lines, want) {
+			t.Fatalf("telemetry: %s <=> want: %s", lines, want)
+		}
+	}
+	if strings.Count(lines, "connect_time") != 2 {
+		t.Fatalf("telemetry: %s <=> want: 2 connect_time", lines)
+	}
+
+	// the statistics are reset after each flush
+	lines = strings.Join(c.connTelemetryLines(), "\n")
+	if !strings.Contains(lines, "statsd.client.conn.reconnects:0|c") || strings.Contains(lines, "connect_time") {
+		t.Fatalf("telemetry not reset: %s", lines)
+	}
+}
+
+func Test_WithTLS(t *testing.T) {
+	// borrow the certificate of the test server, valid for 127.0.0.1
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				bufio.NewReader(conn).ReadString('\n')
+			}()
+		}
+	}()
+
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	c, err := New(l.Addr().String(), WithNetwork("tcp"), WithTelemetry(), WithTLS(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	lines := strings.Join(c.connTelemetryLines(), "\n")
+	if !strings.Contains(lines, "statsd.client.conn.tls_handshake:") {
+		t.Fatalf("telemetry: %s <=> want: statsd.client.conn.tls_handshake", lines)
+	}
+
+	// an untrusted certificate fails the connection
+	if _, err := New(l.Addr().String(), WithNetwork("tcp"), WithTLS(&tls.Config{})); err == nil {
+		t.Fatal("connected with an untrusted certificate")
+	}
+}
+
+func Test_TLSHandshakeTimeout(t *testing.T) {
+	defer func(d time.Duration) { connectTimeout = d }(connectTimeout)
+	connectTimeout = 100 * time.Millisecond
+
+	// the peer accepts the connection but never answers the handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := New(l.Addr().String(), WithNetwork("tcp"), WithTLS(&tls.Config{}))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("connected to a stalled peer")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handshake not bounded by the connect timeout")
+	}
+}
+
+func Test_WithDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// the dialer is given the address, it may connect elsewhere
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+addr)
+		var d net.Dialer
+		return d.DialContext(ctx, network, l.Addr().String())
+	}
+	c, err := New("metrics.internal:8125", WithNetwork("tcp"), WithDialer(dial))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if len(dialed) != 1 || dialed[0] != "tcp metrics.internal:8125" {
+		t.Fatalf("dialed: %q", dialed)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// the first dial is canceled with the context of NewContext
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := NewContext(ctx, l.Addr().String(), WithNetwork("tcp"), WithDialer(blocked)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err: %v <=> want: %v", err, context.Canceled)
+	}
+
+	// and bound by the connect timeout
+	defer func(d time.Duration) { connectTimeout = d }(connectTimeout)
+	connectTimeout = 10 * time.Millisecond
+	if _, err := New(l.Addr().String(), WithNetwork("tcp"), WithDialer(blocked)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err: %v <=> want: %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_WithWriteTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c, err := New(l.Addr().String(), WithNetwork("tcp"), WithWriteTimeout(20*time.Millisecond), WithConnectTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// the server doesn't read, the writes time out once the buffers are full
+	// instead of blocking
+	packet := []byte(strings.Repeat("x", 64<<10))
+	start := time.Now()
+	for time.Since(start) < 10*time.Second {
+		if err = c.write(packet); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("err: %v <=> want: %v", err, os.ErrDeadlineExceeded)
+	}
+
+	if _, err := New(l.Addr().String(), WithWriteTimeout(-1)); err != ErrInvalidTimeout {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidTimeout)
+	}
+	if _, err := New(l.Addr().String(), WithConnectTimeout(0)); err != ErrInvalidTimeout {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidTimeout)
+	}
+}
@@ -0,0 +1,118 @@
+package statsd
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Telemetry is the statistics of a client since its creation, shared with
+// its children, see Client.Telemetry
+type Telemetry struct {
+	MetricsSent  int64 // lines written
+	BytesWritten int64
+	Dropped      int64 // metrics dropped by a full queue, of WithRealTime or of the helpers
+	WriteErrors  int64 // failed packet writes
+	Reconnects   int64 // of the stream connections
+	RateLimited  int64 // metrics dropped by WithRateLimit
+}
+
+// clientStats is the live counterpart of Telemetry
+type clientStats struct {
+	metricsSent  atomic.Int64
+	bytesWritten atomic.Int64
+	dropped      atomic.Int64
+	writeErrors  atomic.Int64
+	reconnects   atomic.Int64
+	rateLimited  atomic.Int64
+
+	m        sync.Mutex
+	reported Telemetry // at the last flush, for the deltas of WithTelemetry
+}
+
+// Telemetry return the statistics of the client since its creation
+func (c *Client) Telemetry() Telemetry {
+	return Telemetry{
+		MetricsSent:  c.stats.metricsSent.Load(),
+		BytesWritten: c.stats.bytesWritten.Load(),
+		Dropped:      c.stats.dropped.Load(),
+		WriteErrors:  c.stats.writeErrors.Load(),
+		Reconnects:   c.stats.reconnects.Load(),
+		RateLimited:  c.stats.rateLimited.Load(),
+	}
+}
+
+// trackWrite count the result of a packet write
+func (c *clientConn) trackWrite(packet []byte, err error) {
+	if err != nil {
+		c.stats.writeErrors.Add(1)
+		return
+	}
+	c.stats.metricsSent.Add(int64(bytes.Count(packet, []byte{'\n'}) + 1))
+	c.stats.bytesWritten.Add(int64(len(packet)))
+}
+
+// openConns count the connections held by the package, by network,
+// across every client, so that a leaking reconnect logic is visible
+var openConns = struct {
+	sync.Mutex
+	n map[string]int64
+}{n: make(map[string]int64)}
+
+func trackConn(network string, delta int64) {
+	openConns.Lock()
+	openConns.n[network] += delta
+	openConns.Unlock()
+}
+
+// OpenConns return the number of connections currently held by the package,
+// by network ("udp", "tcp", "unix", ...)
+func OpenConns() map[string]int64 {
+	openConns.Lock()
+	defer openConns.Unlock()
+
+	n := make(map[string]int64, len(openConns.n))
+	for network, cnt := range openConns.n {
+		n[network] = cnt
+	}
+	return n
+}
+
+// telemetryLines return the lines of the metrics about the package itself
+func (c *Client) telemetryLines() []string {
+	conns := OpenConns()
+	networks := make([]string, 0, len(conns))
+	for network := range conns {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	lines := make([]string, 0, len(networks)+4)
+	for _, network := range networks {
+		lines = append(lines, c.format("statsd.client.conns."+network, conns[network], "g", 1))
+	}
+	lines = append(lines, c.statsTelemetryLines()...)
+	return append(lines, c.connTelemetryLines()...)
+}
+
+// statsTelemetryLines return the counters of the client statistics since the
+// last call, the reconnections are in the connection statistics
+func (c *Client) statsTelemetryLines() []string {
+	now := c.Telemetry()
+	c.stats.m.Lock()
+	prev := c.stats.reported
+	c.stats.reported = now
+	c.stats.m.Unlock()
+
+	lines := []string{
+		c.format("statsd.client.metrics_sent", now.MetricsSent-prev.MetricsSent, "c", 1),
+		c.format("statsd.client.bytes_written", now.BytesWritten-prev.BytesWritten, "c", 1),
+		c.format("statsd.client.dropped", now.Dropped-prev.Dropped, "c", 1),
+		c.format("statsd.client.write_errors", now.WriteErrors-prev.WriteErrors, "c", 1),
+	}
+	if c.limiter != nil {
+		lines = append(lines, c.format("statsd.client.rate_limited", now.RateLimited-prev.RateLimited, "c", 1))
+	}
+	return lines
+}
@@ -0,0 +1,77 @@
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_OpenConns(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	before := OpenConns()["udp"]
+
+	c, err := New(l.LocalAddr().String(), WithPrefix("proj"), WithTelemetry(), WithFlushInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := OpenConns()["udp"]; got != before+1 {
+		t.Fatalf("open udp conns: %d <=> want: %d", got, before+1)
+	}
+
+	// the gauge is sent at flush even without any buffered counter
+	if got := readPacket(t, l); !strings.Contains(got, "proj.statsd.client.conns.udp:") {
+		t.Fatalf("unexpected telemetry packet: %s", got)
+	}
+
+	c.Close()
+	if got := OpenConns()["udp"]; got != before {
+		t.Fatalf("open udp conns after close: %d <=> want: %d", got, before)
+	}
+}
+
+func Test_ClientTelemetry(t *testing.T) {
+	c, l := newTestClient(t, "")
+
+	c.Timing("db", 3)
+	c.sendLines([]string{"a:1|g", "b:2|g"})
+	readPacket(t, l)
+	readPacket(t, l)
+	want := Telemetry{MetricsSent: 3, BytesWritten: int64(len("db:3|ms") + len("a:1|g\nb:2|g"))}
+	if got := c.Telemetry(); got != want {
+		t.Fatalf("telemetry: %+v <=> want: %+v", got, want)
+	}
+
+	// the children share the statistics, the counters report the deltas
+	c.WithPrefix("api").Timing("db", 3)
+	readPacket(t, l)
+	lines := c.statsTelemetryLines()
+	wantLines := []string{
+		"statsd.client.metrics_sent:4|c",
+		"statsd.client.bytes_written:" + strconv.FormatInt(want.BytesWritten+int64(len("api.db:3|ms")), 10) + "|c",
+		"statsd.client.dropped:0|c",
+		"statsd.client.write_errors:0|c",
+	}
+	if strings.Join(lines, "\n") != strings.Join(wantLines, "\n") {
+		t.Fatalf("lines:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(wantLines, "\n"))
+	}
+	if got := c.statsTelemetryLines()[0]; got != "statsd.client.metrics_sent:0|c" {
+		t.Fatalf("second interval: %s", got)
+	}
+
+	failing, err := New("", WithSink(failSink{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failing.Close()
+	failing.Timing("db", 3)
+	if got := failing.Telemetry(); got != (Telemetry{WriteErrors: 1}) {
+		t.Fatalf("telemetry: %+v <=> want one write error", got)
+	}
+}
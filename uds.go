@@ -0,0 +1,100 @@
+package statsd
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// UDSFailover configure the sink of NewUDSFailover
+type UDSFailover struct {
+	Socket string // path of the "unixgram" socket of the local agent
+	Addr   string // "host:port" of the UDP relay
+
+	// MaxLatency fail over when a write to the socket takes longer, i.e. the
+	// agent backs up, 1ms if 0
+	MaxLatency time.Duration
+
+	// RetryInterval is how often the socket is tried again while failed over, 1s if 0
+	RetryInterval time.Duration
+}
+
+// NewUDSFailover return a Sink, for WithSink, preferring the local agent on
+// the unix socket and failing over to the UDP relay when the socket errors
+// or backs up, switching back once the agent recovers. Only the UDP relay
+// must be reachable at creation.
+func NewUDSFailover(cfg UDSFailover) (Sink, error) {
+	if cfg.MaxLatency <= 0 {
+		cfg.MaxLatency = time.Millisecond
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = time.Second
+	}
+
+	udp, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &udsFailover{cfg: cfg, udp: udp}
+	if s.uds, err = net.Dial("unixgram", cfg.Socket); err != nil {
+		s.failedAt = time.Now()
+	}
+	return s, nil
+}
+
+type udsFailover struct {
+	cfg UDSFailover
+	udp net.Conn
+
+	m        sync.Mutex
+	uds      net.Conn  // nil while failed over
+	failedAt time.Time // last failure of the socket
+}
+
+func (s *udsFailover) Write(packet []byte) error {
+	if uds := s.socket(); uds != nil {
+		uds.SetWriteDeadline(time.Now().Add(s.cfg.MaxLatency))
+		if _, err := uds.Write(packet); err == nil {
+			return nil
+		}
+		s.fail(uds)
+	}
+
+	_, err := s.udp.Write(packet)
+	return err
+}
+
+// socket return the connection to the agent, nil while failed over unless
+// it is time to try it again
+func (s *udsFailover) socket() net.Conn {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.uds == nil && time.Since(s.failedAt) >= s.cfg.RetryInterval {
+		s.failedAt = time.Now()
+		s.uds, _ = net.Dial("unixgram", s.cfg.Socket)
+	}
+	return s.uds
+}
+
+// fail over to UDP after a failed write to uds
+func (s *udsFailover) fail(uds net.Conn) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.uds == uds { // not failed over by a concurrent write meanwhile
+		s.uds.Close()
+		s.uds = nil
+		s.failedAt = time.Now()
+	}
+}
+
+func (s *udsFailover) Close() error {
+	s.m.Lock()
+	if s.uds != nil {
+		s.uds.Close()
+		s.uds = nil
+	}
+	s.m.Unlock()
+	return s.udp.Close()
+}
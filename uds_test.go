@@ -0,0 +1,56 @@
+//go:build !windows
+
+package statsd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_NewUDSFailover(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "statsd.sock")
+	agent, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, relay := newTestClient(t, "")
+
+	sink, err := NewUDSFailover(UDSFailover{Socket: socket, Addr: relay.LocalAddr().String(), RetryInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New("", WithSink(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the agent is preferred
+	c.Timing("a", 1)
+	if got := readPacket(t, agent); got != "a:1|ms" {
+		t.Fatalf("agent got: %s <=> want: a:1|ms", got)
+	}
+
+	// then the relay while the agent is down
+	agent.Close()
+	os.Remove(socket)
+	c.Timing("b", 1)
+	if got := readPacket(t, relay); got != "b:1|ms" {
+		t.Fatalf("relay got: %s <=> want: b:1|ms", got)
+	}
+
+	// and the agent again once it recovers
+	agent, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+	time.Sleep(20 * time.Millisecond)
+	c.Timing("c", 1)
+	if got := readPacket(t, agent); got != "c:1|ms" {
+		t.Fatalf("agent got: %s <=> want: c:1|ms", got)
+	}
+}
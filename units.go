@@ -0,0 +1,126 @@
+package statsd
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unit suffixes appended by the typed helpers, so that bucket names follow
+// the naming conventions without relying on review
+const (
+	unitSuffixMillis = "_ms"
+	unitSuffixBytes  = "_bytes"
+	unitSuffixTotal  = "_total"
+)
+
+// withUnit append the unit suffix to stat unless it already ends with it
+func withUnit(stat string, suffix string) string {
+	if stat == "" || strings.HasSuffix(stat, suffix) {
+		return stat
+	}
+
+	return stat + suffix
+}
+
+// TimingDuration track duration of a event, the bucket is suffixed with _ms
+// and the duration sent in whole ms whatever the timing unit of the default
+// client
+func TimingDuration(stat string, d time.Duration, tags ...string) {
+	cfg := config.Load()
+	if cfg == nil || !enabled.Load() {
+		return
+	}
+
+	stat = withUnit(stat, unitSuffixMillis)
+	sendInt(cfg, stat, int64(d/time.Millisecond), metricTypeTimer, cfg.sampleRate(stat), tags)
+}
+
+// WithTimingUnit set the resolution of Client.TimingDuration, the durations
+// are sent as a count of unit (default time.Millisecond). The statsd servers
+// read the timers as milliseconds, a finer unit is for the backends told so.
+func WithTimingUnit(unit time.Duration) Option {
+	return func(c *Client) {
+		c.timingUnit = unit
+	}
+}
+
+// WithFloatTimings send the durations of TimingDuration with their fraction
+// of the timing unit, e.g. "0.437|ms" in place of "0|ms". The buffered timers
+// of WithAggregation and WithBufferedMetrics stay whole, pair it with
+// WithTimingUnit instead.
+func WithFloatTimings() Option {
+	return func(c *Client) {
+		c.floatTimings = true
+	}
+}
+
+// TimingDuration track a duration event, converted with the timing unit of
+// the client. Unlike the package TimingDuration the bucket isn't suffixed,
+// the unit being configurable.
+func (c *Client) TimingDuration(stat string, d time.Duration, tags ...string) error {
+	return c.TimingDurationWithSampling(stat, d, c.rate(stat), tags...)
+}
+
+// TimingDurationWithSampling track a duration event with sampling between 0 and 1
+func (c *Client) TimingDurationWithSampling(stat string, d time.Duration, sampleRate float32, tags ...string) (err error) {
+	defer func() {
+		if err != nil {
+			err = newSendError(err, "TimingDuration", stat, d)
+		}
+	}()
+	if !c.floatTimings || c.aggregate {
+		return c.TimingWithSampling(stat, int64(d/c.timingUnit), sampleRate, tags...)
+	}
+
+	if err := checkSampleRate(sampleRate); err != nil {
+		return err
+	}
+
+	if !c.fire(stat, sampleRate) {
+		return nil
+	}
+	if c.rt != nil {
+		return c.enqueue(rtItem{c, metricTypeDuration, stat, int64(d), 0, sampleRate, tags}) // checked off the caller path
+	}
+	var ok bool
+	if stat, ok, err = c.admit(stat, tags); !ok {
+		return err
+	}
+
+	return c.send(stat, c.durationValue(d), "ms", sampleRate, tags...)
+}
+
+// durationValue return d as sent by TimingDuration without aggregation
+func (c *clientConn) durationValue(d time.Duration) interface{} {
+	if !c.floatTimings {
+		return int64(d / c.timingUnit)
+	}
+	return strconv.FormatFloat(float64(d)/float64(c.timingUnit), 'f', -1, 64)
+}
+
+// GaugeBytes set a size in bytes of a particular event, the bucket is suffixed with _bytes.
+// Sizes beyond the int64 range are clamped instead of wrapping to a negative value.
+func GaugeBytes(stat string, n uint64, tags ...string) {
+	Gauge(withUnit(stat, unitSuffixBytes), clampUint64(n), tags...)
+}
+
+// clampUint64 convert n to int64, saturating at math.MaxInt64
+func clampUint64(n uint64) int64 {
+	if n > math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return int64(n)
+}
+
+// IncrTotal increment a particular event, the bucket is suffixed with _total
+func IncrTotal(stat string, tags ...string) {
+	IncrTotalByVal(stat, 1, tags...)
+}
+
+// IncrTotalByVal increment a particular event with value, the bucket is suffixed with _total
+func IncrTotalByVal(stat string, val int64, tags ...string) {
+	IncrByVal(withUnit(stat, unitSuffixTotal), val, tags...)
+}
@@ -0,0 +1,174 @@
+package statsd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_withUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		stat   string
+		suffix string
+		want   string
+	}{
+		{
+			name:   "append",
+			stat:   "db.query",
+			suffix: unitSuffixMillis,
+			want:   "db.query_ms",
+		},
+		{
+			name:   "already-suffixed",
+			stat:   "resp.size_bytes",
+			suffix: unitSuffixBytes,
+			want:   "resp.size_bytes",
+		},
+		{
+			name:   "other-suffix",
+			stat:   "orders_ms",
+			suffix: unitSuffixTotal,
+			want:   "orders_ms_total",
+		},
+		{
+			name:   "empty",
+			stat:   "",
+			suffix: unitSuffixTotal,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withUnit(tt.stat, tt.suffix); got != tt.want {
+				t.Fatalf("[%s] got: %s <=> want: %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_clampUint64(t *testing.T) {
+	tests := []struct {
+		name string
+		n    uint64
+		want int64
+	}{
+		{
+			name: "zero",
+			n:    0,
+			want: 0,
+		},
+		{
+			name: "in-range",
+			n:    1 << 40,
+			want: 1 << 40,
+		},
+		{
+			name: "max-int64",
+			n:    math.MaxInt64,
+			want: math.MaxInt64,
+		},
+		{
+			name: "overflow",
+			n:    math.MaxUint64,
+			want: math.MaxInt64,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampUint64(tt.n); got != tt.want {
+				t.Fatalf("[%s] got: %d <=> want: %d", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ClientTimingDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"millisecond", nil, "db:12|ms"},
+		{"microsecond", []Option{WithTimingUnit(time.Microsecond)}, "db:12437|ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, l := newTestClient(t, "")
+			c, err := New(l.LocalAddr().String(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			c.TimingDuration("db", 12437*time.Microsecond)
+			if got := readPacket(t, l); got != tt.want {
+				t.Fatalf("got: %s <=> want: %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New("127.0.0.1:8125", WithTimingUnit(0)); err != ErrInvalidTimingUnit {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidTimingUnit)
+	}
+}
+
+func Test_WithFloatTimings(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"millisecond", []Option{WithFloatTimings()}, "db:0.437|ms"},
+		{"microsecond", []Option{WithFloatTimings(), WithTimingUnit(time.Microsecond)}, "db:437|ms"},
+		{"realtime", []Option{WithFloatTimings(), WithRealTime(8, 0)}, "db:0.437|ms"},
+		{"whole", nil, "db:0|ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, l := newTestClient(t, "")
+			c, err := New(l.LocalAddr().String(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			c.TimingDuration("db", 437*time.Microsecond)
+			if got := readPacket(t, l); got != tt.want {
+				t.Fatalf("got: %s <=> want: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_TimingByValueFloat(t *testing.T) {
+	l := setupTestDefault(t, &Config{Enable: true})
+	c, err := New(l.LocalAddr().String(), WithFloatTimings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefault(c)
+
+	// the package helpers use the conversion of the default client
+	TimingByValue("db", 437*time.Microsecond)
+	if got := readPacket(t, l); got != "db:0.437|ms" {
+		t.Fatalf("got: %s <=> want: db:0.437|ms", got)
+	}
+}
+
+func Test_TimingDuration(t *testing.T) {
+	restoreDefault(t)
+	c, l := newTestClient(t, "")
+	c.timingUnit = time.Microsecond
+	SetDefault(c)
+
+	// the _ms bucket holds ms, not the unit of the client
+	TimingDuration("db", 12437*time.Microsecond)
+	if got, want := readPacket(t, l), "db_ms:12|ms"; got != want {
+		t.Fatalf("got: %s <=> want: %s", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate report every invalid field of cfg, the host and the port are only
+// required when enabled without Hosts. The errors wrap the Err* of the client options where
+// they exist, e.g. errors.Is(err, ErrInvalidSampleRate).
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return errors.New("statsd: nil config")
+	}
+
+	var errs []error
+	if cfg.Enable && cfg.Host == "" && len(cfg.Hosts) == 0 {
+		errs = append(errs, errors.New("statsd: Host is empty"))
+	}
+	if cfg.Enable && len(cfg.Hosts) == 0 && (cfg.Port <= 0 || cfg.Port > 65535) {
+		errs = append(errs, fmt.Errorf("statsd: Port %d out of 1-65535", cfg.Port))
+	}
+	if err := checkSampleRate(cfg.SampleRate); err != nil {
+		errs = append(errs, fmt.Errorf("statsd: SampleRate %g: %w", cfg.SampleRate, err))
+	}
+	if err := cfg.SampleSchedule.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("statsd: SampleSchedule: %w", err))
+	}
+	if _, err := newSampleRates(cfg.SampleRates); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.NameRules.MaxLength < 0 {
+		errs = append(errs, fmt.Errorf("statsd: NameRules.MaxLength %d: %w", cfg.NameRules.MaxLength, ErrInvalidNameLength))
+	}
+	if cfg.TagValueLimit < 0 {
+		errs = append(errs, fmt.Errorf("statsd: TagValueLimit %d: %w", cfg.TagValueLimit, ErrInvalidTagLimit))
+	}
+	if err := checkPatterns("Allow", cfg.Allow); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkPatterns("Deny", cfg.Deny); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.RateLimit.Metrics < 0 || cfg.RateLimit.Bytes < 0 {
+		errs = append(errs, fmt.Errorf("statsd: RateLimit %+v: %w", cfg.RateLimit, ErrInvalidRateLimit))
+	}
+	if !cfg.Protocol.valid() {
+		errs = append(errs, fmt.Errorf("statsd: %s: %w", cfg.Protocol, ErrInvalidProtocol))
+	}
+	if cfg.ConnectTimeout < 0 {
+		errs = append(errs, fmt.Errorf("statsd: ConnectTimeout %s: %w", cfg.ConnectTimeout, ErrInvalidTimeout))
+	}
+	if cfg.WriteTimeout < 0 {
+		errs = append(errs, fmt.Errorf("statsd: WriteTimeout %s: %w", cfg.WriteTimeout, ErrInvalidTimeout))
+	}
+	if cfg.SendBuffer < 0 {
+		errs = append(errs, fmt.Errorf("statsd: SendBuffer %d: %w", cfg.SendBuffer, ErrInvalidSendBuffer))
+	}
+	if cfg.ExtensionSchema < 0 {
+		errs = append(errs, fmt.Errorf("statsd: ExtensionSchema %d: %w", cfg.ExtensionSchema, ErrInvalidExtensionSchema))
+	}
+	if cfg.AggregationWindow < 0 {
+		errs = append(errs, fmt.Errorf("statsd: AggregationWindow %s is negative", cfg.AggregationWindow))
+	}
+	if cfg.MaxBatchDelay < 0 {
+		errs = append(errs, fmt.Errorf("statsd: MaxBatchDelay %s: %w", cfg.MaxBatchDelay, ErrInvalidBatchDelay))
+	}
+	if cfg.QueueSize < 0 {
+		errs = append(errs, fmt.Errorf("statsd: QueueSize %d: %w", cfg.QueueSize, ErrInvalidQueueSize))
+	}
+	if !cfg.OverflowPolicy.valid() {
+		errs = append(errs, fmt.Errorf("statsd: unknown %s", cfg.OverflowPolicy))
+	}
+	if cfg.SendWorkers < 0 {
+		errs = append(errs, fmt.Errorf("statsd: SendWorkers %d: %w", cfg.SendWorkers, ErrInvalidConnections))
+	}
+	if cfg.OverflowTimeout < 0 {
+		errs = append(errs, fmt.Errorf("statsd: OverflowTimeout %s is negative", cfg.OverflowTimeout))
+	}
+	if cfg.GapThreshold < 0 {
+		errs = append(errs, fmt.Errorf("statsd: GapThreshold %s is negative", cfg.GapThreshold))
+	}
+	return errors.Join(errs...)
+}
+
+// SetupE is Setup refusing an invalid config, see Config.Validate, in which
+// case the previous config stays in place
+func SetupE(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	Setup(cfg)
+	return nil
+}
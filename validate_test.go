@@ -0,0 +1,75 @@
+package statsd
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+		want []string // parts of the error, none if valid
+	}{
+		{"valid", &Config{Host: "127.0.0.1", Port: 8125, Enable: true, SampleRate: 0.5}, nil},
+		{"disabled without address", &Config{}, nil},
+		{"hosts", &Config{Hosts: []string{"a:8125", "b:8125"}, Enable: true}, nil},
+		{"nil", nil, []string{"nil config"}},
+		{"address", &Config{Enable: true}, []string{"Host is empty", "Port 0 out of 1-65535"}},
+		{"port range", &Config{Host: "h", Port: 70000, Enable: true}, []string{"Port 70000"}},
+		{"sample rate", &Config{SampleRate: -1}, []string{"SampleRate -1"}},
+		{"sample rate NaN", &Config{SampleRate: float32(math.NaN())}, []string{"SampleRate NaN"}},
+		{"schedule", &Config{SampleSchedule: &SampleSchedule{Windows: []SampleWindow{{Rate: 2}}}}, []string{"SampleSchedule"}},
+		{"sample rates", &Config{SampleRates: map[string]float32{"cache.*": 2, "db.[": 0.5}}, []string{`SampleRates["cache.*"]`, `SampleRates["db.["]`}},
+		{"durations", &Config{AggregationWindow: -1, MaxBatchDelay: -time.Second, GapThreshold: -1}, []string{"AggregationWindow", "MaxBatchDelay -1s", "GapThreshold"}},
+		{"name rules", &Config{NameRules: NameRules{MaxLength: -1}}, []string{"NameRules.MaxLength -1"}},
+		{"tag value limit", &Config{TagValueLimit: -1}, []string{"TagValueLimit -1"}},
+		{"filters", &Config{Allow: []string{"http.*", "["}, Deny: []string{"db.["}}, []string{`Allow "["`, `Deny "db.["`}},
+		{"rate limit", &Config{RateLimit: RateLimit{Bytes: -1}}, []string{"RateLimit {Metrics:0 Bytes:-1}"}},
+		{"protocol", &Config{Protocol: 9}, []string{"Protocol(9)"}},
+		{"timeouts", &Config{ConnectTimeout: -1, WriteTimeout: -time.Second}, []string{"ConnectTimeout -1ns", "WriteTimeout -1s"}},
+		{"send buffer", &Config{SendBuffer: -1}, []string{"SendBuffer -1"}},
+		{"extension schema", &Config{ExtensionSchema: -1}, []string{"ExtensionSchema -1"}},
+		{"send queue", &Config{QueueSize: -1, OverflowPolicy: 7, OverflowTimeout: -1}, []string{"QueueSize -1", "OverflowPolicy(7)", "OverflowTimeout"}},
+		{"send workers", &Config{SendWorkers: -1}, []string{"SendWorkers -1"}},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: %v <=> want: nil", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: nil <=> want: %v", tt.name, tt.want)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: %v <=> want: %s", tt.name, err, want)
+			}
+		}
+	}
+
+	if err := (&Config{SampleRate: 2}).Validate(); !errors.Is(err, ErrInvalidSampleRate) {
+		t.Fatalf("err: %v <=> want: %v", err, ErrInvalidSampleRate)
+	}
+}
+
+func Test_SetupE(t *testing.T) {
+	l := setupTestDefault(t, &Config{Project: "valid", Enable: true})
+
+	if err := SetupE(&Config{Enable: true}); err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	// the previous config is kept
+	Gauge("queue", 1)
+	if got := readPacket(t, l); got != "valid.queue:1|g" {
+		t.Fatalf("got: %s <=> want: valid.queue:1|g", got)
+	}
+}
@@ -0,0 +1,63 @@
+package statsd
+
+import "time"
+
+// Aggregate is what a flush window holds for a bucket, all tags together
+type Aggregate struct {
+	Name       string // prefixed bucket
+	Start, End time.Time
+
+	Count    float64 // sum of the counters
+	Gauge    float64 // last value of the gauge, with WithAggregation
+	HasGauge bool
+	Timings  []int64 // timer samples in milliseconds, with WithAggregation
+}
+
+// Watch call fn at each flush with the aggregate of the window for the
+// bucket stat of c, even if empty, for in-process reactions (shed load, open
+// a breaker...) without waiting for the external alerting. fn is called
+// from the flush goroutine and must not block, it may send metrics.
+func (c *Client) Watch(stat string, fn func(window Aggregate)) {
+	stat, _ = c.hashName(stat, nil)
+	w := watch{bucket: c.bucket(stat), fn: fn}
+
+	c.watchMu.Lock()
+	c.watches = append(c.watches, w)
+	c.watchMu.Unlock()
+}
+
+type watch struct {
+	bucket string
+	fn     func(window Aggregate)
+}
+
+// runWatches call the watchers with the aggregates of the flushed buffers
+func (c *clientConn) runWatches(b buffers) {
+	c.watchMu.Lock()
+	ws := c.watches
+	start := c.windowStart
+	now := c.clock.Now()
+	c.windowStart = now
+	c.watchMu.Unlock()
+
+	for _, w := range ws {
+		a := Aggregate{Name: w.bucket, Start: start, End: now}
+		for _, count := range b.counts {
+			if count.name == w.bucket {
+				a.Count += count.count
+			}
+		}
+		for _, g := range b.gauges {
+			if g.name == w.bucket {
+				a.Gauge = g.float()
+				a.HasGauge = true
+			}
+		}
+		for _, t := range b.timers {
+			if t.name == w.bucket {
+				a.Timings = append(a.Timings, t.samples...)
+			}
+		}
+		w.fn(a)
+	}
+}
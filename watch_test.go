@@ -0,0 +1,40 @@
+package statsd
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Watch(t *testing.T) {
+	_, l := newTestClient(t, "")
+	c, err := New(l.LocalAddr().String(), WithPrefix("api"), WithAggregation(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var windows []Aggregate
+	c.Watch("errors", func(w Aggregate) { windows = append(windows, w) })
+	c.WithPrefix("db").Watch("latency", func(w Aggregate) { windows = append(windows, w) })
+
+	c.Incr("errors", 1, "code:500")
+	c.Incr("errors", 1, "code:502")
+	c.Incr("other", 1)
+	c.WithPrefix("db").Timing("latency", 3)
+	c.WithPrefix("db").Timing("latency", 5, "op:select")
+	c.flush()
+	c.flush() // an empty window is reported too
+
+	if len(windows) != 4 {
+		t.Fatalf("windows: %+v <=> want: 4", windows)
+	}
+	if w := windows[0]; w.Name != "api.errors" || w.Count != 2 || !w.End.After(w.Start) {
+		t.Fatalf("window: %+v <=> want: api.errors counting 2", w)
+	}
+	if w := windows[1]; w.Name != "api.db.latency" || len(w.Timings) != 2 || w.Timings[0]+w.Timings[1] != 8 {
+		t.Fatalf("window: %+v <=> want: api.db.latency timing 3 and 5", w)
+	}
+	if w := windows[2]; w.Count != 0 || w.Start != windows[0].End {
+		t.Fatalf("window: %+v <=> want: empty, following the first", w)
+	}
+}
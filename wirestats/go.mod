@@ -0,0 +1,10 @@
+module github.com/sunlit-coder/statsd/wirestats
+
+go 1.22
+
+require (
+	github.com/google/wire v0.7.0
+	github.com/sunlit-coder/statsd v0.0.0
+)
+
+replace github.com/sunlit-coder/statsd => ../
@@ -0,0 +1,23 @@
+// Package wirestats provide a statsd.Statter to github.com/google/wire injectors
+package wirestats
+
+import (
+	"context"
+
+	"github.com/google/wire"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+// ProviderSet provide a statsd.Statter from a context and a *statsd.Config
+var ProviderSet = wire.NewSet(New)
+
+// New build a statsd.Statter from cfg, the returned cleanup closes it
+func New(ctx context.Context, cfg *statsd.Config) (statsd.Statter, func(), error) {
+	s, err := statsd.NewStatter(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, func() { s.Close() }, nil
+}
@@ -0,0 +1,42 @@
+package wirestats
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/sunlit-coder/statsd"
+)
+
+func Test_New(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	s, cleanup, err := New(context.Background(), &statsd.Config{
+		Project: "wire",
+		Host:    "127.0.0.1",
+		Port:    l.LocalAddr().(*net.UDPAddr).Port,
+		Enable:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Timing("start", 1); err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+
+	// the cleanup closes the statter
+	if err := s.Timing("stopped", 1); !errors.Is(err, statsd.ErrClosed) {
+		t.Fatalf("err: %v <=> want: %v", err, statsd.ErrClosed)
+	}
+
+	if _, _, err := New(context.Background(), &statsd.Config{Host: "statsd.invalid", Port: 8125, Enable: true}); err == nil {
+		t.Fatal("no error with an unresolvable host")
+	}
+}